@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// drumRackFirstNote is the note of the bottom-left Drum Rack pad (C1), which is
+// also where Simpler's slicing mode places its first slice
+const drumRackFirstNote = 36
+
+// Simpler playback and slicing settings
+const (
+	simplerPlaybackOneShot = 1
+	simplerPlaybackSlicing = 2
+	simplerSlicingManual   = 3
+)
+
+// abletonPath returns the preset path with the given extension (.adg or .adv)
+// for a combined WAV
+func abletonPath(wavPath, ext string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ext
+}
+
+// abletonDocument wraps a preset body in the root element Live 11 writes
+func abletonDocument(body func(w *xmlWriter)) *xmlWriter {
+	w := newXMLWriter("\t")
+	w.open("Ableton", "MajorVersion", 5, "MinorVersion", "11.0_433", "SchemaChangeCount", 3,
+		"Creator", "wavslice "+Version, "Revision", "")
+	body(w)
+	w.close("Ableton")
+	return w
+}
+
+// writeSampleRef writes a reference to the combined WAV. The relative path is
+// resolved against the preset, which is written next to the WAV; the absolute
+// path is a fallback for presets moved elsewhere.
+func writeSampleRef(w *xmlWriter, layout BatchLayout) {
+	abs, err := filepath.Abs(layout.Path)
+	if err != nil {
+		abs = layout.Path
+	}
+
+	w.open("SampleRef")
+	w.open("FileRef")
+	w.value("RelativePathType", 1)
+	w.value("RelativePath", filepath.Base(layout.Path))
+	w.value("Path", filepath.ToSlash(abs))
+	w.value("Type", 1)
+	w.value("LivePackName", "")
+	w.value("LivePackId", "")
+	w.value("OriginalFileSize", 0)
+	w.value("OriginalCrc", 0)
+	w.close("FileRef")
+	w.value("LastModDate", 0)
+	w.value("DefaultDuration", layout.NumSamples())
+	w.value("DefaultSampleRate", layout.SampleRate)
+	w.close("SampleRef")
+}
+
+// writeSimpler writes a Simpler playing frames start to end-1 of the combined
+// WAV. Slicing mode adds a manual slice point at every slice boundary.
+func writeSimpler(w *xmlWriter, layout BatchLayout, name string, start, end, playbackMode int) {
+	w.open("OriginalSimpler", "Id", 0)
+	w.value("UserName", name)
+	w.open("On")
+	w.value("Manual", true)
+	w.close("On")
+	w.open("Player")
+	w.open("MultiSampleMap")
+	w.open("SampleParts")
+	w.open("MultiSamplePart", "Id", 0, "HasImportedSlicePoints", true, "NeedsAnalysisData", false)
+	w.value("LomId", 0)
+	w.value("Name", name)
+	w.open("KeyRange")
+	w.value("Min", 0)
+	w.value("Max", 127)
+	w.value("CrossfadeMin", 0)
+	w.value("CrossfadeMax", 127)
+	w.close("KeyRange")
+	w.open("VelocityRange")
+	w.value("Min", 1)
+	w.value("Max", 127)
+	w.value("CrossfadeMin", 1)
+	w.value("CrossfadeMax", 127)
+	w.close("VelocityRange")
+	w.value("RootKey", 60)
+	w.value("Detune", 0)
+	w.value("Volume", 1)
+	w.value("Panorama", 0)
+	w.value("SampleStart", start)
+	w.value("SampleEnd", end-1)
+	if playbackMode == simplerPlaybackSlicing {
+		w.value("SlicingStyle", simplerSlicingManual)
+		w.open("ManualSlicePoints")
+		for i := range layout.Slots {
+			sliceStart, _ := layout.sliceBounds(i)
+			w.empty("SlicePoint", "TimeInSeconds", float64(sliceStart)/float64(layout.SampleRate), "Rank", 0, "NormalizedEnergy", 1)
+		}
+		w.close("ManualSlicePoints")
+	}
+	writeSampleRef(w, layout)
+	w.close("MultiSamplePart")
+	w.close("SampleParts")
+	w.close("MultiSampleMap")
+	w.close("Player")
+	w.open("Globals")
+	w.value("PlaybackMode", playbackMode)
+	w.close("Globals")
+	w.close("OriginalSimpler")
+}
+
+// encodeDrumRack builds a Drum Rack preset with one one-shot Simpler pad per
+// slice, from C1 upward
+func encodeDrumRack(layout BatchLayout) string {
+	return abletonDocument(func(w *xmlWriter) {
+		w.open("GroupDevicePreset")
+		w.value("OverwriteProtectionNumber", 2816)
+		w.open("Device")
+		w.open("DrumGroupDevice", "Id", 0)
+		w.value("UserName", strings.TrimSuffix(filepath.Base(layout.Path), ".wav"))
+		w.open("On")
+		w.value("Manual", true)
+		w.close("On")
+		w.close("DrumGroupDevice")
+		w.close("Device")
+		w.empty("PresetRef")
+
+		w.open("BranchPresets")
+		for i, slot := range layout.Slots {
+			name := slotName(slot, i)
+			start, end := layout.sliceBounds(i)
+
+			w.open("DrumBranchPreset", "Id", i)
+			w.value("Name", name)
+			w.value("IsSoloed", false)
+			w.open("DevicePresets")
+			w.open("AbletonDevicePreset", "Id", 0)
+			w.value("OverwriteProtectionNumber", 2816)
+			w.open("Device")
+			writeSimpler(w, layout, name, start, end, simplerPlaybackOneShot)
+			w.close("Device")
+			w.empty("PresetRef")
+			w.close("AbletonDevicePreset")
+			w.close("DevicePresets")
+			w.open("ZoneSettings")
+			// Drum Rack counts receiving notes down from the top of the MIDI range
+			w.value("ReceivingNote", 128-(drumRackFirstNote+i))
+			w.value("SendingNote", 60)
+			w.value("ChokeGroup", 0)
+			w.close("ZoneSettings")
+			w.close("DrumBranchPreset")
+		}
+		w.close("BranchPresets")
+		w.empty("ReturnBranchPresets")
+		w.close("GroupDevicePreset")
+	}).String()
+}
+
+// encodeSimplerSlices builds a Simpler preset in slicing mode with a manual slice
+// point at each slice, so slices play from C1 upward
+func encodeSimplerSlices(layout BatchLayout) string {
+	return abletonDocument(func(w *xmlWriter) {
+		name := strings.TrimSuffix(filepath.Base(layout.Path), ".wav")
+		w.open("AbletonDevicePreset")
+		w.value("OverwriteProtectionNumber", 2816)
+		w.open("Device")
+		writeSimpler(w, layout, name, 0, layout.NumSamples(), simplerPlaybackSlicing)
+		w.close("Device")
+		w.empty("PresetRef")
+		w.close("AbletonDevicePreset")
+	}).String()
+}
+
+// writeGzip writes data gzip-compressed, as Live stores presets
+func writeGzip(path string, data string) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// writeAbletonPresets writes a Drum Rack (.adg) and a slicing Simpler (.adv)
+// for a batch
+func writeAbletonPresets(layout BatchLayout) ([]string, error) {
+	var created []string
+	for _, p := range []struct {
+		ext    string
+		encode func(BatchLayout) string
+	}{
+		{".adg", encodeDrumRack},
+		{".adv", encodeSimplerSlices},
+	} {
+		path := abletonPath(layout.Path, p.ext)
+		if err := writeGzip(path, p.encode(layout)); err != nil {
+			return created, err
+		}
+		created = append(created, path)
+	}
+	return created, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// Ableton preset helper functions
+// ============================================================================
+
+// readGzip returns the decompressed contents of a gzip file
+func readGzip(t *testing.T, path string) []byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%s is not gzip: %v", path, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return data
+}
+
+// abletonValue is a <Name Value="..." /> property
+type abletonValue struct {
+	Value string `xml:"Value,attr"`
+}
+
+// ============================================================================
+// Ableton preset tests
+// ============================================================================
+
+func TestWriteAbletonPresets(t *testing.T) {
+	dir := t.TempDir()
+	slots := []Slot{{FileInfo: FileInfo{Path: "/s/kick_01.wav"}}, {}, {FileInfo: FileInfo{Path: "/s/snare.wav"}}}
+	layout := BatchLayout{Path: filepath.Join(dir, "kit_3slices_batch001.wav"), SampleRate: 44100, Channels: 1, SliceLength: 100, Slots: slots}
+
+	paths, err := writeAbletonPresets(layout)
+	if err != nil {
+		t.Fatalf("writeAbletonPresets failed: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected .adg and .adv, got %v", paths)
+	}
+
+	t.Run("drum rack", func(t *testing.T) {
+		var doc struct {
+			Branches []struct {
+				Name    abletonValue `xml:"Name"`
+				Start   abletonValue `xml:"DevicePresets>AbletonDevicePreset>Device>OriginalSimpler>Player>MultiSampleMap>SampleParts>MultiSamplePart>SampleStart"`
+				End     abletonValue `xml:"DevicePresets>AbletonDevicePreset>Device>OriginalSimpler>Player>MultiSampleMap>SampleParts>MultiSamplePart>SampleEnd"`
+				Path    abletonValue `xml:"DevicePresets>AbletonDevicePreset>Device>OriginalSimpler>Player>MultiSampleMap>SampleParts>MultiSamplePart>SampleRef>FileRef>RelativePath"`
+				Receive abletonValue `xml:"ZoneSettings>ReceivingNote"`
+			} `xml:"GroupDevicePreset>BranchPresets>DrumBranchPreset"`
+		}
+		if err := xml.Unmarshal(readGzip(t, paths[0]), &doc); err != nil {
+			t.Fatalf(".adg is not valid XML: %v", err)
+		}
+		if len(doc.Branches) != 3 {
+			t.Fatalf("expected 3 pads, got %d", len(doc.Branches))
+		}
+		b := doc.Branches[2]
+		if b.Name.Value != "snare" || b.Start.Value != "200" || b.End.Value != "299" || b.Receive.Value != "90" {
+			t.Errorf("unexpected third pad %+v", b)
+		}
+		if b.Path.Value != "kit_3slices_batch001.wav" {
+			t.Errorf("unexpected sample path %q", b.Path.Value)
+		}
+		if doc.Branches[1].Name.Value != "Slice 02" {
+			t.Errorf("expected empty slot to be named Slice 02, got %q", doc.Branches[1].Name.Value)
+		}
+	})
+
+	t.Run("slicing simpler", func(t *testing.T) {
+		var doc struct {
+			Mode   abletonValue `xml:"AbletonDevicePreset>Device>OriginalSimpler>Globals>PlaybackMode"`
+			End    abletonValue `xml:"AbletonDevicePreset>Device>OriginalSimpler>Player>MultiSampleMap>SampleParts>MultiSamplePart>SampleEnd"`
+			Slices []struct {
+				Time string `xml:"TimeInSeconds,attr"`
+			} `xml:"AbletonDevicePreset>Device>OriginalSimpler>Player>MultiSampleMap>SampleParts>MultiSamplePart>ManualSlicePoints>SlicePoint"`
+		}
+		if err := xml.Unmarshal(readGzip(t, paths[1]), &doc); err != nil {
+			t.Fatalf(".adv is not valid XML: %v", err)
+		}
+		if doc.Mode.Value != "2" || doc.End.Value != "299" {
+			t.Errorf("expected slicing mode over the whole file, got mode %s end %s", doc.Mode.Value, doc.End.Value)
+		}
+		if len(doc.Slices) != 3 || doc.Slices[1].Time != "0.0022675736961451248" {
+			t.Errorf("unexpected slice points %+v", doc.Slices)
+		}
+	})
+}
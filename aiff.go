@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// extended80 encodes a sample rate as the 80-bit IEEE extended float AIFF
+// stores in its COMM chunk
+func extended80(rate int) [10]byte {
+	var b [10]byte
+	if rate <= 0 {
+		return b
+	}
+	exp := bits.Len64(uint64(rate)) - 1
+	binary.BigEndian.PutUint16(b[0:2], uint16(16383+exp))
+	binary.BigEndian.PutUint64(b[2:10], uint64(rate)<<(63-exp))
+	return b
+}
+
+// aiffChunk encodes an IFF chunk, padded to an even length
+func aiffChunk(id string, body []byte) []byte {
+	chunk := make([]byte, 8, 8+len(body)+1)
+	copy(chunk, id)
+	binary.BigEndian.PutUint32(chunk[4:], uint32(len(body)))
+	chunk = append(chunk, body...)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// encodeAIFF encodes 16-bit big-endian PCM as an AIFF file. extra holds
+// complete chunks (see aiffChunk) written between COMM and SSND.
+func encodeAIFF(samples [][]float64, sampleRate int, extra ...[]byte) []byte {
+	numChannels := len(samples)
+	numFrames := 0
+	if numChannels > 0 {
+		numFrames = len(samples[0])
+	}
+
+	comm := make([]byte, 18)
+	binary.BigEndian.PutUint16(comm[0:2], uint16(numChannels))
+	binary.BigEndian.PutUint32(comm[2:6], uint32(numFrames))
+	binary.BigEndian.PutUint16(comm[6:8], 16)
+	rate := extended80(sampleRate)
+	copy(comm[8:], rate[:])
+
+	// SSND starts with an offset and block size, both unused here
+	ssnd := make([]byte, 8, 8+2*numChannels*numFrames)
+	for i := 0; i < numFrames; i++ {
+		for ch := 0; ch < numChannels; ch++ {
+			v := math.Max(-1, math.Min(1, samples[ch][i]))
+			ssnd = binary.BigEndian.AppendUint16(ssnd, uint16(int16(v*32767)))
+		}
+	}
+
+	var form bytes.Buffer
+	form.WriteString("AIFF")
+	form.Write(aiffChunk("COMM", comm))
+	for _, chunk := range extra {
+		form.Write(chunk)
+	}
+	form.Write(aiffChunk("SSND", ssnd))
+	return aiffChunk("FORM", form.Bytes())
+}
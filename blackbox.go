@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The Blackbox has a 4×4 pad grid; a preset is a folder holding preset.xml and
+// the samples it plays
+const (
+	blackboxColumns = 4
+	blackboxPads    = 16
+)
+
+// blackboxPresetDirs returns one preset folder per 16 slices, named after the
+// combined WAV and, when there is more than one, lettered like pad banks
+func blackboxPresetDirs(layout BatchLayout) []string {
+	base := strings.TrimSuffix(layout.Path, ".wav")
+	pages := (len(layout.Slots) + blackboxPads - 1) / blackboxPads
+	if pages <= 1 {
+		return []string{base}
+	}
+	dirs := make([]string, pages)
+	for p := range dirs {
+		dirs[p] = fmt.Sprintf("%s_%c", base, 'A'+p)
+	}
+	return dirs
+}
+
+// blackboxSampleName returns the file name a slice is stored under in its preset
+// folder, which is also the label the Blackbox shows on the pad
+func blackboxSampleName(slot Slot, index int) string {
+	return fmt.Sprintf("%02d %s.wav", index+1, sanitizeFilename(slotName(slot, index)))
+}
+
+// encodeBlackboxPreset builds preset.xml for up to 16 slices, one per pad from
+// the top left, each a one-shot of its own sample file
+func encodeBlackboxPreset(slots []Slot, first, sliceLength int) string {
+	w := newXMLWriter("  ")
+	w.open("document", "version", 2)
+	w.open("session")
+	for i, slot := range slots {
+		w.open("cell", "row", i/blackboxColumns, "column", i%blackboxColumns, "layer", 0,
+			"filename", `.\`+blackboxSampleName(slot, first+i), "type", "sample")
+		w.empty("params",
+			"gaindb", 0, "pitch", 0, "panpos", 0,
+			"samtrigtype", 0, "loopmode", 0, "loopmodes", 0, "midimode", 0, "reverse", 0, "cellmode", 0,
+			"envattack", 0, "envdecay", 0, "envsus", 1000, "envrel", 200,
+			"samstart", 0, "samlen", sliceLength, "loopstart", 0, "looplen", sliceLength,
+			"rootnote", 0, "chokegrp", 0, "outputbus", 0, "polymode", 0)
+		w.empty("slices")
+		w.close("cell")
+	}
+	w.close("session")
+	w.close("document")
+	return w.String()
+}
+
+// writeBlackboxPresets writes a preset folder per 16 slices with the slices as
+// individual WAVs
+func writeBlackboxPresets(layout BatchLayout) ([]string, error) {
+	wav, err := readWavFile(layout.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for p, dir := range blackboxPresetDirs(layout) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return created, err
+		}
+
+		first := p * blackboxPads
+		slots := layout.Slots[first:min(first+blackboxPads, len(layout.Slots))]
+		for i, slot := range slots {
+			start, end := layout.sliceBounds(first + i)
+			slice := make([][]float64, len(wav.Samples))
+			for ch := range slice {
+				n := len(wav.Samples[ch])
+				slice[ch] = wav.Samples[ch][min(start, n):min(end, n)]
+			}
+			path := filepath.Join(dir, blackboxSampleName(slot, first+i))
+			if err := writeWavFile(path, slice, layout.SampleRate, layout.Channels); err != nil {
+				return created, err
+			}
+		}
+
+		path := filepath.Join(dir, "preset.xml")
+		if err := os.WriteFile(path, []byte(encodeBlackboxPreset(slots, first, layout.SliceLength)), 0644); err != nil {
+			return created, err
+		}
+		created = append(created, path)
+	}
+	return created, nil
+}
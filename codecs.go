@@ -0,0 +1,196 @@
+package main
+
+import "encoding/binary"
+
+// WAV format tags for the compressed and companded formats wavslice can decode
+const (
+	formatMSADPCM  = 0x0002
+	formatALaw     = 0x0006
+	formatMuLaw    = 0x0007
+	formatIMAADPCM = 0x0011
+)
+
+// ============================================================================
+// G.711 µ-law and A-law
+// ============================================================================
+
+// mulawToLinear expands an 8-bit G.711 µ-law code to a 16-bit linear sample
+func mulawToLinear(b byte) int16 {
+	u := ^b
+	exponent := (u >> 4) & 0x07
+	mantissa := int32(u & 0x0F)
+	sample := ((mantissa << 3) + 0x84) << exponent
+	sample -= 0x84
+	if u&0x80 != 0 {
+		return int16(-sample)
+	}
+	return int16(sample)
+}
+
+// alawToLinear expands an 8-bit G.711 A-law code to a 16-bit linear sample
+func alawToLinear(b byte) int16 {
+	a := b ^ 0x55
+	segment := (a >> 4) & 0x07
+	sample := int32(a&0x0F) << 4
+	switch segment {
+	case 0:
+		sample += 8
+	case 1:
+		sample += 0x108
+	default:
+		sample = (sample + 0x108) << (segment - 1)
+	}
+	// In A-law a set sign bit means positive
+	if a&0x80 != 0 {
+		return int16(sample)
+	}
+	return int16(-sample)
+}
+
+// ============================================================================
+// IMA ADPCM
+// ============================================================================
+
+var imaStepTable = [89]int32{
+	7, 8, 9, 10, 11, 12, 13, 14, 16, 17, 19, 21, 23, 25, 28, 31, 34, 37, 41, 45,
+	50, 55, 60, 66, 73, 80, 88, 97, 107, 118, 130, 143, 157, 173, 190, 209, 230,
+	253, 279, 307, 337, 371, 408, 449, 494, 544, 598, 658, 724, 796, 876, 963,
+	1060, 1166, 1282, 1411, 1552, 1707, 1878, 2066, 2272, 2499, 2749, 3024, 3327,
+	3660, 4026, 4428, 4871, 5358, 5894, 6484, 7132, 7845, 8630, 9493, 10442,
+	11487, 12635, 13899, 15289, 16818, 18500, 20350, 22385, 24623, 27086, 29794,
+	32767,
+}
+
+var imaIndexTable = [16]int32{-1, -1, -1, -1, 2, 4, 6, 8, -1, -1, -1, -1, 2, 4, 6, 8}
+
+// imaState is the per-channel predictor of an IMA ADPCM decoder
+type imaState struct {
+	predictor int32
+	index     int32
+}
+
+func (s *imaState) decode(nibble byte) int16 {
+	step := imaStepTable[s.index]
+	diff := step >> 3
+	if nibble&1 != 0 {
+		diff += step >> 2
+	}
+	if nibble&2 != 0 {
+		diff += step >> 1
+	}
+	if nibble&4 != 0 {
+		diff += step
+	}
+	if nibble&8 != 0 {
+		s.predictor -= diff
+	} else {
+		s.predictor += diff
+	}
+	s.predictor = max(-32768, min(32767, s.predictor))
+	s.index = max(0, min(88, s.index+imaIndexTable[nibble]))
+	return int16(s.predictor)
+}
+
+// imaBlockFrames returns the frames held in an IMA ADPCM block of size bytes:
+// one from the header, then 8 per 4-byte group of each channel
+func imaBlockFrames(size, channels int) int {
+	if size < 4*channels {
+		return 0
+	}
+	return 1 + (size-4*channels)/(4*channels)*8
+}
+
+// decodeIMABlock appends the frames of one IMA ADPCM block to out. Each channel
+// starts with a 4-byte header (initial sample, step index, reserved) and the
+// nibbles follow in 4-byte groups per channel, low nibble first.
+func decodeIMABlock(block []byte, channels int, out [][]float64) [][]float64 {
+	frames := imaBlockFrames(len(block), channels)
+	if frames == 0 {
+		return out
+	}
+
+	states := make([]imaState, channels)
+	for ch := range states {
+		hdr := block[ch*4:]
+		states[ch] = imaState{
+			predictor: int32(int16(binary.LittleEndian.Uint16(hdr[0:2]))),
+			index:     max(0, min(88, int32(hdr[2]))),
+		}
+		out[ch] = append(out[ch], float64(states[ch].predictor)/32768.0)
+	}
+
+	data := block[4*channels:]
+	for group := 0; group < (frames-1)/8; group++ {
+		for ch := 0; ch < channels; ch++ {
+			for _, b := range data[(group*channels+ch)*4 : (group*channels+ch+1)*4] {
+				out[ch] = append(out[ch], float64(states[ch].decode(b&0x0F))/32768.0)
+				out[ch] = append(out[ch], float64(states[ch].decode(b>>4))/32768.0)
+			}
+		}
+	}
+	return out
+}
+
+// ============================================================================
+// Microsoft ADPCM
+// ============================================================================
+
+var msAdaptTable = [16]int32{230, 230, 230, 230, 307, 409, 512, 614, 768, 614, 512, 409, 307, 230, 230, 230}
+
+// msDefaultCoefs are the seven standard predictor pairs, used when the fmt chunk has none
+var msDefaultCoefs = [][2]int16{{256, 0}, {512, -256}, {0, 0}, {192, 64}, {240, 0}, {460, -208}, {392, -232}}
+
+// msBlockFrames returns the frames held in an MS ADPCM block of size bytes:
+// two from the header, then two per byte across the channels
+func msBlockFrames(size, channels int) int {
+	if size < 7*channels {
+		return 0
+	}
+	return 2 + (size-7*channels)*2/channels
+}
+
+// decodeMSBlock appends the frames of one MS ADPCM block to out. The header holds
+// per-channel predictor indexes, deltas and the two most recent samples (older
+// first in output order); nibbles follow high nibble first, interleaved by channel.
+func decodeMSBlock(block []byte, channels int, coefs [][2]int16, out [][]float64) [][]float64 {
+	frames := msBlockFrames(len(block), channels)
+	if frames == 0 {
+		return out
+	}
+
+	type msState struct {
+		c1, c2, delta, s1, s2 int32
+	}
+	le := binary.LittleEndian
+	states := make([]msState, channels)
+	for ch := range states {
+		pair := coefs[min(int(block[ch]), len(coefs)-1)]
+		states[ch] = msState{
+			c1:    int32(pair[0]),
+			c2:    int32(pair[1]),
+			delta: int32(int16(le.Uint16(block[channels+2*ch:]))),
+			s1:    int32(int16(le.Uint16(block[3*channels+2*ch:]))),
+			s2:    int32(int16(le.Uint16(block[5*channels+2*ch:]))),
+		}
+		out[ch] = append(out[ch], float64(states[ch].s2)/32768.0, float64(states[ch].s1)/32768.0)
+	}
+
+	ch := 0
+	for _, b := range block[7*channels : 7*channels+(frames-2)*channels/2] {
+		for _, nibble := range []byte{b >> 4, b & 0x0F} {
+			s := &states[ch]
+			signed := int32(nibble)
+			if signed >= 8 {
+				signed -= 16
+			}
+			predicted := (s.s1*s.c1+s.s2*s.c2)>>8 + signed*s.delta
+			predicted = max(-32768, min(32767, predicted))
+			s.s2, s.s1 = s.s1, predicted
+			s.delta = max(16, msAdaptTable[nibble]*s.delta>>8)
+
+			out[ch] = append(out[ch], float64(predicted)/32768.0)
+			ch = (ch + 1) % channels
+		}
+	}
+	return out
+}
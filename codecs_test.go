@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// Codec helper functions
+// ============================================================================
+
+// codecFmtBody returns a fmt chunk body for a compressed format, with an optional
+// cbSize extension
+func codecFmtBody(format, channels uint16, sampleRate uint32, blockAlign, bits uint16, extension []byte) []byte {
+	body := binary.LittleEndian.AppendUint16(nil, format)
+	body = binary.LittleEndian.AppendUint16(body, channels)
+	body = binary.LittleEndian.AppendUint32(body, sampleRate)
+	body = binary.LittleEndian.AppendUint32(body, sampleRate*uint32(blockAlign))
+	body = binary.LittleEndian.AppendUint16(body, blockAlign)
+	body = binary.LittleEndian.AppendUint16(body, bits)
+	if extension != nil {
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(extension)))
+		body = append(body, extension...)
+	}
+	return body
+}
+
+// imaMonoBlock builds an 8-byte mono IMA ADPCM block (9 frames)
+func imaMonoBlock(predictor int16, index byte, data [4]byte) []byte {
+	block := binary.LittleEndian.AppendUint16(nil, uint16(predictor))
+	block = append(block, index, 0)
+	return append(block, data[:]...)
+}
+
+// ============================================================================
+// G.711 tests
+// ============================================================================
+
+func TestG711(t *testing.T) {
+	tests := []struct {
+		name     string
+		expand   func(byte) int16
+		code     byte
+		expected int16
+	}{
+		{"µ-law zero", mulawToLinear, 0xFF, 0},
+		{"µ-law positive max", mulawToLinear, 0x80, 32124},
+		{"µ-law negative max", mulawToLinear, 0x00, -32124},
+		{"A-law smallest positive", alawToLinear, 0xD5, 8},
+		{"A-law smallest negative", alawToLinear, 0x55, -8},
+		{"A-law positive max", alawToLinear, 0xAA, 32256},
+		{"A-law negative max", alawToLinear, 0x2A, -32256},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.expand(tc.code); got != tc.expected {
+				t.Errorf("expand(0x%02x) = %d, expected %d", tc.code, got, tc.expected)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// ADPCM block tests
+// ============================================================================
+
+func TestDecodeIMABlock(t *testing.T) {
+	block := imaMonoBlock(1000, 0, [4]byte{0x07, 0x00, 0x00, 0x00})
+	out := decodeIMABlock(block, 1, make([][]float64, 1))
+
+	if len(out[0]) != 9 {
+		t.Fatalf("expected 9 frames, got %d", len(out[0]))
+	}
+	// Header sample, then nibble 7 at step 7 (+11), then nibble 0 at step 16 (+2)
+	for i, want := range []int{1000, 1011, 1013} {
+		if got := int(out[0][i] * 32768); got != want {
+			t.Errorf("frame %d = %d, expected %d", i, got, want)
+		}
+	}
+
+	t.Run("short block", func(t *testing.T) {
+		if out := decodeIMABlock(block[:3], 1, make([][]float64, 1)); len(out[0]) != 0 {
+			t.Errorf("expected no frames from a block shorter than its header, got %d", len(out[0]))
+		}
+		if out := decodeIMABlock(block[:4], 1, make([][]float64, 1)); len(out[0]) != 1 {
+			t.Errorf("expected the header frame only, got %d", len(out[0]))
+		}
+	})
+}
+
+func TestDecodeMSBlock(t *testing.T) {
+	// Predictor 0, delta 16, sample1 100, sample2 50, then nibbles 1 and 0
+	block := []byte{0}
+	block = binary.LittleEndian.AppendUint16(block, 16)
+	block = binary.LittleEndian.AppendUint16(block, 100)
+	block = binary.LittleEndian.AppendUint16(block, 50)
+	block = append(block, 0x10)
+
+	out := decodeMSBlock(block, 1, msDefaultCoefs, make([][]float64, 1))
+	expected := []int{50, 100, 116, 116}
+	if len(out[0]) != len(expected) {
+		t.Fatalf("expected %d frames, got %d", len(expected), len(out[0]))
+	}
+	for i, want := range expected {
+		if got := int(out[0][i] * 32768); got != want {
+			t.Errorf("frame %d = %d, expected %d", i, got, want)
+		}
+	}
+}
+
+// ============================================================================
+// Compressed file reading tests
+// ============================================================================
+
+func TestReadCompressedWavFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("µ-law", func(t *testing.T) {
+		path := filepath.Join(dir, "mulaw.wav")
+		os.WriteFile(path, riffFile(0,
+			riffChunk("fmt ", codecFmtBody(formatMuLaw, 1, 8000, 1, 8, []byte{}), true),
+			riffChunk("fact", binary.LittleEndian.AppendUint32(nil, 3), true),
+			riffChunk("data", []byte{0xFF, 0x80, 0x00}, true)), 0644)
+
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.NumSamples != 3 || wav.Samples[0][0] != 0 || wav.Samples[0][1] < 0.98 || wav.Samples[0][2] > -0.98 {
+			t.Errorf("unexpected samples %v", wav.Samples)
+		}
+	})
+
+	t.Run("IMA ADPCM with short last block and fact", func(t *testing.T) {
+		data := imaMonoBlock(1000, 0, [4]byte{})
+		data = append(data, imaMonoBlock(-1000, 0, [4]byte{})...)
+		data = append(data, imaMonoBlock(2000, 0, [4]byte{})[:4]...) // header only
+
+		path := filepath.Join(dir, "ima.wav")
+		os.WriteFile(path, riffFile(0,
+			riffChunk("fmt ", codecFmtBody(formatIMAADPCM, 1, 22050, 8, 4, binary.LittleEndian.AppendUint16(nil, 9)), true),
+			riffChunk("fact", binary.LittleEndian.AppendUint32(nil, 16), true),
+			riffChunk("data", data, true)), 0644)
+
+		info, err := readWavInfo(path)
+		if err != nil {
+			t.Fatalf("readWavInfo failed: %v", err)
+		}
+		if info.NumSamples != 16 {
+			t.Errorf("expected 16 frames from fact, got %d", info.NumSamples)
+		}
+
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.NumSamples != 16 {
+			t.Fatalf("expected 16 frames, got %d", wav.NumSamples)
+		}
+		if int(wav.Samples[0][9]*32768) != -1000 {
+			t.Errorf("expected second block to start at -1000, got %f", wav.Samples[0][9]*32768)
+		}
+
+		s, err := openWavStream(path)
+		if err != nil {
+			t.Fatalf("openWavStream failed: %v", err)
+		}
+		defer s.Close()
+		s.Skip(9)
+		frames, _ := s.ReadFrames(2)
+		if len(frames[0]) != 2 || int(frames[0][0]*32768) != -1000 {
+			t.Errorf("expected skip to land on the second block header, got %v", frames)
+		}
+	})
+
+	t.Run("IMA ADPCM with fewer samples per block than fit", func(t *testing.T) {
+		data := imaMonoBlock(1000, 0, [4]byte{0x11, 0x11, 0x11, 0x11})
+		data = append(data, imaMonoBlock(-1000, 0, [4]byte{})...)
+
+		path := filepath.Join(dir, "ima_short_blocks.wav")
+		os.WriteFile(path, riffFile(0,
+			riffChunk("fmt ", codecFmtBody(formatIMAADPCM, 1, 22050, 8, 4, binary.LittleEndian.AppendUint16(nil, 5)), true),
+			riffChunk("data", data, true)), 0644)
+
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.NumSamples != 10 || len(wav.Samples[0]) != 10 {
+			t.Fatalf("expected 5 frames from each of 2 blocks, got %d (%d decoded)", wav.NumSamples, len(wav.Samples[0]))
+		}
+		if int(wav.Samples[0][5]*32768) != -1000 {
+			t.Errorf("expected the second block to start at frame 5, got %f", wav.Samples[0][5]*32768)
+		}
+
+		s, err := openWavStream(path)
+		if err != nil {
+			t.Fatalf("openWavStream failed: %v", err)
+		}
+		defer s.Close()
+		s.Skip(6)
+		frames, _ := s.ReadFrames(10)
+		if len(frames[0]) != 4 {
+			t.Errorf("expected the last 4 frames after skipping 6, got %d", len(frames[0]))
+		}
+	})
+
+	t.Run("MS ADPCM stereo", func(t *testing.T) {
+		block := []byte{0, 0}
+		for _, v := range []uint16{16, 16, 100, 200, 50, 150} {
+			block = binary.LittleEndian.AppendUint16(block, v)
+		}
+		block = append(block, 0x00, 0x00)
+
+		ext := binary.LittleEndian.AppendUint16(nil, 4) // samples per block
+		ext = binary.LittleEndian.AppendUint16(ext, 7)
+		for _, c := range msDefaultCoefs {
+			ext = binary.LittleEndian.AppendUint16(ext, uint16(c[0]))
+			ext = binary.LittleEndian.AppendUint16(ext, uint16(c[1]))
+		}
+
+		path := filepath.Join(dir, "ms.wav")
+		os.WriteFile(path, riffFile(0,
+			riffChunk("fmt ", codecFmtBody(formatMSADPCM, 2, 22050, 16, 4, ext), true),
+			riffChunk("data", block, true)), 0644)
+
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.NumSamples != 4 {
+			t.Fatalf("expected 4 frames, got %d", wav.NumSamples)
+		}
+		if int(wav.Samples[0][0]*32768) != 50 || int(wav.Samples[1][1]*32768) != 200 {
+			t.Errorf("unexpected samples %v", wav.Samples)
+		}
+	})
+
+	t.Run("MS ADPCM with custom coefficients", func(t *testing.T) {
+		// Predictor 1, delta 16, sample1 100, sample2 50, then nibbles 1 and 0
+		block := []byte{1}
+		for _, v := range []uint16{16, 100, 50} {
+			block = binary.LittleEndian.AppendUint16(block, v)
+		}
+		block = append(block, 0x10)
+
+		ext := binary.LittleEndian.AppendUint16(nil, 4) // samples per block
+		ext = binary.LittleEndian.AppendUint16(ext, 2)
+		for _, c := range []uint16{256, 0, 128, 128} {
+			ext = binary.LittleEndian.AppendUint16(ext, c)
+		}
+
+		path := filepath.Join(dir, "ms_coefs.wav")
+		os.WriteFile(path, riffFile(0,
+			riffChunk("fmt ", codecFmtBody(formatMSADPCM, 1, 22050, 8, 4, ext), true),
+			riffChunk("data", block, true)), 0644)
+
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.Header.SamplesPerBlock != 4 || len(wav.Header.Coefs) != 2 || wav.Header.Coefs[1] != [2]int16{128, 128} {
+			t.Errorf("fmt extension not parsed: %d frames per block, coefficients %v", wav.Header.SamplesPerBlock, wav.Header.Coefs)
+		}
+
+		// (100*128 + 50*128)>>8 + 16 = 91, then (91*128 + 100*128)>>8 = 95;
+		// the standard second pair (512, -256) would give 166
+		expected := []int{50, 100, 91, 95}
+		if len(wav.Samples[0]) != len(expected) {
+			t.Fatalf("expected %d frames, got %d", len(expected), len(wav.Samples[0]))
+		}
+		for i, want := range expected {
+			if got := int(wav.Samples[0][i] * 32768); got != want {
+				t.Errorf("frame %d = %d, expected %d", i, got, want)
+			}
+		}
+	})
+
+	t.Run("invalid ADPCM fmt extensions", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body []byte
+		}{
+			{"IMA extension too short", codecFmtBody(formatIMAADPCM, 1, 22050, 8, 4, []byte{9})},
+			{"MS extension without a coefficient count", codecFmtBody(formatMSADPCM, 1, 22050, 8, 4, []byte{4, 0})},
+			{"MS coefficients past the extension", codecFmtBody(formatMSADPCM, 1, 22050, 8, 4, []byte{4, 0, 7, 0, 0, 1, 0, 0})},
+			{"MS without coefficients", codecFmtBody(formatMSADPCM, 1, 22050, 8, 4, []byte{4, 0, 0, 0})},
+			{"extension size past the chunk", append(codecFmtBody(formatMSADPCM, 1, 22050, 8, 4, nil), 32, 0, 4, 0)},
+		}
+		for _, tt := range tests {
+			var header WavHeader
+			if err := parseFmtChunk(&header, tt.body); err == nil {
+				t.Errorf("%s: expected error", tt.name)
+			}
+		}
+	})
+
+	t.Run("unsupported ADPCM bit depth", func(t *testing.T) {
+		path := filepath.Join(dir, "bad.wav")
+		os.WriteFile(path, riffFile(0,
+			riffChunk("fmt ", codecFmtBody(formatIMAADPCM, 1, 22050, 8, 3, nil), true),
+			riffChunk("data", make([]byte, 8), true)), 0644)
+
+		if _, err := readWavFile(path); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
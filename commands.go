@@ -0,0 +1,567 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FirstSliceNote is the MIDI note the P-6 Chop mode assigns to the first slice (C4).
+const FirstSliceNote = 60
+
+// validSampleRates are the output rates the P-6 accepts
+var validSampleRates = map[int]bool{44100: true, 22050: true, 14700: true, 11025: true}
+
+// command is a wavslice subcommand
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands returns every subcommand in help order
+func commands() []command {
+	return []command{
+		{"slice", "[flags]", "Combine matching WAV files into evenly-sliced batches (default)", runSlice},
+		{"plan", "[flags]", "Show which files land in which batch and slot without writing anything", runPlan},
+		{"verify", "[-slices n] file.wav...", "Check that combined files are ready for the P-6", runVerify},
+		{"info", "file.wav...", "List and decode every chunk of WAV files and flag inconsistencies", runInfo},
+		{"validate", "file.wav...", "Check WAV files for structural problems, exiting non-zero if any are invalid", runValidate},
+		{"convert", "[flags] input.wav output.wav", "Convert a single file's sample rate, channels or format", runConvert},
+		{"split", "[flags] input.wav", "Split a combined file back into individual slices", runSplit},
+		{"serve", "[-addr host:port] [flags]", "Build kits in a browser: drag library files into slots, preview and write them", runServe},
+		{"config", "show [flags]", "Print effective settings and where they came from", runConfigCommand},
+	}
+}
+
+// runCommand dispatches to a subcommand. Arguments that start with a flag run
+// the slice command, so invocations from before subcommands existed keep working.
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		printUsage()
+		return errors.New("missing command")
+	}
+
+	name := args[0]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		printUsage()
+		return nil
+	}
+	if strings.HasPrefix(name, "-") {
+		return runSlice(args)
+	}
+
+	for _, c := range commands() {
+		if c.name == name {
+			return c.run(args[1:])
+		}
+	}
+
+	printUsage()
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// printUsage lists the available subcommands
+func printUsage() {
+	writeUsage(os.Stdout)
+}
+
+// writeUsage writes each subcommand's summary and arguments to w
+func writeUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: wavslice <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.summary)
+		fmt.Fprintf(w, "  %-9s wavslice %s %s\n", "", c.name, c.args)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'wavslice <command> -h' for command flags.")
+}
+
+// sliceJob is the resolved input shared by the slice and plan commands
+type sliceJob struct {
+	Settings        *Settings
+	Kit             *Kit
+	Slots           []Slot
+	Name            string // output file prefix
+	Device          *DeviceProfile
+	NumChannels     int
+	SamplesPerSlice int
+}
+
+// newSliceJob parses slice flags, merges config and finds the slots to process
+func newSliceJob(name string, args []string) (*sliceJob, error) {
+	fs, preset := newSettingsFlagSet(name, flag.ExitOnError)
+	fs.Parse(args)
+
+	configs, err := loadConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %v", err)
+	}
+	settings, err := resolveSettings(fs, *preset, configs)
+	if err != nil {
+		return nil, err
+	}
+	progress.setFormat(settings.OutputFormat)
+
+	// Validate arguments
+	if settings.Pattern == "" && settings.Kit == "" {
+		fs.Usage()
+		return nil, errors.New("-pattern or -kit is required")
+	}
+	if settings.Interactive && settings.OutputFormat == OutputJSON {
+		return nil, errors.New("-interactive cannot be combined with -output-format json")
+	}
+	if settings.Interactive && settings.Yes {
+		return nil, errors.New("-interactive cannot be combined with -yes")
+	}
+	// Scripts reading JSON events can't answer the y/n prompt, so they must
+	// say up front that the run should go ahead
+	if name == "slice" && settings.OutputFormat == OutputJSON && !settings.Yes {
+		return nil, errors.New("-output-format json needs -yes, since there is no prompt to confirm the run")
+	}
+
+	job := &sliceJob{Settings: settings, Name: settings.Pattern}
+
+	if settings.Kit != "" {
+		job.Kit, err = loadKit(settings.Kit)
+		if err != nil {
+			return nil, fmt.Errorf("loading kit: %v", err)
+		}
+		if job.Kit.Slices != nil {
+			settings.Slices = *job.Kit.Slices
+			settings.Sources["slices"] = settings.Kit
+		}
+		job.Name = job.Kit.Name
+	}
+
+	if err := job.configure(); err != nil {
+		return nil, err
+	}
+	job.printHeader()
+
+	if job.Kit != nil {
+		job.Slots, err = job.Kit.resolveSlots()
+		if err != nil {
+			return nil, fmt.Errorf("reading kit sources: %v", err)
+		}
+		job.checkMonoCompatibility()
+		return job, nil
+	}
+
+	// Build regex pattern from user input
+	regexPattern := wavPattern(settings.Pattern)
+	re, err := regexp.Compile(regexPattern)
+	if err != nil {
+		return nil, fmt.Errorf("compiling regex: %v", err)
+	}
+
+	progress.Printf("Searching with regex: %s\n", regexPattern)
+
+	// Generically named files can still match through their tags
+	var tagRe *regexp.Regexp
+	if settings.Tags {
+		tagRe = regexp.MustCompile("(?i)" + regexp.QuoteMeta(settings.Pattern))
+		progress.Println("Also matching title, comment, description, genre and category tags")
+	}
+	progress.Println()
+
+	// Find matching files
+	files, err := findWavFiles(settings.Dir, re, tagRe)
+	if err != nil {
+		return nil, fmt.Errorf("searching for files: %v", err)
+	}
+	job.Slots = slotsFromFiles(files)
+	job.checkMonoCompatibility()
+
+	return job, nil
+}
+
+// configure applies the device profile, validates output options and derives slice timing
+func (j *sliceJob) configure() error {
+	s := j.Settings
+
+	// Settings were validated when parsed, so the device exists
+	j.Device, _ = findDeviceProfile(s.Device)
+	if s.Sources["slices"] == "default" && s.Slices > j.Device.MaxSlices {
+		s.Slices = j.Device.MaxSlices
+	}
+	if err := j.Device.validate(s); err != nil {
+		return err
+	}
+	if err := s.SFZ.validate(); err != nil {
+		return err
+	}
+	if err := s.MIDI.validate(); err != nil {
+		return err
+	}
+	if err := s.Preview.validate(); err != nil {
+		return err
+	}
+
+	// Calculate slice duration
+	j.NumChannels = 1
+	if s.Stereo {
+		j.NumChannels = 2
+	}
+	j.SamplesPerSlice = j.Device.maxFrames(j.NumChannels) / s.Slices
+	return nil
+}
+
+// printHeader prints the run settings and derived slice timing
+func (j *sliceJob) printHeader() {
+	s := j.Settings
+	maxSamples := j.Device.maxFrames(j.NumChannels)
+	sliceDurationMs := float64(j.SamplesPerSlice) / float64(s.Rate) * 1000.0
+
+	channelMode := fmt.Sprintf("Mono (%s)", s.Mono)
+	if s.Stereo {
+		channelMode = "Stereo"
+	}
+
+	progress.emit(Event{
+		Event:           eventStart,
+		Device:          j.Device.Name,
+		Output:          s.Output,
+		SampleRate:      s.Rate,
+		Channels:        j.NumChannels,
+		Slices:          s.Slices,
+		SamplesPerSlice: j.SamplesPerSlice,
+		SliceMs:         sliceDurationMs,
+	}, "=== WAV Sample Slicer ===\n")
+	progress.Printf("Device: %s\n", j.Device.Description)
+	if j.Kit != nil {
+		progress.Printf("Kit: %s (%s)\n", j.Kit.Name, s.Kit)
+	} else {
+		progress.Printf("Working Directory: %s\n", s.Dir)
+		progress.Printf("Pattern: %s\n", s.Pattern)
+	}
+	progress.Printf("Output Sample Rate: %d Hz\n", s.Rate)
+	progress.Printf("Output Channels: %s\n", channelMode)
+	progress.Printf("Slice Count: %d\n", s.Slices)
+	progress.Printf("Samples per Slice: %d\n", j.SamplesPerSlice)
+	progress.Printf("Slice Duration: %.2f ms\n", sliceDurationMs)
+	progress.Printf("Max Total Duration: %.3f s\n", float64(maxSamples)/float64(s.Rate))
+	progress.Println()
+}
+
+// checkMonoCompatibility measures stereo sources when they will be summed to mono
+func (j *sliceJob) checkMonoCompatibility() {
+	if !j.Settings.Stereo {
+		checkMonoCompatibility(j.Slots, j.Settings.Rate, j.SamplesPerSlice)
+	}
+}
+
+// printMonoWarnings warns about stereo sources that cancel when summed for a mono run
+func (j *sliceJob) printMonoWarnings() {
+	if !j.Settings.Stereo {
+		printMonoWarnings(j.files(), j.Settings.Fallback)
+	}
+}
+
+// files returns the source info of every non-empty slot
+func (j *sliceJob) files() []FileInfo {
+	var files []FileInfo
+	for _, s := range j.Slots {
+		if s.Path != "" {
+			files = append(files, s.FileInfo)
+		}
+	}
+	return files
+}
+
+// runSlice is the original combine workflow: find, summarize, confirm, process
+func runSlice(args []string) error {
+	job, err := newSliceJob("slice", args)
+	if err != nil {
+		return err
+	}
+
+	files := job.files()
+	if len(files) == 0 {
+		printNoFiles()
+		return nil
+	}
+
+	// Display summary
+	displaySummary(files)
+	job.printMonoWarnings()
+
+	s := job.Settings
+
+	// Ask for confirmation, or let the slots be edited first
+	switch {
+	case s.Interactive:
+		fmt.Println()
+		proceed, err := editSlots(job, os.Stdin, os.Stdout, isTerminal(os.Stdout))
+		if err != nil {
+			return fmt.Errorf("reading commands: %v", err)
+		}
+		if !proceed {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	case s.Yes:
+	default:
+		fmt.Print("\nProceed with processing? (y/n): ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	// Create output directory if it doesn't exist
+	if err := os.MkdirAll(s.Output, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %v", err)
+	}
+
+	// Process files in batches
+	results, err := processFiles(job.Slots, s.Rate, job.NumChannels, s.Slices, job.SamplesPerSlice, job.Name, s.Output, s.Normalize, MixOptions{Mono: s.Mono, Fallback: s.Fallback}, s.outputOptions())
+	if err != nil {
+		return fmt.Errorf("processing files: %v", err)
+	}
+
+	if s.Report {
+		path, err := writeReport(job, results)
+		if err != nil {
+			return fmt.Errorf("writing report: %v", err)
+		}
+		progress.created("report", path, 0)
+	}
+
+	progress.emit(Event{Event: eventComplete, Files: len(files)}, "\nProcessing complete!\n")
+	return nil
+}
+
+// printNoFiles reports a search that found nothing, which ends the run
+func printNoFiles() {
+	progress.emit(Event{Event: eventWarning, Message: "no matching WAV files found"}, "No matching WAV files found.\n")
+	progress.emit(Event{Event: eventComplete}, "")
+}
+
+// runPlan prints the batch and slot assignment a slice run would produce
+func runPlan(args []string) error {
+	job, err := newSliceJob("plan", args)
+	if err != nil {
+		return err
+	}
+
+	files := job.files()
+	if len(files) == 0 {
+		printNoFiles()
+		return nil
+	}
+
+	displaySummary(files)
+	job.printMonoWarnings()
+	progress.Println()
+	printPlan(job)
+	progress.emit(Event{Event: eventComplete, Files: len(files)}, "")
+	return nil
+}
+
+// printPlan lists every batch with each slot's note, source and expected truncation
+func printPlan(job *sliceJob) {
+	s := job.Settings
+	sliceSeconds := float64(job.SamplesPerSlice) / float64(s.Rate)
+
+	batchNum := 0
+	for i := 0; i < len(job.Slots); i += s.Slices {
+		batchNum++
+		end := i + s.Slices
+		if end > len(job.Slots) {
+			end = len(job.Slots)
+		}
+
+		outputFile := filepath.Join(s.Output, batchFileName(job.Name, s.Slices, batchNum))
+		progress.emit(Event{Event: eventPlanBatch, Batch: batchNum, Path: outputFile, Files: end - i}, "Batch %d -> %s\n", batchNum, outputFile)
+
+		for idx, slot := range job.Slots[i:end] {
+			name := "(empty)"
+			note := ""
+			over := truncation(slot, sliceSeconds)
+			if slot.Path != "" {
+				name = filepath.Base(slot.Path)
+				if over > 0 {
+					note = fmt.Sprintf("truncated by %.0f ms", over*1000)
+				}
+			}
+			e := Event{Event: eventPlanSlot, Batch: batchNum, Slot: idx + 1, Note: noteName(FirstSliceNote + idx), Path: slot.Path, TruncatedMs: over * 1000}
+			progress.emit(e, "  %2d %-4s %-48s %s\n", idx+1, e.Note, name, note)
+		}
+	}
+}
+
+// truncation estimates how many seconds of a slot's source will not fit in
+// its slice. Trimming and silence removal may shorten the source further.
+func truncation(slot Slot, sliceSeconds float64) float64 {
+	if slot.Path == "" {
+		return 0
+	}
+	return max(slot.Duration-slot.TrimMs/1000.0-sliceSeconds, 0)
+}
+
+// wavExtensions are the source file extensions searched for: WAV, plus the
+// extensions recorders give Wave64 and RF64/BW64 files
+var wavExtensions = []string{"wav", "w64", "rf64", "bw64"}
+
+// wavPattern returns the case-insensitive regex matching WAV file names that
+// contain pattern. An empty pattern matches every WAV file.
+func wavPattern(pattern string) string {
+	return fmt.Sprintf("(?i)^.*%s.*\\.(%s)$", regexp.QuoteMeta(pattern), strings.Join(wavExtensions, "|"))
+}
+
+// hasWavExtension reports whether a file name ends in one of wavExtensions
+func hasWavExtension(name string) bool {
+	return slices.Contains(wavExtensions, strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")))
+}
+
+// batchFileName returns the output file name for a batch
+func batchFileName(name string, sliceCount, batchNum int) string {
+	return fmt.Sprintf("%s_%dslices_batch%03d.wav", sanitizeFilename(name), sliceCount, batchNum)
+}
+
+// noteName returns the name of a MIDI note using the C4 = 60 convention
+func noteName(note int) string {
+	names := []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	return fmt.Sprintf("%s%d", names[note%12], note/12-1)
+}
+
+// runVerify checks combined files against the P-6 limits
+func runVerify(args []string) error {
+	if err := textOnly("verify", args); err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	sliceCount := fs.Int("slices", 0, "Expected slice count; checks the length divides evenly (0 to skip)")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no files given")
+	}
+
+	failed := 0
+	for _, path := range fs.Args() {
+		problems := verifyFile(path, *sliceCount)
+		if len(problems) == 0 {
+			fmt.Printf("OK    %s\n", path)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %s\n", path)
+		for _, p := range problems {
+			fmt.Printf("      - %s\n", p)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed verification", failed, fs.NArg())
+	}
+	return nil
+}
+
+// verifyFile returns a list of problems that would stop a file working as a P-6 chop sample
+func verifyFile(path string, sliceCount int) []string {
+	wav, err := readWavFile(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	h := wav.Header
+	if h.AudioFormat != 1 || h.BitsPerSample != 16 {
+		problems = append(problems, fmt.Sprintf("expected 16-bit PCM, got format %d with %d bits", h.AudioFormat, h.BitsPerSample))
+	}
+	if !validSampleRates[int(h.SampleRate)] {
+		problems = append(problems, fmt.Sprintf("sample rate %d Hz is not one of 44100, 22050, 14700, 11025", h.SampleRate))
+	}
+	if h.NumChannels != 1 && h.NumChannels != 2 {
+		problems = append(problems, fmt.Sprintf("expected mono or stereo, got %d channels", h.NumChannels))
+	}
+	if wav.NumSamples*int(h.NumChannels) > MaxTotalSamples {
+		problems = append(problems, fmt.Sprintf("%d frames exceeds the %d sample limit", wav.NumSamples, MaxTotalSamples/int(h.NumChannels)))
+	}
+	if sliceCount > 0 && wav.NumSamples%sliceCount != 0 {
+		problems = append(problems, fmt.Sprintf("%d frames does not divide evenly into %d slices", wav.NumSamples, sliceCount))
+	}
+
+	return problems
+}
+
+// runConvert converts a single file to 16-bit PCM with optional rate and channel changes
+func runConvert(args []string) error {
+	if err := textOnly("convert", args); err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	rate := fs.Int("rate", 0, "Output sample rate in Hz (0 keeps the source rate)")
+	channels := fs.Int("channels", 0, "Output channel count (0 keeps the source channels)")
+	mono := fs.String("mono", string(MonoSum6dB), "How stereo sources become mono: sum-6db, sum-3db, mid, side, left or right")
+	fallback := fs.String("mono-fallback", string(FallbackNone), "For stereo sources that cancel when summed: none, louder or mid (flip the right channel's polarity)")
+	trim := fs.Bool("trim", false, "Remove leading silence")
+	normalize := fs.Bool("normalize", false, "Normalize volume")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected input and output file")
+	}
+
+	mode, err := parseMonoMode(*mono)
+	if err != nil {
+		return err
+	}
+	policy, err := parseMonoFallback(*fallback)
+	if err != nil {
+		return err
+	}
+
+	return convertFile(fs.Arg(0), fs.Arg(1), *rate, *channels, MixOptions{Mono: mode, Fallback: policy}, *trim, *normalize)
+}
+
+// convertFile reads any supported WAV and writes it as 16-bit PCM
+func convertFile(input, output string, rate, channels int, mix MixOptions, trim, normalize bool) error {
+	wav, err := readWavFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", input, err)
+	}
+
+	samples := wav.Samples
+	if rate <= 0 {
+		rate = int(wav.Header.SampleRate)
+	}
+	if channels <= 0 {
+		channels = int(wav.Header.NumChannels)
+	}
+
+	samples = resample(samples, int(wav.Header.SampleRate), rate)
+	samples = convertChannels(samples, wav.Header.ExtChannelMask, channels, mix)
+	if trim {
+		samples = removeLeadingSilence(samples)
+	}
+	if normalize {
+		samples = normalizeSamples(samples)
+	}
+
+	// Carry over LIST/INFO and bext metadata from the source
+	if err := writeWavFileWithMetadata(output, samples, rate, channels, wav.Metadata); err != nil {
+		return fmt.Errorf("failed to write %s: %v", output, err)
+	}
+
+	fmt.Printf("Created: %s (%d Hz, %d ch, %.3fs)\n", output, rate, channels, float64(len(samples[0]))/float64(rate))
+	return nil
+}
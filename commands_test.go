@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// runCommand tests
+// ============================================================================
+
+func TestRunCommand(t *testing.T) {
+	t.Run("unknown command", func(t *testing.T) {
+		err := runCommand([]string{"bogus"})
+		if err == nil || !strings.Contains(err.Error(), "bogus") {
+			t.Errorf("expected unknown command error, got %v", err)
+		}
+	})
+
+	t.Run("missing command", func(t *testing.T) {
+		if err := runCommand(nil); err == nil {
+			t.Error("expected error without a command")
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		if err := runCommand([]string{"help"}); err != nil {
+			t.Errorf("help failed: %v", err)
+		}
+	})
+
+	t.Run("usage lists arguments", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeUsage(&buf)
+		for _, want := range []string{"wavslice verify [-slices n] file.wav...", "wavslice serve [-addr host:port] [flags]"} {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("expected %q in usage:\n%s", want, buf.String())
+			}
+		}
+	})
+
+	t.Run("every command has a runner", func(t *testing.T) {
+		for _, c := range commands() {
+			if c.run == nil || c.summary == "" || c.args == "" {
+				t.Errorf("command %s is incomplete", c.name)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// Naming helper tests
+// ============================================================================
+
+func TestNoteName(t *testing.T) {
+	tests := []struct {
+		note     int
+		expected string
+	}{
+		{60, "C4"},
+		{61, "C#4"},
+		{63, "D#4"},
+		{72, "C5"},
+		{123, "D#9"},
+		{59, "B3"},
+	}
+
+	for _, tc := range tests {
+		result := noteName(tc.note)
+		if result != tc.expected {
+			t.Errorf("noteName(%d) = %s, expected %s", tc.note, result, tc.expected)
+		}
+	}
+}
+
+func TestBatchFileName(t *testing.T) {
+	if name := batchFileName("kick", 32, 1); name != "kick_32slices_batch001.wav" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if name := batchFileName("a/b", 16, 12); name != "a_b_16slices_batch012.wav" {
+		t.Errorf("expected sanitized name, got %s", name)
+	}
+}
+
+// ============================================================================
+// verify tests
+// ============================================================================
+
+func TestVerifyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("valid file", func(t *testing.T) {
+		path := filepath.Join(dir, "ok.wav")
+		writeWavFile(path, [][]float64{make([]float64, 64)}, 44100, 1)
+		if problems := verifyFile(path, 4); len(problems) != 0 {
+			t.Errorf("expected no problems, got %v", problems)
+		}
+	})
+
+	t.Run("wrong rate and uneven slices", func(t *testing.T) {
+		path := filepath.Join(dir, "bad.wav")
+		writeWavFile(path, [][]float64{make([]float64, 63)}, 48000, 1)
+		problems := verifyFile(path, 4)
+		if len(problems) != 2 {
+			t.Errorf("expected 2 problems, got %v", problems)
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		path := filepath.Join(dir, "long.wav")
+		writeWavFile(path, [][]float64{make([]float64, MaxTotalSamples/2+1), make([]float64, MaxTotalSamples/2+1)}, 44100, 2)
+		if problems := verifyFile(path, 0); len(problems) != 1 {
+			t.Errorf("expected 1 problem, got %v", problems)
+		}
+	})
+
+	t.Run("unreadable", func(t *testing.T) {
+		if problems := verifyFile(filepath.Join(dir, "missing.wav"), 0); len(problems) != 1 {
+			t.Errorf("expected read error, got %v", problems)
+		}
+	})
+
+	t.Run("runVerify returns error on failure", func(t *testing.T) {
+		if err := runVerify([]string{filepath.Join(dir, "bad.wav")}); err == nil {
+			t.Error("expected error")
+		}
+		if err := runVerify([]string{filepath.Join(dir, "ok.wav")}); err != nil {
+			t.Errorf("expected success, got %v", err)
+		}
+	})
+}
+
+// ============================================================================
+// convert tests
+// ============================================================================
+
+func TestConvertFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.wav")
+	output := filepath.Join(dir, "out.wav")
+
+	samples := [][]float64{make([]float64, 4800), make([]float64, 4800)}
+	for i := range samples[0] {
+		samples[0][i] = 0.25
+		samples[1][i] = 0.25
+	}
+	writeWavFile(input, samples, 48000, 2)
+
+	if err := convertFile(input, output, 22050, 1, MixOptions{}, false, true); err != nil {
+		t.Fatalf("convertFile failed: %v", err)
+	}
+
+	wav, err := readWavFile(output)
+	if err != nil {
+		t.Fatalf("readWavFile failed: %v", err)
+	}
+	if wav.Header.SampleRate != 22050 || wav.Header.NumChannels != 1 {
+		t.Errorf("expected 22050 Hz mono, got %d Hz %d ch", wav.Header.SampleRate, wav.Header.NumChannels)
+	}
+	if wav.Samples[0][100] < 0.95 {
+		t.Errorf("expected normalized output, got %f", wav.Samples[0][100])
+	}
+
+	t.Run("keeps source format by default", func(t *testing.T) {
+		if err := convertFile(input, output, 0, 0, MixOptions{}, false, false); err != nil {
+			t.Fatalf("convertFile failed: %v", err)
+		}
+		wav, _ := readWavFile(output)
+		if wav.Header.SampleRate != 48000 || wav.Header.NumChannels != 2 {
+			t.Errorf("expected 48000 Hz stereo, got %d Hz %d ch", wav.Header.SampleRate, wav.Header.NumChannels)
+		}
+	})
+
+	t.Run("missing input", func(t *testing.T) {
+		if err := convertFile(filepath.Join(dir, "none.wav"), output, 0, 0, MixOptions{}, false, false); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
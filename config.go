@@ -0,0 +1,612 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProjectConfigName is the config file looked up in the working directory and its parents.
+const ProjectConfigName = ".wavslice.yaml"
+
+// Settings are the effective run options after merging defaults, config files,
+// a preset and command line flags, in that order of increasing precedence.
+type Settings struct {
+	Dir           string
+	Pattern       string
+	Device        string // device profile name, see deviceProfiles
+	Tags          bool   // also match Pattern against embedded tags
+	Kit           string
+	Output        string
+	Rate          int
+	Slices        int
+	Stereo        bool
+	Mono          MonoMode // how stereo and surround sources become mono
+	Fallback      MonoFallback
+	Normalize     bool
+	Bext          bool // write a Broadcast WAV bext chunk alongside LIST/INFO
+	Octatrack     bool // write an .ot slice file next to each output
+	SFZ           SFZOptions
+	DecentSampler bool // write a Decent Sampler .dspreset next to each output
+	Ableton       bool // write Ableton Drum Rack and Simpler presets next to each output
+	MPC           bool // write slice files and an MPC drum program next to each output
+	MIDI          MIDIOptions
+	Preview       PreviewOptions
+	Waveform      WaveformFormat // overview and thumbnail images of each output
+	Report        bool           // write an HTML report of the run
+	Export        []string       // exporter names, see exporterNames
+	Interactive   bool           // edit the slots before a slice run instead of a y/n prompt
+	Yes           bool           // run a slice job without asking for confirmation
+	OutputFormat  OutputFormat   // text, or JSON events for scripts
+
+	// Sources records where each setting's value came from, keyed by setting name
+	Sources map[string]string
+}
+
+// settingKeys lists every configurable setting in display order. Keys match flag names.
+var settingKeys = []string{"dir", "pattern", "device", "tags", "kit", "output", "rate", "slices", "stereo", "mono", "mono-fallback", "normalize", "bext", "octatrack", "sfz", "sfz-layers", "sfz-round-robin", "decent-sampler", "ableton", "mpc", "midi", "midi-spacing", "midi-velocity", "preview", "preview-gap", "preview-click", "waveform", "export", "report", "interactive", "yes", "output-format"}
+
+// configValues maps setting keys to raw, unparsed values
+type configValues map[string]string
+
+// Config is a parsed config file: top-level defaults plus named presets.
+type Config struct {
+	Path    string
+	Values  configValues
+	Presets map[string]configValues
+}
+
+// defaultSettings returns the built-in defaults
+func defaultSettings() *Settings {
+	s := &Settings{
+		Dir:          ".",
+		Device:       deviceProfiles[0].Name,
+		Output:       ".",
+		Rate:         44100,
+		Slices:       32,
+		Mono:         MonoSum6dB,
+		Fallback:     FallbackNone,
+		SFZ:          SFZOptions{Mode: SFZNone, Layers: 1, RoundRobin: 1},
+		MIDI:         MIDIOptions{SpacingMs: 500, Velocity: 100},
+		Preview:      PreviewOptions{Format: PreviewNone, GapMs: 250},
+		Waveform:     WaveformNone,
+		OutputFormat: OutputText,
+		Sources:      make(map[string]string),
+	}
+	for _, key := range settingKeys {
+		s.Sources[key] = "default"
+	}
+	return s
+}
+
+// set parses and stores a single setting, recording its source
+func (s *Settings) set(key, value, source string) error {
+	var err error
+	switch key {
+	case "dir":
+		s.Dir = value
+	case "pattern":
+		s.Pattern = value
+	case "device":
+		device, err := findDeviceProfile(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+		s.Device = device.Name
+	case "tags":
+		s.Tags, err = strconv.ParseBool(value)
+	case "kit":
+		s.Kit = value
+	case "output":
+		s.Output = value
+	case "rate":
+		s.Rate, err = strconv.Atoi(value)
+	case "slices":
+		s.Slices, err = strconv.Atoi(value)
+	case "stereo":
+		s.Stereo, err = strconv.ParseBool(value)
+	case "mono":
+		if s.Mono, err = parseMonoMode(value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	case "mono-fallback":
+		if s.Fallback, err = parseMonoFallback(value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	case "normalize":
+		s.Normalize, err = strconv.ParseBool(value)
+	case "bext":
+		s.Bext, err = strconv.ParseBool(value)
+	case "octatrack":
+		s.Octatrack, err = strconv.ParseBool(value)
+	case "sfz":
+		if s.SFZ.Mode, err = parseSFZMode(value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	case "sfz-layers":
+		s.SFZ.Layers, err = strconv.Atoi(value)
+	case "sfz-round-robin":
+		s.SFZ.RoundRobin, err = strconv.Atoi(value)
+	case "decent-sampler":
+		s.DecentSampler, err = strconv.ParseBool(value)
+	case "ableton":
+		s.Ableton, err = strconv.ParseBool(value)
+	case "mpc":
+		s.MPC, err = strconv.ParseBool(value)
+	case "midi":
+		s.MIDI.Enabled, err = strconv.ParseBool(value)
+	case "midi-spacing":
+		s.MIDI.SpacingMs, err = strconv.Atoi(value)
+	case "midi-velocity":
+		s.MIDI.Velocity, err = strconv.Atoi(value)
+	case "preview":
+		if s.Preview.Format, err = parsePreviewFormat(value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	case "preview-gap":
+		s.Preview.GapMs, err = strconv.Atoi(value)
+	case "preview-click":
+		s.Preview.Click, err = strconv.ParseBool(value)
+	case "waveform":
+		if s.Waveform, err = parseWaveformFormat(value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	case "export":
+		if s.Export, err = parseExportList(value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	case "report":
+		s.Report, err = strconv.ParseBool(value)
+	case "interactive":
+		s.Interactive, err = strconv.ParseBool(value)
+	case "yes":
+		s.Yes, err = strconv.ParseBool(value)
+	case "output-format":
+		if s.OutputFormat, err = parseOutputFormat(value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	default:
+		return fmt.Errorf("%s: unknown setting %q", source, key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid value %q for %s", source, value, key)
+	}
+	s.Sources[key] = source
+	return nil
+}
+
+// apply sets every value in sorted key order so errors are deterministic
+func (s *Settings) apply(values configValues, source string) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := s.set(k, values[k], source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value returns the display form of a setting
+func (s *Settings) value(key string) string {
+	switch key {
+	case "dir":
+		return s.Dir
+	case "pattern":
+		return s.Pattern
+	case "device":
+		return s.Device
+	case "tags":
+		return strconv.FormatBool(s.Tags)
+	case "kit":
+		return s.Kit
+	case "output":
+		return s.Output
+	case "rate":
+		return strconv.Itoa(s.Rate)
+	case "slices":
+		return strconv.Itoa(s.Slices)
+	case "stereo":
+		return strconv.FormatBool(s.Stereo)
+	case "mono":
+		return string(s.Mono)
+	case "mono-fallback":
+		return string(s.Fallback)
+	case "normalize":
+		return strconv.FormatBool(s.Normalize)
+	case "bext":
+		return strconv.FormatBool(s.Bext)
+	case "octatrack":
+		return strconv.FormatBool(s.Octatrack)
+	case "sfz":
+		return string(s.SFZ.Mode)
+	case "sfz-layers":
+		return strconv.Itoa(s.SFZ.Layers)
+	case "sfz-round-robin":
+		return strconv.Itoa(s.SFZ.RoundRobin)
+	case "decent-sampler":
+		return strconv.FormatBool(s.DecentSampler)
+	case "ableton":
+		return strconv.FormatBool(s.Ableton)
+	case "mpc":
+		return strconv.FormatBool(s.MPC)
+	case "midi":
+		return strconv.FormatBool(s.MIDI.Enabled)
+	case "midi-spacing":
+		return strconv.Itoa(s.MIDI.SpacingMs)
+	case "midi-velocity":
+		return strconv.Itoa(s.MIDI.Velocity)
+	case "preview":
+		return string(s.Preview.Format)
+	case "preview-gap":
+		return strconv.Itoa(s.Preview.GapMs)
+	case "preview-click":
+		return strconv.FormatBool(s.Preview.Click)
+	case "waveform":
+		return string(s.Waveform)
+	case "export":
+		return strings.Join(s.Export, ",")
+	case "report":
+		return strconv.FormatBool(s.Report)
+	case "interactive":
+		return strconv.FormatBool(s.Interactive)
+	case "yes":
+		return strconv.FormatBool(s.Yes)
+	case "output-format":
+		return string(s.OutputFormat)
+	}
+	return ""
+}
+
+// newSettingsFlagSet registers a flag for every setting plus -preset.
+// Flag defaults are only used for help output; resolveSettings reads explicitly set flags.
+func newSettingsFlagSet(name string, handling flag.ErrorHandling) (*flag.FlagSet, *string) {
+	d := defaultSettings()
+	fs := flag.NewFlagSet(name, handling)
+	fs.String("dir", d.Dir, "Working directory to search for WAV files")
+	fs.String("pattern", d.Pattern, "File pattern to search for (e.g., 'kick')")
+	fs.String("device", d.Device, "Device profile setting slice and length limits: "+strings.Join(deviceNames(), ", "))
+	fs.Bool("tags", d.Tags, "Also match the pattern against embedded title, comment, genre and category tags")
+	fs.Int("rate", d.Rate, "Output sample rate in Hz (e.g., 44100, 22050, 14700, 11025)")
+	fs.Bool("stereo", d.Stereo, "Output stereo (default is mono)")
+	fs.String("mono", string(d.Mono), "How stereo sources become mono: sum-6db, sum-3db, mid, side, left or right")
+	fs.String("mono-fallback", string(d.Fallback), "For stereo sources that cancel when summed: none, louder (use the louder channel) or mid (flip the right channel's polarity, then sum)")
+	fs.Int("slices", d.Slices, "Number of slices per output file (up to 64 on the P-6, 24 on the OP-1)")
+	fs.Bool("normalize", d.Normalize, "Normalize volume before saving combined output")
+	fs.Bool("bext", d.Bext, "Also write a Broadcast WAV bext chunk with the slice sources")
+	fs.Bool("octatrack", d.Octatrack, "Also write an Elektron Octatrack .ot slice file next to each output")
+	fs.String("sfz", string(d.SFZ.Mode), "Also write an SFZ instrument mapping slices to keys from C4: none, offsets (into the combined WAV) or files (one WAV per slice)")
+	fs.Int("sfz-layers", d.SFZ.Layers, "Consecutive slices per key played as velocity layers in the SFZ")
+	fs.Int("sfz-round-robin", d.SFZ.RoundRobin, "Consecutive slices per velocity layer played as round-robin alternatives in the SFZ")
+	fs.Bool("decent-sampler", d.DecentSampler, "Also write a Decent Sampler .dspreset next to each output")
+	fs.Bool("ableton", d.Ableton, "Also write an Ableton Drum Rack (.adg) and slicing Simpler (.adv) preset next to each output")
+	fs.Bool("mpc", d.MPC, "Also write each slice as a WAV and an Akai MPC .xpm drum program, 16 pads per bank")
+	fs.Bool("midi", d.MIDI.Enabled, "Also write a MIDI file per batch playing every slice from C4 upward to audition it")
+	fs.Int("midi-spacing", d.MIDI.SpacingMs, "Milliseconds between notes in the audition MIDI file")
+	fs.Int("midi-velocity", d.MIDI.Velocity, "Note velocity in the audition MIDI file (1-127)")
+	fs.String("preview", string(d.Preview.Format), "Also write a normalized preview of each batch with a gap before every slice: none, wav or flac")
+	fs.Int("preview-gap", d.Preview.GapMs, "Milliseconds of silence before each slice in the preview")
+	fs.Bool("preview-click", d.Preview.Click, "Start each preview gap with a click marking the next slice")
+	fs.String("waveform", string(d.Waveform), "Also draw each output's waveform and a per-slice thumbnail grid: none, png, svg or both")
+	fs.String("export", "", "Comma-separated exports to write next to each output: "+strings.Join(exporterNames, ", "))
+	fs.Bool("report", d.Report, "Write an HTML report of the run with slice tables, warnings, levels and audio players")
+	fs.Bool("interactive", d.Interactive, "Review and edit the slots in a terminal editor before a slice run instead of the y/n prompt")
+	fs.Bool("yes", d.Yes, "Run a slice job without asking for confirmation (required with -output-format json)")
+	fs.String("output-format", string(d.OutputFormat), "Progress output: text, or json for one event per line (discovery, batches, files, warnings and created outputs)")
+	fs.String("output", d.Output, "Output directory for combined WAV files")
+	fs.String("kit", d.Kit, "Kit definition file listing slots explicitly (replaces -pattern search)")
+	preset := fs.String("preset", "", "Named preset from a config file (e.g., 'p6-kicks-64')")
+	return fs, preset
+}
+
+// resolveSettings merges defaults, config files (lowest precedence first), the named preset
+// and any flags explicitly set on the parsed flag set.
+func resolveSettings(fs *flag.FlagSet, preset string, configs []*Config) (*Settings, error) {
+	s := defaultSettings()
+
+	for _, c := range configs {
+		if err := s.apply(c.Values, c.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	if preset != "" {
+		// Later config files override presets of the same name
+		var values configValues
+		var source string
+		for _, c := range configs {
+			if p, ok := c.Presets[preset]; ok {
+				values = p
+				source = fmt.Sprintf("preset %s (%s)", preset, c.Path)
+			}
+		}
+		if values == nil {
+			return nil, fmt.Errorf("unknown preset %q", preset)
+		}
+		if err := s.apply(values, source); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		// Command flags such as -preset or serve's -addr are not settings
+		if err != nil || !slices.Contains(settingKeys, f.Name) {
+			return
+		}
+		err = s.set(f.Name, f.Value.String(), "flag -"+f.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// loadConfigs loads the user config followed by the nearest project config, skipping
+// files that don't exist.
+func loadConfigs() ([]*Config, error) {
+	var paths []string
+	if p := userConfigPath(); p != "" {
+		paths = append(paths, p)
+	}
+	var project string
+	if cwd, err := os.Getwd(); err == nil {
+		if project = findProjectConfig(cwd); project != "" {
+			paths = append(paths, project)
+		}
+	}
+
+	var configs []*Config
+	for _, p := range paths {
+		c, err := loadConfigFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			if p == project {
+				c.resolvePaths()
+			}
+			configs = append(configs, c)
+		}
+	}
+	return configs, nil
+}
+
+// pathKeys lists the settings that name files or directories
+var pathKeys = []string{"dir", "output", "kit"}
+
+// resolvePaths makes relative paths in a project config relative to the
+// config's directory, since it may have been found in a parent of the
+// working directory
+func (c *Config) resolvePaths() {
+	dir := filepath.Dir(c.Path)
+	all := []configValues{c.Values}
+	for _, values := range c.Presets {
+		all = append(all, values)
+	}
+	for _, values := range all {
+		for _, key := range pathKeys {
+			if p, ok := values[key]; ok && !filepath.IsAbs(p) {
+				values[key] = filepath.Join(dir, p)
+			}
+		}
+	}
+}
+
+// userConfigPath returns the per-user config location, e.g. ~/.config/wavslice/config.yaml
+func userConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wavslice", "config.yaml")
+}
+
+// findProjectConfig walks up from dir looking for a project config file
+func findProjectConfig(dir string) string {
+	for {
+		p := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadConfigFile reads a config file, returning nil without error if it doesn't exist
+func loadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	c.Path = path
+	return c, nil
+}
+
+// parseConfig parses the small YAML subset used by config files: top-level
+// "key: value" pairs and a "presets:" mapping of preset names to key/value pairs.
+//
+//	rate: 22050
+//	output: ./out
+//
+//	presets:
+//	  p6-kicks-64:
+//	    slices: 64
+//	    normalize: true
+func parseConfig(data []byte) (*Config, error) {
+	c := &Config{
+		Values:  make(configValues),
+		Presets: make(map[string]configValues),
+	}
+
+	var inPresets bool
+	var preset configValues
+	presetIndent, keyIndent := -1, -1
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := stripComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNum)
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", lineNum)
+		}
+		key = strings.TrimSpace(key)
+		value = unquote(strings.TrimSpace(value))
+
+		switch {
+		case indent == 0:
+			inPresets = false
+			if key == "presets" {
+				if value != "" {
+					return nil, fmt.Errorf("line %d: presets must be a mapping", lineNum)
+				}
+				inPresets = true
+				presetIndent, keyIndent = -1, -1
+				continue
+			}
+			if value == "" {
+				return nil, fmt.Errorf("line %d: missing value for %s", lineNum, key)
+			}
+			c.Values[key] = value
+
+		case !inPresets:
+			return nil, fmt.Errorf("line %d: unexpected indentation", lineNum)
+
+		case presetIndent == -1 || indent == presetIndent:
+			if value != "" {
+				return nil, fmt.Errorf("line %d: preset %s must be a mapping", lineNum, key)
+			}
+			presetIndent = indent
+			keyIndent = -1
+			preset = make(configValues)
+			c.Presets[key] = preset
+
+		case indent > presetIndent && (keyIndent == -1 || indent == keyIndent):
+			if value == "" {
+				return nil, fmt.Errorf("line %d: missing value for %s", lineNum, key)
+			}
+			keyIndent = indent
+			preset[key] = value
+
+		default:
+			return nil, fmt.Errorf("line %d: inconsistent indentation", lineNum)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// stripComment removes a trailing "# comment" that is outside quotes
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote strips matching single or double quotes from a scalar value
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// printSettings writes each effective setting with the source it came from
+func printSettings(w io.Writer, s *Settings) {
+	fmt.Fprintf(w, "%-10s %-24s %s\n", "Setting", "Value", "Source")
+	fmt.Fprintln(w, strings.Repeat("-", 70))
+	for _, key := range settingKeys {
+		value := s.value(key)
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(w, "%-10s %-24s %s\n", key, value, s.Sources[key])
+	}
+}
+
+// runConfigCommand handles "wavslice config show [flags]"
+func runConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return fmt.Errorf("usage: wavslice config show [-preset name] [flags]")
+	}
+
+	fs, preset := newSettingsFlagSet("config show", flag.ExitOnError)
+	fs.Parse(args[1:])
+
+	configs, err := loadConfigs()
+	if err != nil {
+		return err
+	}
+
+	if len(configs) == 0 {
+		fmt.Println("No config files found.")
+	}
+	for _, c := range configs {
+		names := make([]string, 0, len(c.Presets))
+		for name := range c.Presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("Config: %s\n", c.Path)
+		if len(names) > 0 {
+			fmt.Printf("  Presets: %s\n", strings.Join(names, ", "))
+		}
+	}
+	fmt.Println()
+
+	s, err := resolveSettings(fs, *preset, configs)
+	if err != nil {
+		return err
+	}
+	printSettings(os.Stdout, s)
+	return nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// parseConfig tests
+// ============================================================================
+
+func TestParseConfig(t *testing.T) {
+	t.Run("values and presets", func(t *testing.T) {
+		data := `# project defaults
+rate: 22050
+output: "./out dir"   # quoted with spaces
+normalize: true
+
+presets:
+  p6-kicks-64:
+    slices: 64
+    pattern: kick
+  hats:
+    pattern: 'hat # open'
+`
+		c, err := parseConfig([]byte(data))
+		if err != nil {
+			t.Fatalf("parseConfig failed: %v", err)
+		}
+		if c.Values["rate"] != "22050" {
+			t.Errorf("expected rate 22050, got %q", c.Values["rate"])
+		}
+		if c.Values["output"] != "./out dir" {
+			t.Errorf("expected unquoted output, got %q", c.Values["output"])
+		}
+		if len(c.Presets) != 2 {
+			t.Fatalf("expected 2 presets, got %d", len(c.Presets))
+		}
+		if c.Presets["p6-kicks-64"]["slices"] != "64" {
+			t.Errorf("expected preset slices 64, got %q", c.Presets["p6-kicks-64"]["slices"])
+		}
+		if c.Presets["hats"]["pattern"] != "hat # open" {
+			t.Errorf("expected quoted # kept, got %q", c.Presets["hats"]["pattern"])
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+		}{
+			{"missing colon", "rate 22050\n"},
+			{"missing value", "rate:\n"},
+			{"indented outside presets", "rate: 1\n  slices: 2\n"},
+			{"preset with scalar", "presets:\n  kicks: 1\n"},
+			{"presets with scalar", "presets: 1\n"},
+			{"tab indentation", "presets:\n\tkicks:\n"},
+			{"inconsistent indentation", "presets:\n  kicks:\n      slices: 1\n    rate: 2\n"},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				if _, err := parseConfig([]byte(tc.data)); err == nil {
+					t.Error("expected error")
+				}
+			})
+		}
+	})
+}
+
+// ============================================================================
+// resolveSettings tests
+// ============================================================================
+
+func TestResolveSettings(t *testing.T) {
+	user := &Config{
+		Path:   "user.yaml",
+		Values: configValues{"rate": "22050", "output": "./user-out"},
+		Presets: map[string]configValues{
+			"kicks": {"pattern": "kick", "slices": "16"},
+		},
+	}
+	project := &Config{
+		Path:   "project.yaml",
+		Values: configValues{"output": "./project-out"},
+		Presets: map[string]configValues{
+			"kicks": {"pattern": "kick", "slices": "64"},
+		},
+	}
+
+	t.Run("precedence", func(t *testing.T) {
+		fs, preset := newSettingsFlagSet("test", flag.ContinueOnError)
+		if err := fs.Parse([]string{"-preset", "kicks", "-stereo"}); err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+
+		s, err := resolveSettings(fs, *preset, []*Config{user, project})
+		if err != nil {
+			t.Fatalf("resolveSettings failed: %v", err)
+		}
+		if s.Rate != 22050 || s.Sources["rate"] != "user.yaml" {
+			t.Errorf("expected rate from user config, got %d from %s", s.Rate, s.Sources["rate"])
+		}
+		if s.Output != "./project-out" || s.Sources["output"] != "project.yaml" {
+			t.Errorf("expected output from project config, got %s from %s", s.Output, s.Sources["output"])
+		}
+		if s.Slices != 64 || !strings.HasPrefix(s.Sources["slices"], "preset kicks") {
+			t.Errorf("expected slices from project preset, got %d from %s", s.Slices, s.Sources["slices"])
+		}
+		if !s.Stereo || s.Sources["stereo"] != "flag -stereo" {
+			t.Errorf("expected stereo from flag, got %v from %s", s.Stereo, s.Sources["stereo"])
+		}
+		if s.Sources["normalize"] != "default" {
+			t.Errorf("expected normalize from default, got %s", s.Sources["normalize"])
+		}
+	})
+
+	t.Run("flags override preset", func(t *testing.T) {
+		fs, preset := newSettingsFlagSet("test", flag.ContinueOnError)
+		fs.Parse([]string{"-preset", "kicks", "-slices", "8"})
+
+		s, err := resolveSettings(fs, *preset, []*Config{user})
+		if err != nil {
+			t.Fatalf("resolveSettings failed: %v", err)
+		}
+		if s.Slices != 8 {
+			t.Errorf("expected slices 8, got %d", s.Slices)
+		}
+	})
+
+	t.Run("unknown preset", func(t *testing.T) {
+		fs, _ := newSettingsFlagSet("test", flag.ContinueOnError)
+		if _, err := resolveSettings(fs, "missing", []*Config{user}); err == nil {
+			t.Error("expected error for unknown preset")
+		}
+	})
+
+	t.Run("invalid config values", func(t *testing.T) {
+		fs, _ := newSettingsFlagSet("test", flag.ContinueOnError)
+		bad := &Config{Path: "bad.yaml", Values: configValues{"rate": "fast"}}
+		if _, err := resolveSettings(fs, "", []*Config{bad}); err == nil {
+			t.Error("expected error for invalid rate")
+		}
+		unknown := &Config{Path: "bad.yaml", Values: configValues{"volume": "11"}}
+		if _, err := resolveSettings(fs, "", []*Config{unknown}); err == nil {
+			t.Error("expected error for unknown setting")
+		}
+	})
+}
+
+// ============================================================================
+// Config file lookup tests
+// ============================================================================
+
+func TestFindProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	os.MkdirAll(nested, 0755)
+
+	if p := findProjectConfig(nested); p != "" && strings.HasPrefix(p, root) {
+		t.Errorf("expected no config under %s, got %s", root, p)
+	}
+
+	configPath := filepath.Join(root, ProjectConfigName)
+	os.WriteFile(configPath, []byte("rate: 11025\n"), 0644)
+
+	if p := findProjectConfig(nested); p != configPath {
+		t.Errorf("expected %s, got %s", configPath, p)
+	}
+}
+
+func TestLoadConfigsResolvesProjectPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	os.MkdirAll(nested, 0755)
+	data := "dir: samples\noutput: ./out\nkit: kits/house.json\npattern: kick\n\npresets:\n  abs:\n    output: /tmp/out\n    dir: ../shared\n"
+	os.WriteFile(filepath.Join(root, ProjectConfigName), []byte(data), 0644)
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(nested); err != nil {
+		t.Fatalf("chdir failed: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	configs, err := loadConfigs()
+	if err != nil {
+		t.Fatalf("loadConfigs failed: %v", err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("expected the project config only, got %d configs", len(configs))
+	}
+
+	// The working directory may be reached through a symlink, so compare
+	// against the directory the config was found in
+	base := filepath.Dir(configs[0].Path)
+	want := configValues{
+		"dir":     filepath.Join(base, "samples"),
+		"output":  filepath.Join(base, "out"),
+		"kit":     filepath.Join(base, "kits", "house.json"),
+		"pattern": "kick",
+	}
+	if !reflect.DeepEqual(configs[0].Values, want) {
+		t.Errorf("expected %v, got %v", want, configs[0].Values)
+	}
+	preset := configs[0].Presets["abs"]
+	if preset["output"] != "/tmp/out" || preset["dir"] != filepath.Join(filepath.Dir(base), "shared") {
+		t.Errorf("unexpected preset paths %v", preset)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		c, err := loadConfigFile(filepath.Join(t.TempDir(), "none.yaml"))
+		if err != nil || c != nil {
+			t.Errorf("expected nil config without error, got %v, %v", c, err)
+		}
+	})
+
+	t.Run("parse error includes path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bad.yaml")
+		os.WriteFile(path, []byte("nonsense\n"), 0644)
+		_, err := loadConfigFile(path)
+		if err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("expected error mentioning path, got %v", err)
+		}
+	})
+}
+
+// ============================================================================
+// printSettings tests
+// ============================================================================
+
+func TestPrintSettings(t *testing.T) {
+	s := defaultSettings()
+	s.set("rate", "14700", "flag -rate")
+
+	buf := new(bytes.Buffer)
+	printSettings(buf, s)
+	out := buf.String()
+
+	if !strings.Contains(out, "14700") || !strings.Contains(out, "flag -rate") {
+		t.Errorf("expected rate and its source in output:\n%s", out)
+	}
+	for _, key := range settingKeys {
+		if !strings.Contains(out, key) {
+			t.Errorf("expected %s in output", key)
+		}
+	}
+}
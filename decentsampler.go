@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dsPresetPath returns the Decent Sampler preset path for a combined WAV
+func dsPresetPath(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".dspreset"
+}
+
+// encodeDSPreset builds a Decent Sampler preset playing each slice of the
+// combined WAV on its own key from FirstSliceNote, as the P-6 Chop mode does.
+// Sample paths are relative to the preset, which is written next to the WAV.
+func encodeDSPreset(layout BatchLayout) string {
+	name := strings.TrimSuffix(filepath.Base(layout.Path), ".wav")
+
+	w := newXMLWriter("  ")
+	w.comment(fmt.Sprintf("%s, %d slices, written by wavslice %s", name, len(layout.Slots), Version))
+	w.open("DecentSampler", "minVersion", "1.0.0")
+	w.open("groups")
+	w.open("group", "name", name)
+	for i, slot := range layout.Slots {
+		note := FirstSliceNote + i
+		start, end := layout.sliceBounds(i)
+
+		source := "(empty)"
+		if slot.Path != "" {
+			source = filepath.Base(slot.Path)
+		}
+		w.comment(fmt.Sprintf("%02d %s %s", i+1, noteName(note), source))
+		w.empty("sample",
+			"path", filepath.Base(layout.Path),
+			"rootNote", note, "loNote", note, "hiNote", note,
+			"start", start, "end", end-1,
+			"trigger", "attack")
+	}
+	w.close("group")
+	w.close("groups")
+	w.close("DecentSampler")
+	return w.String()
+}
+
+// writeDSPreset writes the .dspreset for a batch
+func writeDSPreset(layout BatchLayout) (string, error) {
+	path := dsPresetPath(layout.Path)
+	if err := os.WriteFile(path, []byte(encodeDSPreset(layout)), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
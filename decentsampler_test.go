@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// Decent Sampler preset tests
+// ============================================================================
+
+func TestEncodeDSPreset(t *testing.T) {
+	slots := []Slot{{FileInfo: FileInfo{Path: "/s/kick & snare.wav"}}, {}}
+	layout := BatchLayout{Path: "/out/kit_2slices_batch001.wav", SampleRate: 44100, Channels: 1, SliceLength: 100, Slots: slots}
+
+	var doc struct {
+		MinVersion string `xml:"minVersion,attr"`
+		Samples    []struct {
+			Path     string `xml:"path,attr"`
+			RootNote int    `xml:"rootNote,attr"`
+			LoNote   int    `xml:"loNote,attr"`
+			HiNote   int    `xml:"hiNote,attr"`
+			Start    int    `xml:"start,attr"`
+			End      int    `xml:"end,attr"`
+		} `xml:"groups>group>sample"`
+	}
+	if err := xml.Unmarshal([]byte(encodeDSPreset(layout)), &doc); err != nil {
+		t.Fatalf("preset is not valid XML: %v", err)
+	}
+
+	if doc.MinVersion != "1.0.0" {
+		t.Errorf("unexpected minVersion %q", doc.MinVersion)
+	}
+	if len(doc.Samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(doc.Samples))
+	}
+	s := doc.Samples[1]
+	if s.Path != "kit_2slices_batch001.wav" || s.RootNote != 61 || s.LoNote != 61 || s.HiNote != 61 || s.Start != 100 || s.End != 199 {
+		t.Errorf("unexpected second sample %+v", s)
+	}
+}
+
+func TestWriteDSPreset(t *testing.T) {
+	dir := t.TempDir()
+	layout := BatchLayout{Path: filepath.Join(dir, "kit.wav"), SampleRate: 44100, Channels: 1, SliceLength: 10, Slots: make([]Slot, 1)}
+
+	path, err := writeDSPreset(layout)
+	if err != nil {
+		t.Fatalf("writeDSPreset failed: %v", err)
+	}
+	if path != filepath.Join(dir, "kit.dspreset") {
+		t.Errorf("unexpected path %s", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected preset file: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// delugeSampleDir is where kits expect the combined WAV on the Deluge's card
+const delugeSampleDir = "SAMPLES/WAVSLICE"
+
+// delugeFirmware is the firmware version kits are written for
+const delugeFirmware = "3.1.5"
+
+// delugePath returns the kit path for a combined WAV. The Deluge lists kits by
+// upper-case .XML name.
+func delugePath(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".XML"
+}
+
+// encodeDelugeKit builds a kit with one row per slice, each playing its part
+// of the combined WAV once
+func encodeDelugeKit(layout BatchLayout) string {
+	fileName := delugeSampleDir + "/" + filepath.Base(layout.Path)
+
+	w := newXMLWriter("\t")
+	w.open("kit")
+	w.text("firmwareVersion", delugeFirmware)
+	w.text("earliestCompatibleFirmware", delugeFirmware)
+	w.open("soundSources")
+	for i, slot := range layout.Slots {
+		start, end := layout.sliceBounds(i)
+		w.open("sound")
+		w.text("name", slotName(slot, i))
+		w.open("osc1")
+		w.text("type", "sample")
+		w.text("loopMode", 1) // play once
+		w.text("reversed", 0)
+		w.text("fileName", fileName)
+		w.open("zone")
+		w.text("startSamplePos", start)
+		w.text("endSamplePos", end)
+		w.close("zone")
+		w.close("osc1")
+		w.text("polyphonic", "poly")
+		w.close("sound")
+	}
+	w.close("soundSources")
+	w.text("selectedDrumIndex", 0)
+	w.close("kit")
+	return w.String()
+}
+
+// writeDelugeKit writes the kit XML for a batch. The WAV itself has to be
+// copied to SAMPLES/WAVSLICE on the card.
+func writeDelugeKit(layout BatchLayout) (string, error) {
+	path := delugePath(layout.Path)
+	if err := os.WriteFile(path, []byte(encodeDelugeKit(layout)), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DeviceProfile describes the memory and format limits of the sampler a batch
+// is built for. Slice length is derived from the memory limit and slice count.
+type DeviceProfile struct {
+	Name        string
+	Description string
+	MaxSamples  int      // total sample memory, frames × channels
+	MaxSlices   int      // slices one sample can hold
+	Rates       []int    // accepted output rates
+	MonoOnly    bool     // the device only plays mono samples
+	Exporters   []string // exports every batch needs to load on the device
+}
+
+// deviceProfiles lists the supported devices in help order; the first is the default
+var deviceProfiles = []DeviceProfile{
+	{
+		Name:        "p6",
+		Description: "Roland P-6",
+		MaxSamples:  MaxTotalSamples,
+		MaxSlices:   64,
+		Rates:       []int{44100, 22050, 14700, 11025},
+	},
+	{
+		Name:        "op1",
+		Description: "Teenage Engineering OP-1/OP-Z drum sampler",
+		MaxSamples:  op1MaxFrames,
+		MaxSlices:   op1Slices,
+		Rates:       []int{op1SampleRate},
+		MonoOnly:    true,
+		Exporters:   []string{"op1"},
+	},
+}
+
+// deviceNames returns the accepted -device values
+func deviceNames() []string {
+	names := make([]string, len(deviceProfiles))
+	for i, d := range deviceProfiles {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// findDeviceProfile returns the profile for a device name
+func findDeviceProfile(name string) (*DeviceProfile, error) {
+	for i := range deviceProfiles {
+		if deviceProfiles[i].Name == strings.ToLower(name) {
+			return &deviceProfiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown device %q (expected %s)", name, strings.Join(deviceNames(), ", "))
+}
+
+// validate checks that the slice count, rate and channels fit the device
+func (d *DeviceProfile) validate(s *Settings) error {
+	if s.Slices < 1 || s.Slices > d.MaxSlices {
+		return fmt.Errorf("-slices must be between 1 and %d for the %s", d.MaxSlices, d.Description)
+	}
+	if !slices.Contains(d.Rates, s.Rate) {
+		rates := make([]string, len(d.Rates))
+		for i, r := range d.Rates {
+			rates[i] = fmt.Sprint(r)
+		}
+		return fmt.Errorf("-rate must be one of: %s", strings.Join(rates, ", "))
+	}
+	if d.MonoOnly && s.Stereo {
+		return fmt.Errorf("the %s only plays mono samples; run without -stereo", d.Description)
+	}
+	return nil
+}
+
+// maxFrames returns how many frames fit in the device's memory
+func (d *DeviceProfile) maxFrames(numChannels int) int {
+	return d.MaxSamples / numChannels
+}
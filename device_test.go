@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ============================================================================
+// Device profile tests
+// ============================================================================
+
+func TestFindDeviceProfile(t *testing.T) {
+	d, err := findDeviceProfile("OP1")
+	if err != nil {
+		t.Fatalf("findDeviceProfile failed: %v", err)
+	}
+	if d.MaxSlices != 24 || d.maxFrames(1) != 12*44100 {
+		t.Errorf("unexpected op1 limits %+v", d)
+	}
+
+	if _, err := findDeviceProfile("sp404"); err == nil {
+		t.Error("expected error for unknown device")
+	}
+}
+
+func TestDeviceProfileValidate(t *testing.T) {
+	p6, _ := findDeviceProfile("p6")
+	op1, _ := findDeviceProfile("op1")
+
+	tests := []struct {
+		name    string
+		device  *DeviceProfile
+		modify  func(s *Settings)
+		wantErr bool
+	}{
+		{"p6 defaults", p6, func(s *Settings) {}, false},
+		{"p6 64 slices stereo", p6, func(s *Settings) { s.Slices = 64; s.Stereo = true }, false},
+		{"p6 65 slices", p6, func(s *Settings) { s.Slices = 65 }, true},
+		{"p6 bad rate", p6, func(s *Settings) { s.Rate = 48000 }, true},
+		{"op1 24 slices", op1, func(s *Settings) { s.Slices = 24 }, false},
+		{"op1 32 slices", op1, func(s *Settings) { s.Slices = 32 }, true},
+		{"op1 22050 Hz", op1, func(s *Settings) { s.Slices = 24; s.Rate = 22050 }, true},
+		{"op1 stereo", op1, func(s *Settings) { s.Slices = 24; s.Stereo = true }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := defaultSettings()
+			tt.modify(s)
+			err := tt.device.validate(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeviceSetting(t *testing.T) {
+	s := defaultSettings()
+	if s.Device != "p6" {
+		t.Errorf("expected default device p6, got %q", s.Device)
+	}
+	if err := s.set("device", "OP1", "test"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if s.value("device") != "op1" {
+		t.Errorf("expected op1, got %q", s.value("device"))
+	}
+	if err := s.set("device", "sp404", "test"); err == nil {
+		t.Error("expected error for unknown device")
+	}
+
+	// The device's own exports come first and are not repeated
+	s.Export = []string{"sfz", "op1"}
+	var names []string
+	for _, e := range s.outputOptions().Exporters {
+		names = append(names, e.Name())
+	}
+	if want := []string{"op1", "sfz"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
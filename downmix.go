@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// MonoMode selects how a stereo (or downmixed multichannel) source becomes mono
+type MonoMode string
+
+const (
+	MonoSum6dB MonoMode = "sum-6db" // (L+R)/2, the original averaging
+	MonoSum3dB MonoMode = "sum-3db" // (L+R)×0.707, louder but may clip correlated material
+	MonoMid    MonoMode = "mid"     // alias of sum-6db for those who think in M/S terms
+	MonoSide   MonoMode = "side"    // (L-R)/2, the S of M/S
+	MonoLeft   MonoMode = "left"
+	MonoRight  MonoMode = "right"
+)
+
+// monoModes lists the accepted modes in help order
+var monoModes = []MonoMode{MonoSum6dB, MonoSum3dB, MonoMid, MonoSide, MonoLeft, MonoRight}
+
+// parseMonoMode validates a mono mode name
+func parseMonoMode(s string) (MonoMode, error) {
+	for _, m := range monoModes {
+		if string(m) == strings.ToLower(s) {
+			return m, nil
+		}
+	}
+	names := make([]string, len(monoModes))
+	for i, m := range monoModes {
+		names[i] = string(m)
+	}
+	return "", fmt.Errorf("unknown mono mode %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// MixOptions controls how sources are reduced to the output channel count
+type MixOptions struct {
+	Mono     MonoMode
+	Fallback MonoFallback // replaces Mono for poorly correlated stereo sources
+}
+
+// WAVEFORMATEXTENSIBLE speaker position bits
+const (
+	speakerFrontLeft          = 0x1
+	speakerFrontRight         = 0x2
+	speakerFrontCenter        = 0x4
+	speakerLowFrequency       = 0x8
+	speakerBackLeft           = 0x10
+	speakerBackRight          = 0x20
+	speakerFrontLeftOfCenter  = 0x40
+	speakerFrontRightOfCenter = 0x80
+	speakerBackCenter         = 0x100
+	speakerSideLeft           = 0x200
+	speakerSideRight          = 0x400
+)
+
+// defaultChannelMasks are the layouts assumed for multichannel files without a mask
+var defaultChannelMasks = map[int]uint32{
+	1: speakerFrontCenter,
+	2: speakerFrontLeft | speakerFrontRight,
+	3: speakerFrontLeft | speakerFrontRight | speakerFrontCenter,
+	4: speakerFrontLeft | speakerFrontRight | speakerBackLeft | speakerBackRight,
+	5: speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerBackLeft | speakerBackRight,
+	6: speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerLowFrequency | speakerBackLeft | speakerBackRight,
+	8: speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerLowFrequency | speakerBackLeft | speakerBackRight | speakerSideLeft | speakerSideRight,
+}
+
+// ituStereoGains are the ITU-R BS.775 left/right downmix coefficients per speaker.
+// The LFE channel and height speakers are dropped.
+var ituStereoGains = map[uint32][2]float64{
+	speakerFrontLeft:          {1, 0},
+	speakerFrontRight:         {0, 1},
+	speakerFrontCenter:        {math.Sqrt2 / 2, math.Sqrt2 / 2},
+	speakerBackLeft:           {math.Sqrt2 / 2, 0},
+	speakerBackRight:          {0, math.Sqrt2 / 2},
+	speakerFrontLeftOfCenter:  {1, 0},
+	speakerFrontRightOfCenter: {0, 1},
+	speakerBackCenter:         {0.5, 0.5},
+	speakerSideLeft:           {math.Sqrt2 / 2, 0},
+	speakerSideRight:          {0, math.Sqrt2 / 2},
+}
+
+// channelPositions assigns a speaker bit to each channel in mask order. Channels
+// beyond the mask have no position. It returns nil when the layout is unknown.
+func channelPositions(mask uint32, numChannels int) []uint32 {
+	if mask == 0 {
+		mask = defaultChannelMasks[numChannels]
+	}
+	if mask == 0 {
+		return nil
+	}
+
+	positions := make([]uint32, numChannels)
+	for ch := range positions {
+		if mask == 0 {
+			break
+		}
+		positions[ch] = 1 << bits.TrailingZeros32(mask)
+		mask &= mask - 1
+	}
+	return positions
+}
+
+// downmixToStereo folds a multichannel source to stereo with ITU coefficients,
+// scaled so full-scale material in every channel cannot clip. It returns nil
+// when the layout is unknown.
+func downmixToStereo(samples [][]float64, mask uint32) [][]float64 {
+	positions := channelPositions(mask, len(samples))
+	if positions == nil {
+		return nil
+	}
+
+	var gains [][2]float64
+	var total [2]float64
+	for _, pos := range positions {
+		g := ituStereoGains[pos]
+		gains = append(gains, g)
+		total[0] += g[0]
+		total[1] += g[1]
+	}
+	for side := range total {
+		total[side] = max(total[side], 1)
+	}
+
+	numSamples := len(samples[0])
+	result := [][]float64{make([]float64, numSamples), make([]float64, numSamples)}
+	for ch, g := range gains {
+		for i, v := range samples[ch] {
+			result[0][i] += v * g[0] / total[0]
+			result[1][i] += v * g[1] / total[1]
+		}
+	}
+	return result
+}
+
+// stereoToMono combines left and right with the given mode
+func stereoToMono(left, right []float64, mode MonoMode) []float64 {
+	result := make([]float64, len(left))
+	for i := range left {
+		switch mode {
+		case MonoSum3dB:
+			result[i] = (left[i] + right[i]) * math.Sqrt2 / 2
+		case MonoSide:
+			result[i] = (left[i] - right[i]) / 2
+		case MonoLeft:
+			result[i] = left[i]
+		case MonoRight:
+			result[i] = right[i]
+		default: // MonoSum6dB and its alias MonoMid
+			result[i] = (left[i] + right[i]) / 2
+		}
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// ============================================================================
+// Mono mode tests
+// ============================================================================
+
+func TestParseMonoMode(t *testing.T) {
+	if m, err := parseMonoMode("SIDE"); err != nil || m != MonoSide {
+		t.Errorf("expected side, got %q, %v", m, err)
+	}
+	if _, err := parseMonoMode("loudest"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestStereoToMono(t *testing.T) {
+	left := []float64{0.5}
+	right := []float64{0.25}
+
+	tests := []struct {
+		mode     MonoMode
+		expected float64
+	}{
+		{MonoSum6dB, 0.375},
+		{MonoSum3dB, 0.75 * math.Sqrt2 / 2},
+		{MonoMid, 0.375},
+		{MonoSide, 0.125},
+		{MonoLeft, 0.5},
+		{MonoRight, 0.25},
+		{"", 0.375},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			if got := stereoToMono(left, right, tc.mode)[0]; math.Abs(got-tc.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMonoMidIsSum6dB(t *testing.T) {
+	left := []float64{1, 0.5, -0.25, 0}
+	right := []float64{-1, 0.25, 0.75, 0.5}
+	if mid, sum := stereoToMono(left, right, MonoMid), stereoToMono(left, right, MonoSum6dB); !reflect.DeepEqual(mid, sum) {
+		t.Errorf("expected mid to match sum-6db %v, got %v", sum, mid)
+	}
+}
+
+// ============================================================================
+// Downmix tests
+// ============================================================================
+
+func TestChannelPositions(t *testing.T) {
+	positions := channelPositions(speakerFrontLeft|speakerFrontRight|speakerLowFrequency, 4)
+	expected := []uint32{speakerFrontLeft, speakerFrontRight, speakerLowFrequency, 0}
+	for i := range expected {
+		if positions[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, positions)
+			break
+		}
+	}
+
+	if channelPositions(0, 7) != nil {
+		t.Error("expected unknown layout for 7 channels without a mask")
+	}
+}
+
+func TestDownmixToStereo(t *testing.T) {
+	// 5.1: FL FR FC LFE BL BR
+	surround := [][]float64{{1}, {0}, {1}, {1}, {0}, {1}}
+	stereo := downmixToStereo(surround, 0)
+	if len(stereo) != 2 {
+		t.Fatalf("expected 2 channels, got %d", len(stereo))
+	}
+
+	total := 1 + math.Sqrt2
+	if left := (1 + math.Sqrt2/2) / total; math.Abs(stereo[0][0]-left) > 1e-9 {
+		t.Errorf("expected left %f, got %f", left, stereo[0][0])
+	}
+	// LFE is dropped
+	if right := math.Sqrt2 / total; math.Abs(stereo[1][0]-right) > 1e-9 {
+		t.Errorf("expected right %f, got %f", right, stereo[1][0])
+	}
+
+	t.Run("full scale does not clip", func(t *testing.T) {
+		full := [][]float64{{1}, {1}, {1}, {1}, {1}, {1}}
+		stereo := downmixToStereo(full, 0)
+		if stereo[0][0] > 1+1e-9 || stereo[1][0] > 1+1e-9 {
+			t.Errorf("expected at most full scale, got %v", stereo)
+		}
+	})
+
+	t.Run("channel mask overrides default layout", func(t *testing.T) {
+		// Three channels tagged as FL, FR and LFE: LFE is dropped instead of treated as center
+		out := convertChannels([][]float64{{0}, {0}, {1}}, speakerFrontLeft|speakerFrontRight|speakerLowFrequency, 1, MixOptions{})
+		if out[0][0] != 0 {
+			t.Errorf("expected LFE to be dropped, got %f", out[0][0])
+		}
+	})
+
+	t.Run("surround to mono uses mono mode", func(t *testing.T) {
+		out := convertChannels(surround, 0, 1, MixOptions{Mono: MonoLeft})
+		if math.Abs(out[0][0]-stereo[0][0]) > 1e-9 {
+			t.Errorf("expected downmixed left %f, got %f", stereo[0][0], out[0][0])
+		}
+	})
+}
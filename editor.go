@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The slot editor replaces the y/n prompt of a slice run with -interactive.
+// It redraws the slot list after every typed command rather than reading keys,
+// so it needs nothing beyond the standard library and works over ssh or a
+// serial console on a headless machine.
+
+const editorHelp = `Commands (slot numbers count from 1 across all batches):
+  m FROM TO    move a slot, shifting the slots in between
+  s A B        swap two slots
+  x N...       remove slots
+  e N          insert an empty slot at N
+  a FILE [N]   add a WAV file at slot N, or at the end
+  r N...       toggle reverse
+  n N...       toggle normalize
+  t N MS       trim MS milliseconds from the start of a slot's source
+  y            write the batches
+  q            quit without writing anything (so does n on its own)`
+
+// clearScreen moves the cursor home and clears a terminal
+const clearScreen = "\033[H\033[2J"
+
+// editorAction is what a slice run does after an editor command
+type editorAction int
+
+const (
+	editorContinue editorAction = iota
+	editorRun
+	editorQuit
+)
+
+// slotEditor edits a job's slots in place
+type slotEditor struct {
+	job    *sliceJob
+	out    io.Writer
+	clear  bool   // redraw the whole screen for each command
+	status string // result of the last command
+}
+
+// editSlots runs the slot editor on in and out until the slots are confirmed
+// or abandoned, and reports whether to go ahead with the run. End of input
+// abandons the run.
+func editSlots(job *sliceJob, in io.Reader, out io.Writer, clear bool) (bool, error) {
+	e := &slotEditor{job: job, out: out, clear: clear}
+	scanner := bufio.NewScanner(in)
+	for {
+		e.render()
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return false, scanner.Err()
+		}
+
+		action, err := e.exec(scanner.Text())
+		if err != nil {
+			e.status = "Error: " + err.Error()
+			continue
+		}
+		switch action {
+		case editorRun:
+			return true, nil
+		case editorQuit:
+			return false, nil
+		}
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// render draws every batch with each slot's key, source, edits and expected truncation
+func (e *slotEditor) render() {
+	s := e.job.Settings
+	slots := e.job.Slots
+	sliceSeconds := float64(e.job.SamplesPerSlice) / float64(s.Rate)
+
+	if e.clear {
+		fmt.Fprint(e.out, clearScreen)
+	}
+	fmt.Fprintf(e.out, "=== Slots: %d, %d per batch, %.2f ms each ===\n", len(slots), s.Slices, sliceSeconds*1000)
+
+	files, truncated := 0, 0
+	for i, slot := range slots {
+		idx := i % s.Slices
+		if idx == 0 {
+			outputFile := filepath.Join(s.Output, batchFileName(e.job.Name, s.Slices, i/s.Slices+1))
+			fmt.Fprintf(e.out, "\nBatch %d -> %s\n", i/s.Slices+1, outputFile)
+		}
+
+		name := "(empty)"
+		note := ""
+		if slot.Path != "" {
+			files++
+			name = filepath.Base(slot.Path)
+			if over := truncation(slot, sliceSeconds); over > 0 {
+				truncated++
+				note = fmt.Sprintf("truncated by %.0f ms", over*1000)
+			}
+		}
+		row := fmt.Sprintf("  %3d %-4s %-40s %-22s %s", i+1, noteName(FirstSliceNote+idx), name, slotEdits(slot), note)
+		fmt.Fprintln(e.out, strings.TrimRight(row, " "))
+	}
+	fmt.Fprintf(e.out, "\n%d files, %d truncated\n", files, truncated)
+
+	if e.status != "" {
+		fmt.Fprintf(e.out, "\n%s\n", e.status)
+		e.status = ""
+	}
+	fmt.Fprint(e.out, "\nCommand (? for help, y to run, q to quit): ")
+}
+
+// slotEdits summarizes a slot's edits for the editor's list
+func slotEdits(slot Slot) string {
+	var edits []string
+	if slot.Reverse {
+		edits = append(edits, "rev")
+	}
+	if slot.Normalize {
+		edits = append(edits, "norm")
+	}
+	if slot.TrimMs > 0 {
+		edits = append(edits, fmt.Sprintf("trim %gms", slot.TrimMs))
+	}
+	if slot.GainDB != 0 {
+		edits = append(edits, fmt.Sprintf("%+gdB", slot.GainDB))
+	}
+	return strings.Join(edits, " ")
+}
+
+// exec applies one command line to the slots
+func (e *slotEditor) exec(line string) (editorAction, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return editorContinue, nil
+	}
+	cmd, args := strings.ToLower(fields[0]), fields[1:]
+
+	switch cmd {
+	case "?", "h", "help":
+		e.status = editorHelp
+	case "y", "yes":
+		if len(e.job.files()) == 0 {
+			return editorContinue, errors.New("no files to process")
+		}
+		return editorRun, nil
+	case "q", "quit", "no":
+		return editorQuit, nil
+	case "m":
+		return editorContinue, e.move(args)
+	case "s":
+		return editorContinue, e.swap(args)
+	case "x":
+		return editorContinue, e.remove(args)
+	case "e":
+		return editorContinue, e.insertEmpty(args)
+	case "a":
+		return editorContinue, e.add(strings.TrimSpace(line[strings.Index(line, fields[0])+len(fields[0]):]))
+	case "r":
+		return editorContinue, e.toggle(args, "reverse", func(s *Slot) { s.Reverse = !s.Reverse })
+	case "n":
+		// A bare n answers the y/n prompt this editor replaces
+		if len(args) == 0 {
+			return editorQuit, nil
+		}
+		return editorContinue, e.toggle(args, "normalize", func(s *Slot) { s.Normalize = !s.Normalize })
+	case "t":
+		return editorContinue, e.trim(args)
+	default:
+		return editorContinue, fmt.Errorf("unknown command %q (? lists commands)", fields[0])
+	}
+	return editorContinue, nil
+}
+
+// slotIndex parses a 1-based slot number into an index below limit
+func slotIndex(arg string, limit int) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > limit {
+		return 0, fmt.Errorf("no slot %q (expected 1-%d)", arg, limit)
+	}
+	return n - 1, nil
+}
+
+// slotIndexes parses one or more distinct slot numbers
+func (e *slotEditor) slotIndexes(args []string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, errors.New("expected a slot number")
+	}
+	seen := make(map[int]bool)
+	var indexes []int
+	for _, arg := range args {
+		i, err := slotIndex(arg, len(e.job.Slots))
+		if err != nil {
+			return nil, err
+		}
+		if !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
+
+// slotPair parses the two slot numbers of a move or swap
+func (e *slotEditor) slotPair(args []string) (int, int, error) {
+	if len(args) != 2 {
+		return 0, 0, errors.New("expected two slot numbers")
+	}
+	a, err := slotIndex(args[0], len(e.job.Slots))
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := slotIndex(args[1], len(e.job.Slots))
+	return a, b, err
+}
+
+func (e *slotEditor) move(args []string) error {
+	from, to, err := e.slotPair(args)
+	if err != nil {
+		return err
+	}
+	slot := e.job.Slots[from]
+	slots := append(e.job.Slots[:from:from], e.job.Slots[from+1:]...)
+	e.job.Slots = append(slots[:to:to], append([]Slot{slot}, slots[to:]...)...)
+	return nil
+}
+
+func (e *slotEditor) swap(args []string) error {
+	a, b, err := e.slotPair(args)
+	if err != nil {
+		return err
+	}
+	e.job.Slots[a], e.job.Slots[b] = e.job.Slots[b], e.job.Slots[a]
+	return nil
+}
+
+func (e *slotEditor) remove(args []string) error {
+	indexes, err := e.slotIndexes(args)
+	if err != nil {
+		return err
+	}
+	drop := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		drop[i] = true
+	}
+	var slots []Slot
+	for i, slot := range e.job.Slots {
+		if !drop[i] {
+			slots = append(slots, slot)
+		}
+	}
+	e.job.Slots = slots
+	return nil
+}
+
+// insert places a slot at index i, shifting later slots along
+func (e *slotEditor) insert(i int, slot Slot) {
+	slots := e.job.Slots
+	e.job.Slots = append(slots[:i:i], append([]Slot{slot}, slots[i:]...)...)
+}
+
+func (e *slotEditor) insertEmpty(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected a slot number")
+	}
+	i, err := slotIndex(args[0], len(e.job.Slots)+1)
+	if err != nil {
+		return err
+	}
+	e.insert(i, Slot{})
+	return nil
+}
+
+// add inserts a WAV file given relative to the working directory or -dir. A
+// trailing number is the slot to insert it at.
+func (e *slotEditor) add(arg string) error {
+	if arg == "" {
+		return errors.New("expected a WAV file")
+	}
+	path, at := arg, len(e.job.Slots)
+	if i := strings.LastIndexByte(arg, ' '); i > 0 {
+		if _, err := strconv.Atoi(arg[i+1:]); err == nil {
+			index, err := slotIndex(arg[i+1:], len(e.job.Slots)+1)
+			if err != nil {
+				return err
+			}
+			path, at = strings.TrimSpace(arg[:i]), index
+		}
+	}
+
+	if _, err := os.Stat(path); err != nil && !filepath.IsAbs(path) {
+		path = filepath.Join(e.job.Settings.Dir, path)
+	}
+	info, err := readSourceInfo(path)
+	if err != nil {
+		return err
+	}
+	e.insert(at, Slot{FileInfo: info})
+	e.status = fmt.Sprintf("Added %s at slot %d", filepath.Base(path), at+1)
+	return nil
+}
+
+// toggle flips an edit on the given slots, which must not be empty
+func (e *slotEditor) toggle(args []string, edit string, flip func(*Slot)) error {
+	indexes, err := e.slotIndexes(args)
+	if err != nil {
+		return err
+	}
+	for _, i := range indexes {
+		if e.job.Slots[i].Path == "" {
+			return fmt.Errorf("cannot %s slot %d: it is empty", edit, i+1)
+		}
+	}
+	for _, i := range indexes {
+		flip(&e.job.Slots[i])
+	}
+	return nil
+}
+
+func (e *slotEditor) trim(args []string) error {
+	if len(args) != 2 {
+		return errors.New("expected a slot number and milliseconds")
+	}
+	i, err := slotIndex(args[0], len(e.job.Slots))
+	if err != nil {
+		return err
+	}
+	ms, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || ms < 0 || math.IsNaN(ms) || math.IsInf(ms, 0) {
+		return fmt.Errorf("invalid trim %q: expected milliseconds of zero or more", args[1])
+	}
+	slot := e.job.Slots[i]
+	if slot.Path == "" {
+		return fmt.Errorf("cannot trim slot %d: it is empty", i+1)
+	}
+	slot.TrimMs = ms
+	if err := slot.checkTrim(); err != nil {
+		return fmt.Errorf("cannot trim slot %d: %v", i+1, err)
+	}
+	e.job.Slots[i] = slot
+	return nil
+}
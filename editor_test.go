@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// Slot editor tests
+// ============================================================================
+
+// editorJob returns a job with slots a.wav, b.wav and c.wav of 0.5 s each
+// and 4 slices of 0.25 s
+func editorJob() *sliceJob {
+	s := defaultSettings()
+	s.Slices = 4
+	var slots []Slot
+	for _, name := range []string{"a", "b", "c"} {
+		slots = append(slots, Slot{FileInfo: FileInfo{Path: "/s/" + name + ".wav", Duration: 0.5}})
+	}
+	return &sliceJob{Settings: s, Name: "kit", Slots: slots, SamplesPerSlice: s.Rate / 4}
+}
+
+// slotNames lists the base names of a job's slots, "-" for empty ones
+func slotNames(job *sliceJob) string {
+	var names []string
+	for _, s := range job.Slots {
+		if s.Path == "" {
+			names = append(names, "-")
+		} else {
+			names = append(names, strings.TrimSuffix(filepath.Base(s.Path), ".wav"))
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestSlotEditorExec(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"m 1 3", "b c a"},
+		{"m 3 1", "c a b"},
+		{"s 1 3", "c b a"},
+		{"x 2", "a c"},
+		{"x 3 1 3", "b"},
+		{"e 2", "a - b c"},
+		{"e 4", "a b c -"},
+		{"", "a b c"},
+	}
+	for _, tt := range tests {
+		job := editorJob()
+		e := &slotEditor{job: job}
+		if _, err := e.exec(tt.line); err != nil {
+			t.Errorf("%q failed: %v", tt.line, err)
+			continue
+		}
+		if got := slotNames(job); got != tt.want {
+			t.Errorf("%q: expected %s, got %s", tt.line, tt.want, got)
+		}
+	}
+}
+
+func TestSlotEditorEdits(t *testing.T) {
+	job := editorJob()
+	e := &slotEditor{job: job}
+	for _, line := range []string{"r 1 3", "n 2", "r 3", "t 2 40"} {
+		if _, err := e.exec(line); err != nil {
+			t.Fatalf("%q failed: %v", line, err)
+		}
+	}
+	got := []string{slotEdits(job.Slots[0]), slotEdits(job.Slots[1]), slotEdits(job.Slots[2])}
+	want := []string{"rev", "norm trim 40ms", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected edits %q, got %q", want, got)
+	}
+}
+
+func TestSlotEditorErrors(t *testing.T) {
+	for _, line := range []string{"m 1", "m 1 5", "x 0", "x", "e 6", "r two", "t 1 -5", "t 1 NaN", "t 1 Inf", "t 1 1e30", "t 1 600", "a", "a /missing.wav", "bogus"} {
+		job := editorJob()
+		job.Slots = append(job.Slots, Slot{})
+		e := &slotEditor{job: job}
+		if _, err := e.exec(line); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+		if got := slotNames(job); got != "a b c -" {
+			t.Errorf("%q changed the slots to %s", line, got)
+		}
+		if job.Slots[0].TrimMs != 0 {
+			t.Errorf("%q trimmed slot 1 by %g ms", line, job.Slots[0].TrimMs)
+		}
+	}
+
+	// Edits need a source
+	e := &slotEditor{job: &sliceJob{Settings: defaultSettings(), Slots: []Slot{{}}}}
+	if _, err := e.exec("r 1"); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("expected an empty slot error, got %v", err)
+	}
+}
+
+func TestSlotEditorAdd(t *testing.T) {
+	dir := t.TempDir()
+	writeWavFile(filepath.Join(dir, "new hit.wav"), [][]float64{make([]float64, 100)}, 44100, 1)
+
+	job := editorJob()
+	job.Settings.Dir = dir
+	e := &slotEditor{job: job}
+	if _, err := e.exec("a new hit.wav 2"); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if _, err := e.exec("a " + filepath.Join(dir, "new hit.wav")); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if got := slotNames(job); got != "a new hit b c new hit" {
+		t.Errorf("unexpected slots %s", got)
+	}
+	if job.Slots[1].Size == 0 || job.Slots[1].SampleRate != 44100 {
+		t.Errorf("expected the added file's header, got %+v", job.Slots[1].FileInfo)
+	}
+}
+
+func TestEditSlots(t *testing.T) {
+	tests := []struct {
+		input   string
+		proceed bool
+	}{
+		{"x 1\ny\n", true},
+		{"q\n", false},
+		{"n\n", false},
+		{"x 1 2 3\ny\nq\n", false},
+		{"m 1 2\n", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		proceed, err := editSlots(editorJob(), strings.NewReader(tt.input), &out, false)
+		if err != nil {
+			t.Fatalf("%q: editSlots failed: %v", tt.input, err)
+		}
+		if proceed != tt.proceed {
+			t.Errorf("%q: expected proceed %v, got %v", tt.input, tt.proceed, proceed)
+		}
+	}
+
+	var out bytes.Buffer
+	job := editorJob()
+	editSlots(job, strings.NewReader("bogus\nt 3 400\n"), &out, false)
+	screen := out.String()
+	for _, want := range []string{
+		"Batch 1 -> kit_4slices_batch001.wav",
+		"    1 C4   a.wav",
+		"truncated by 250 ms",
+		`Error: unknown command "bogus"`,
+		"3 files, 2 truncated",
+	} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("editor output is missing %q", want)
+		}
+	}
+	if strings.Contains(screen, clearScreen) {
+		t.Error("expected no screen clearing when not on a terminal")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// BatchLayout describes a written batch: where each slice starts and ends in
+// the combined file. Slices are contiguous and all SliceLength frames long.
+type BatchLayout struct {
+	Path        string
+	SampleRate  int
+	Channels    int
+	SliceLength int
+	Slots       []Slot
+}
+
+// NumSamples returns the length of the combined file in frames
+func (l BatchLayout) NumSamples() int {
+	return l.SliceLength * len(l.Slots)
+}
+
+// sliceBounds returns the first frame of slice i and the frame after its end
+func (l BatchLayout) sliceBounds(i int) (int, int) {
+	return i * l.SliceLength, (i + 1) * l.SliceLength
+}
+
+// slotName returns a display name for a slot: its source file without extension
+func slotName(slot Slot, index int) string {
+	if slot.Path == "" {
+		return fmt.Sprintf("Slice %02d", index+1)
+	}
+	return strings.TrimSuffix(filepath.Base(slot.Path), filepath.Ext(slot.Path))
+}
+
+// BatchResult is a written batch and the files exported alongside it
+type BatchResult struct {
+	BatchLayout
+	Exports []string
+}
+
+// Exporter writes files for another sampler, groovebox or DAW next to a
+// combined WAV once its batch is written
+type Exporter interface {
+	// Name is the -export value that selects the exporter
+	Name() string
+
+	// Export writes the files for a batch and returns their paths. Paths
+	// written before a failure are returned along with the error.
+	Export(layout BatchLayout) ([]string, error)
+}
+
+// exporterFunc adapts a write function to the Exporter interface
+type exporterFunc struct {
+	name  string
+	write func(layout BatchLayout) ([]string, error)
+}
+
+func (e exporterFunc) Name() string {
+	return e.name
+}
+
+func (e exporterFunc) Export(layout BatchLayout) ([]string, error) {
+	return e.write(layout)
+}
+
+// singleFile adapts a writer producing one file
+func singleFile(write func(BatchLayout) (string, error)) func(BatchLayout) ([]string, error) {
+	return func(layout BatchLayout) ([]string, error) {
+		path, err := write(layout)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+}
+
+// exporterNames lists the accepted -export values in help order
+var exporterNames = []string{"octatrack", "sfz", "decent-sampler", "ableton", "mpc", "blackbox", "tracker", "deluge", "op1", "midi", "preview", "waveform"}
+
+// newExporter returns the exporter for a name, configured from s. sfz uses the
+// -sfz options, writing offsets when -sfz itself is none.
+func newExporter(name string, s *Settings) (Exporter, error) {
+	switch name {
+	case "octatrack":
+		return exporterFunc{name, singleFile(writeOTFile)}, nil
+	case "sfz":
+		sfz := s.SFZ
+		if !sfz.enabled() {
+			sfz.Mode = SFZOffsets
+		}
+		return exporterFunc{name, func(layout BatchLayout) ([]string, error) {
+			return writeSFZFile(layout, sfz)
+		}}, nil
+	case "decent-sampler":
+		return exporterFunc{name, singleFile(writeDSPreset)}, nil
+	case "ableton":
+		return exporterFunc{name, writeAbletonPresets}, nil
+	case "mpc":
+		return exporterFunc{name, writeXPMProgram}, nil
+	case "blackbox":
+		return exporterFunc{name, writeBlackboxPresets}, nil
+	case "tracker":
+		return exporterFunc{name, singleFile(writeTrackerInstrument)}, nil
+	case "deluge":
+		return exporterFunc{name, singleFile(writeDelugeKit)}, nil
+	case "op1":
+		return exporterFunc{name, singleFile(writeOP1Drum)}, nil
+	case "preview":
+		preview := s.Preview
+		if !preview.enabled() {
+			preview.Format = PreviewWAV
+		}
+		return exporterFunc{name, singleFile(func(layout BatchLayout) (string, error) {
+			return writePreview(layout, preview)
+		})}, nil
+	case "waveform":
+		format := s.Waveform
+		if !format.enabled() {
+			format = WaveformBoth
+		}
+		return exporterFunc{name, func(layout BatchLayout) ([]string, error) {
+			return writeWaveforms(layout, format)
+		}}, nil
+	case "midi":
+		midi := s.MIDI
+		return exporterFunc{name, singleFile(func(layout BatchLayout) (string, error) {
+			return writeMIDIFile(layout, midi)
+		})}, nil
+	}
+	return nil, fmt.Errorf("unknown export %q (expected %s)", name, strings.Join(exporterNames, ", "))
+}
+
+// parseExportList splits and validates a comma-separated -export value
+func parseExportList(s string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, err := newExporter(name, defaultSettings()); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// OutputOptions selects what is written in and alongside each combined WAV
+type OutputOptions struct {
+	Bext      bool // Broadcast WAV bext chunk in the WAV itself
+	Exporters []Exporter
+}
+
+// outputOptions collects the exporters the device needs and those selected by
+// -export and the individual export flags, each once, in the order given
+func (s *Settings) outputOptions() OutputOptions {
+	var names []string
+	// The device was validated when the setting was parsed
+	if device, err := findDeviceProfile(s.Device); err == nil {
+		names = append(names, device.Exporters...)
+	}
+	names = append(names, s.Export...)
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"octatrack", s.Octatrack},
+		{"sfz", s.SFZ.enabled()},
+		{"decent-sampler", s.DecentSampler},
+		{"ableton", s.Ableton},
+		{"mpc", s.MPC},
+		{"midi", s.MIDI.Enabled},
+		{"preview", s.Preview.enabled()},
+		{"waveform", s.Waveform.enabled()},
+	} {
+		if flag.set {
+			names = append(names, flag.name)
+		}
+	}
+
+	out := OutputOptions{Bext: s.Bext}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		// Names were validated when the setting was parsed
+		e, _ := newExporter(name, s)
+		out.Exporters = append(out.Exporters, e)
+	}
+	return out
+}
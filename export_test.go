@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateFixtures = flag.Bool("update", false, "rewrite testdata fixtures from the current encoders")
+
+// checkFixture compares encoded output with testdata/name, rewriting it with -update
+func checkFixture(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateFixtures {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to update fixture: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; rerun with -update if the change is intended\ngot:\n%s", path, got)
+	}
+}
+
+// fixtureLayout is the batch every fixture is encoded from
+func fixtureLayout() BatchLayout {
+	return BatchLayout{
+		Path:        "/out/drums_3slices_batch001.wav",
+		SampleRate:  44100,
+		Channels:    1,
+		SliceLength: 4,
+		Slots: []Slot{
+			{FileInfo: FileInfo{Path: "/s/kick.wav"}},
+			{FileInfo: FileInfo{Path: "/s/snare & clap.wav"}},
+			{},
+		},
+	}
+}
+
+// ============================================================================
+// Exporter selection tests
+// ============================================================================
+
+func TestParseExportList(t *testing.T) {
+	names, err := parseExportList(" Blackbox, deluge,,tracker ")
+	if err != nil {
+		t.Fatalf("parseExportList failed: %v", err)
+	}
+	if want := []string{"blackbox", "deluge", "tracker"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	if _, err := parseExportList("blackbox,op-z"); err == nil {
+		t.Error("expected error for unknown export")
+	}
+}
+
+func TestNewExporter(t *testing.T) {
+	for _, name := range exporterNames {
+		e, err := newExporter(name, defaultSettings())
+		if err != nil {
+			t.Fatalf("newExporter(%q) failed: %v", name, err)
+		}
+		if e.Name() != name {
+			t.Errorf("expected name %q, got %q", name, e.Name())
+		}
+	}
+}
+
+func TestOutputOptions(t *testing.T) {
+	s := defaultSettings()
+	s.Export = []string{"deluge", "octatrack"}
+	s.Octatrack = true
+	s.MPC = true
+
+	var names []string
+	for _, e := range s.outputOptions().Exporters {
+		names = append(names, e.Name())
+	}
+	if want := []string{"deluge", "octatrack", "mpc"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
+
+// ============================================================================
+// Blackbox tests
+// ============================================================================
+
+func TestEncodeBlackboxPreset(t *testing.T) {
+	layout := fixtureLayout()
+	checkFixture(t, "blackbox_preset.xml", []byte(encodeBlackboxPreset(layout.Slots, 0, layout.SliceLength)))
+}
+
+// TestBlackboxPresetFormat reads the preset back the way the Blackbox does,
+// independently of the fixture: a version 2 document whose session cells sit on
+// the 4×4 pad grid, each a one-shot sample file next to preset.xml
+func TestBlackboxPresetFormat(t *testing.T) {
+	var doc struct {
+		XMLName xml.Name `xml:"document"`
+		Version string   `xml:"version,attr"`
+		Cells   []struct {
+			Row      int    `xml:"row,attr"`
+			Column   int    `xml:"column,attr"`
+			Layer    int    `xml:"layer,attr"`
+			Filename string `xml:"filename,attr"`
+			Type     string `xml:"type,attr"`
+			Params   struct {
+				CellMode int `xml:"cellmode,attr"`
+				SamStart int `xml:"samstart,attr"`
+				SamLen   int `xml:"samlen,attr"`
+			} `xml:"params"`
+		} `xml:"session>cell"`
+	}
+	slots := make([]Slot, 16)
+	slots[5] = Slot{FileInfo: FileInfo{Path: "/s/snare & clap.wav"}}
+	preset := encodeBlackboxPreset(slots, 0, 4)
+	if !strings.HasPrefix(preset, `<?xml version="1.0" encoding="UTF-8"?>`) {
+		t.Errorf("expected an XML declaration, got %q", preset[:min(40, len(preset))])
+	}
+	if err := xml.Unmarshal([]byte(preset), &doc); err != nil {
+		t.Fatalf("preset is not valid XML: %v", err)
+	}
+
+	if doc.Version != "2" || len(doc.Cells) != 16 {
+		t.Fatalf("expected a version 2 document with 16 cells, got version %q with %d", doc.Version, len(doc.Cells))
+	}
+	for i, c := range doc.Cells {
+		if c.Row != i/4 || c.Column != i%4 || c.Layer != 0 || c.Type != "sample" {
+			t.Errorf("cell %d: expected row %d column %d of a sample layer, got %+v", i, i/4, i%4, c)
+		}
+		if !strings.HasPrefix(c.Filename, `.\`) || strings.ContainsAny(c.Filename[2:], `\/`) {
+			t.Errorf("cell %d: expected a file in the preset folder, got %q", i, c.Filename)
+		}
+		if c.Params.CellMode != 0 || c.Params.SamStart != 0 || c.Params.SamLen != 4 {
+			t.Errorf("cell %d: expected a 4-frame one-shot, got %+v", i, c.Params)
+		}
+	}
+	if got := doc.Cells[5].Filename; got != `.\06 snare & clap.wav` {
+		t.Errorf("expected the escaped name to read back, got %q", got)
+	}
+}
+
+func TestBlackboxPresetDirs(t *testing.T) {
+	layout := BatchLayout{Path: "/out/kit.wav", Slots: make([]Slot, 16)}
+	if dirs := blackboxPresetDirs(layout); !reflect.DeepEqual(dirs, []string{"/out/kit"}) {
+		t.Errorf("unexpected dirs %v", dirs)
+	}
+	layout.Slots = make([]Slot, 17)
+	if dirs := blackboxPresetDirs(layout); !reflect.DeepEqual(dirs, []string{"/out/kit_A", "/out/kit_B"}) {
+		t.Errorf("unexpected dirs %v", dirs)
+	}
+}
+
+func TestWriteBlackboxPresets(t *testing.T) {
+	dir := t.TempDir()
+	layout := fixtureLayout()
+	layout.Path = filepath.Join(dir, "drums.wav")
+	writeWavFile(layout.Path, [][]float64{make([]float64, layout.NumSamples())}, 44100, 1)
+
+	paths, err := writeBlackboxPresets(layout)
+	if err != nil {
+		t.Fatalf("writeBlackboxPresets failed: %v", err)
+	}
+	if want := []string{filepath.Join(dir, "drums", "preset.xml")}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+
+	slice, err := readWavFile(filepath.Join(dir, "drums", "02 snare & clap.wav"))
+	if err != nil {
+		t.Fatalf("expected slice WAV: %v", err)
+	}
+	if slice.NumSamples != layout.SliceLength {
+		t.Errorf("expected %d samples, got %d", layout.SliceLength, slice.NumSamples)
+	}
+}
+
+// ============================================================================
+// Polyend Tracker tests
+// ============================================================================
+
+func TestEncodeTrackerInstrument(t *testing.T) {
+	layout := fixtureLayout()
+	audio := []float64{1, 0.5, 0, -0.5, -1, 0, 0.25, 0, 0, 0, 0, 0}
+	data, err := encodeTrackerInstrument(layout, audio)
+	if err != nil {
+		t.Fatalf("encodeTrackerInstrument failed: %v", err)
+	}
+	checkFixture(t, "tracker.pti", data)
+
+	t.Run("stereo", func(t *testing.T) {
+		stereo := fixtureLayout()
+		stereo.Channels = 2
+		if _, err := encodeTrackerInstrument(stereo, audio); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("too many slices", func(t *testing.T) {
+		layout.Slots = make([]Slot, ptiMaxSlices+1)
+		if _, err := encodeTrackerInstrument(layout, audio); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+// TestTrackerInstrumentFormat checks the .pti fields at the offsets the Tracker
+// reads them from, written out as literals rather than the encoder's constants
+func TestTrackerInstrumentFormat(t *testing.T) {
+	layout := fixtureLayout()
+	audio := []float64{1, 0.5, 0, -0.5, -1, 0, 0.25, 0, 0, 0, 0, 0}
+	data, err := encodeTrackerInstrument(layout, audio)
+	if err != nil {
+		t.Fatalf("encodeTrackerInstrument failed: %v", err)
+	}
+	le := binary.LittleEndian
+
+	if len(data) != 392+2*len(audio) {
+		t.Fatalf("expected a 392-byte header and 16-bit samples, got %d bytes", len(data))
+	}
+	if !bytes.Equal(data[0:2], []byte("TI")) {
+		t.Errorf("expected TI magic, got %q", data[0:2])
+	}
+	if name := string(bytes.TrimRight(data[21:52], "\x00")); name != "drums_3slices_batch001" {
+		t.Errorf("expected the instrument name at 21, got %q", name)
+	}
+	if n := le.Uint32(data[60:64]); n != 12 {
+		t.Errorf("expected a sample length of 12 at 60, got %d", n)
+	}
+	if mode := data[76]; mode != 4 {
+		t.Errorf("expected slice playback mode 4 at 76, got %d", mode)
+	}
+	if start, end := le.Uint16(data[78:80]), le.Uint16(data[84:86]); start != 0 || end != 65535 {
+		t.Errorf("expected playback over the whole sample, got %d-%d", start, end)
+	}
+	if volume, pan := data[272], data[276]; volume != 50 || pan != 50 {
+		t.Errorf("expected default volume and centre pan, got %d and %d", volume, pan)
+	}
+
+	// Three 4-frame slices of 12 start at 0, 1/3 and 2/3 of 65535
+	for i, want := range []uint16{0, 21845, 43690} {
+		if got := le.Uint16(data[280+2*i:]); got != want {
+			t.Errorf("slice %d: expected position %d, got %d", i+1, want, got)
+		}
+	}
+	if count := data[376]; count != 3 {
+		t.Errorf("expected 3 slices at 376, got %d", count)
+	}
+
+	for i, want := range []int16{32767, 16383, 0, -16383, -32767} {
+		if got := int16(le.Uint16(data[392+2*i:])); got != want {
+			t.Errorf("sample %d: expected %d, got %d", i, want, got)
+		}
+	}
+}
+
+// ============================================================================
+// Deluge tests
+// ============================================================================
+
+func TestEncodeDelugeKit(t *testing.T) {
+	checkFixture(t, "deluge_kit.xml", []byte(encodeDelugeKit(fixtureLayout())))
+}
+
+// TestDelugeKitFormat reads the kit back the way the Deluge does: one sound per
+// row, each playing once from its zone of the combined WAV on the card
+func TestDelugeKitFormat(t *testing.T) {
+	var kit struct {
+		XMLName  xml.Name `xml:"kit"`
+		Firmware string   `xml:"firmwareVersion"`
+		Sounds   []struct {
+			Name string `xml:"name"`
+			Osc  struct {
+				Type     string `xml:"type"`
+				LoopMode int    `xml:"loopMode"`
+				FileName string `xml:"fileName"`
+				Start    int    `xml:"zone>startSamplePos"`
+				End      int    `xml:"zone>endSamplePos"`
+			} `xml:"osc1"`
+		} `xml:"soundSources>sound"`
+	}
+	if err := xml.Unmarshal([]byte(encodeDelugeKit(fixtureLayout())), &kit); err != nil {
+		t.Fatalf("kit is not valid XML: %v", err)
+	}
+
+	if kit.Firmware == "" || len(kit.Sounds) != 3 {
+		t.Fatalf("expected a firmware version and 3 sounds, got %q and %d", kit.Firmware, len(kit.Sounds))
+	}
+	if kit.Sounds[1].Name != "snare & clap" {
+		t.Errorf("expected the escaped name to read back, got %q", kit.Sounds[1].Name)
+	}
+	for i, sound := range kit.Sounds {
+		osc := sound.Osc
+		if osc.Type != "sample" || osc.LoopMode != 1 || osc.FileName != "SAMPLES/WAVSLICE/drums_3slices_batch001.wav" {
+			t.Errorf("sound %d: expected a one-shot of the combined WAV, got %+v", i+1, osc)
+		}
+		// Zones end where the next one starts, so the rows tile the file
+		if osc.Start != 4*i || osc.End != 4*(i+1) {
+			t.Errorf("sound %d: expected zone %d-%d, got %d-%d", i+1, 4*i, 4*(i+1), osc.Start, osc.End)
+		}
+	}
+}
+
+func TestWriteDelugeKit(t *testing.T) {
+	dir := t.TempDir()
+	layout := fixtureLayout()
+	layout.Path = filepath.Join(dir, "drums.wav")
+
+	path, err := writeDelugeKit(layout)
+	if err != nil {
+		t.Fatalf("writeDelugeKit failed: %v", err)
+	}
+	if path != filepath.Join(dir, "drums.XML") {
+		t.Errorf("unexpected path %s", path)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// flacBlockSize is the number of frames per FLAC frame, the reference encoder's default
+const flacBlockSize = 4096
+
+// flacMaxRiceParam is the largest parameter a 4-bit Rice partition header holds
+// without the escape code
+const flacMaxRiceParam = 14
+
+// bitWriter packs big-endian bit fields, as FLAC frames are written
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// write appends the low n bits of v, n at most 32
+func (w *bitWriter) write(v uint64, n uint) {
+	w.acc = w.acc<<n | v&(1<<n-1)
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.nbits -= 8
+		w.buf = append(w.buf, byte(w.acc>>w.nbits))
+	}
+}
+
+// writeUnary appends q zero bits followed by a one
+func (w *bitWriter) writeUnary(q uint64) {
+	for ; q >= 32; q -= 32 {
+		w.write(0, 32)
+	}
+	w.write(1, uint(q)+1)
+}
+
+// align pads with zero bits to a byte boundary
+func (w *bitWriter) align() {
+	if w.nbits > 0 {
+		w.write(0, 8-w.nbits)
+	}
+}
+
+// crc8 is the FLAC frame header checksum (polynomial x^8+x^2+x+1)
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// crc16 is the FLAC frame footer checksum (polynomial x^16+x^15+x^2+1)
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// appendUTF8Number appends a frame number in FLAC's extended UTF-8 coding
+func appendUTF8Number(b []byte, n uint64) []byte {
+	if n < 0x80 {
+		return append(b, byte(n))
+	}
+	// Continuation bytes carry 6 bits each; the lead byte has 6-extra bits
+	extra := 1
+	for n >= 1<<(6*extra+6-extra) {
+		extra++
+	}
+	lead := byte(0xFF<<(7-extra)) | byte(n>>(6*extra))
+	b = append(b, lead)
+	for i := extra - 1; i >= 0; i-- {
+		b = append(b, 0x80|byte(n>>(6*i)&0x3F))
+	}
+	return b
+}
+
+// fixedResidual returns the prediction error of FLAC's fixed polynomial
+// predictor of the given order for samples past the warm-up
+func fixedResidual(s []int64, order int) []int64 {
+	res := make([]int64, 0, len(s)-order)
+	for i := order; i < len(s); i++ {
+		var p int64
+		switch order {
+		case 1:
+			p = s[i-1]
+		case 2:
+			p = 2*s[i-1] - s[i-2]
+		case 3:
+			p = 3*s[i-1] - 3*s[i-2] + s[i-3]
+		case 4:
+			p = 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+		}
+		res = append(res, s[i]-p)
+	}
+	return res
+}
+
+// zigzag folds a signed residual into the unsigned value Rice coding stores
+func zigzag(r int64) uint64 {
+	return uint64(r<<1 ^ r>>63)
+}
+
+// riceParam picks the parameter closest to log2 of the mean folded residual
+func riceParam(res []int64) uint {
+	if len(res) == 0 {
+		return 0
+	}
+	var sum uint64
+	for _, r := range res {
+		sum += zigzag(r)
+	}
+	mean := sum / uint64(len(res))
+	if mean == 0 {
+		return 0
+	}
+	return uint(min(bits.Len64(mean)-1, flacMaxRiceParam))
+}
+
+// riceBits returns how many bits residuals take with parameter k
+func riceBits(res []int64, k uint) uint64 {
+	n := uint64(0)
+	for _, r := range res {
+		n += zigzag(r)>>k + 1 + uint64(k)
+	}
+	return n
+}
+
+// writeSubframe writes one channel of a frame as a FIXED subframe with the
+// cheapest predictor order, or VERBATIM when prediction does not help
+func writeSubframe(w *bitWriter, s []int64, bps uint) {
+	bestOrder, bestBits := -1, uint64(len(s))*uint64(bps)
+	var bestRes []int64
+	var bestK uint
+	for order := 0; order <= 4 && order < len(s); order++ {
+		res := fixedResidual(s, order)
+		k := riceParam(res)
+		n := uint64(order)*uint64(bps) + 10 + riceBits(res, k)
+		if n < bestBits {
+			bestOrder, bestBits, bestRes, bestK = order, n, res, k
+		}
+	}
+
+	w.write(0, 1) // padding
+	if bestOrder < 0 {
+		w.write(0x01, 6) // VERBATIM
+		w.write(0, 1)    // no wasted bits
+		for _, v := range s {
+			w.write(uint64(v), bps)
+		}
+		return
+	}
+
+	w.write(0x08|uint64(bestOrder), 6) // FIXED
+	w.write(0, 1)
+	for _, v := range s[:bestOrder] {
+		w.write(uint64(v), bps)
+	}
+	w.write(0, 2) // Rice coding with 4-bit parameters
+	w.write(0, 4) // a single partition
+	w.write(uint64(bestK), 4)
+	for _, r := range bestRes {
+		u := zigzag(r)
+		w.writeUnary(u >> bestK)
+		w.write(u, bestK)
+	}
+}
+
+// encodeFLAC encodes samples as a 16-bit FLAC stream
+func encodeFLAC(samples [][]float64, sampleRate int) []byte {
+	const bps = 16
+	numChannels := len(samples)
+	numFrames := 0
+	if numChannels > 0 {
+		numFrames = len(samples[0])
+	}
+
+	// Quantize as writeWavFile does; the MD5 covers little-endian PCM
+	pcm := make([][]int64, numChannels)
+	sum := md5.New()
+	le := make([]byte, 2)
+	for ch := range pcm {
+		pcm[ch] = make([]int64, numFrames)
+	}
+	for i := 0; i < numFrames; i++ {
+		for ch := range pcm {
+			v := int16(math.Max(-1, math.Min(1, samples[ch][i])) * 32767)
+			pcm[ch][i] = int64(v)
+			binary.LittleEndian.PutUint16(le, uint16(v))
+			sum.Write(le)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("fLaC")
+
+	// STREAMINFO, the only metadata block
+	info := bitWriter{}
+	info.write(flacBlockSize, 16)
+	info.write(flacBlockSize, 16)
+	info.write(0, 24) // frame sizes unknown
+	info.write(0, 24)
+	info.write(uint64(sampleRate), 20)
+	info.write(uint64(numChannels-1), 3)
+	info.write(bps-1, 5)
+	info.write(uint64(numFrames)>>32, 4)
+	info.write(uint64(numFrames)&0xFFFFFFFF, 32)
+	out.Write([]byte{0x80, 0, 0, 34}) // last block, type 0, length
+	out.Write(info.buf)
+	out.Write(sum.Sum(nil))
+
+	for frame, start := uint64(0), 0; start < numFrames; frame, start = frame+1, start+flacBlockSize {
+		end := min(start+flacBlockSize, numFrames)
+
+		header := []byte{0xFF, 0xF8, 0x70, byte(numChannels-1)<<4 | 0x08}
+		header = appendUTF8Number(header, frame)
+		header = binary.BigEndian.AppendUint16(header, uint16(end-start-1))
+		header = append(header, crc8(header))
+
+		w := bitWriter{buf: header}
+		for ch := range pcm {
+			writeSubframe(&w, pcm[ch][start:end], bps)
+		}
+		w.align()
+		out.Write(binary.BigEndian.AppendUint16(w.buf, crc16(w.buf)))
+	}
+	return out.Bytes()
+}
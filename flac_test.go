@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// bitReader reads the big-endian bit fields written by bitWriter
+type bitReader struct {
+	data []byte
+	pos  uint // bit offset
+}
+
+func (r *bitReader) read(n uint) uint64 {
+	var v uint64
+	for i := uint(0); i < n; i++ {
+		bit := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | uint64(bit)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) readSigned(n uint) int64 {
+	v := r.read(n)
+	return int64(v<<(64-n)) >> (64 - n)
+}
+
+func (r *bitReader) readUnary() uint64 {
+	q := uint64(0)
+	for r.read(1) == 0 {
+		q++
+	}
+	return q
+}
+
+// decodeFLAC decodes the subset of FLAC that encodeFLAC writes, checking every
+// frame's checksums and the stream MD5
+func decodeFLAC(t *testing.T, data []byte) (int, [][]int64) {
+	t.Helper()
+	if string(data[0:4]) != "fLaC" || data[4] != 0x80 {
+		t.Fatal("missing fLaC marker or STREAMINFO")
+	}
+	info := bitReader{data: data[8:42]}
+	info.read(16 + 16 + 24 + 24)
+	rate := int(info.read(20))
+	numChannels := int(info.read(3)) + 1
+	if bps := info.read(5) + 1; bps != 16 {
+		t.Fatalf("expected 16 bits per sample, got %d", bps)
+	}
+	total := int(info.read(36))
+	wantMD5 := data[26:42]
+
+	pcm := make([][]int64, numChannels)
+	off := 42
+	for frame := 0; off < len(data); frame++ {
+		r := bitReader{data: data[off:]}
+		if sync := r.read(16); sync != 0xFFF8 {
+			t.Fatalf("frame %d: bad sync %#x", frame, sync)
+		}
+		r.read(8)
+		if ch := int(r.read(4)) + 1; ch != numChannels {
+			t.Fatalf("frame %d: %d channels", frame, ch)
+		}
+		r.read(4)
+		// Frame numbers in these tests stay below 0x80, one byte
+		if n := int(r.read(8)); n != frame {
+			t.Fatalf("frame %d: numbered %d", frame, n)
+		}
+		blockSize := int(r.read(16)) + 1
+		headerLen := int(r.pos / 8)
+		if crc := byte(r.read(8)); crc != crc8(data[off:off+headerLen]) {
+			t.Fatalf("frame %d: header CRC mismatch", frame)
+		}
+
+		for ch := range pcm {
+			r.read(1)
+			kind := r.read(6)
+			r.read(1)
+			s := make([]int64, 0, blockSize)
+			switch {
+			case kind == 0x01:
+				for i := 0; i < blockSize; i++ {
+					s = append(s, r.readSigned(16))
+				}
+			case kind&0x38 == 0x08:
+				order := int(kind & 0x07)
+				for i := 0; i < order; i++ {
+					s = append(s, r.readSigned(16))
+				}
+				r.read(2 + 4)
+				k := uint(r.read(4))
+				for i := order; i < blockSize; i++ {
+					u := r.readUnary()<<k | r.read(k)
+					res := int64(u>>1) ^ -int64(u&1)
+					// The residual of a zero sample is minus the prediction
+					pred := fixedResidual(append(s[i-order:i:i], 0), order)
+					s = append(s, res-pred[0])
+				}
+			default:
+				t.Fatalf("frame %d: unexpected subframe type %#x", frame, kind)
+			}
+			pcm[ch] = append(pcm[ch], s...)
+		}
+
+		if r.pos%8 != 0 {
+			r.read(8 - r.pos%8)
+		}
+		end := off + int(r.pos/8)
+		if crc := binary.BigEndian.Uint16(data[end:]); crc != crc16(data[off:end]) {
+			t.Fatalf("frame %d: footer CRC mismatch", frame)
+		}
+		off = end + 2
+	}
+
+	if len(pcm[0]) != total {
+		t.Fatalf("STREAMINFO says %d frames, decoded %d", total, len(pcm[0]))
+	}
+	sum := md5.New()
+	for i := 0; i < total; i++ {
+		for ch := range pcm {
+			sum.Write(binary.LittleEndian.AppendUint16(nil, uint16(pcm[ch][i])))
+		}
+	}
+	if string(sum.Sum(nil)) != string(wantMD5) {
+		t.Error("MD5 of decoded audio does not match STREAMINFO")
+	}
+	return rate, pcm
+}
+
+// ============================================================================
+// FLAC tests
+// ============================================================================
+
+func TestEncodeFLACRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples [][]float64
+	}{
+		{"silence", [][]float64{make([]float64, 100)}},
+		{"sine over several frames", func() [][]float64 {
+			l, r := make([]float64, 2*flacBlockSize+123), make([]float64, 2*flacBlockSize+123)
+			for i := range l {
+				l[i] = 0.8 * math.Sin(float64(i)/7)
+				r[i] = -0.3 * math.Sin(float64(i)/31)
+			}
+			return [][]float64{l, r}
+		}()},
+		{"noise-like full scale", func() [][]float64 {
+			s := make([]float64, 500)
+			for i := range s {
+				s[i] = math.Mod(float64(i)*0.618034, 2) - 1
+			}
+			s[0], s[1] = 1, -1
+			return [][]float64{s}
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, pcm := decodeFLAC(t, encodeFLAC(tt.samples, 22050))
+			if rate != 22050 {
+				t.Errorf("expected 22050 Hz, got %d", rate)
+			}
+			if len(pcm) != len(tt.samples) {
+				t.Fatalf("expected %d channels, got %d", len(tt.samples), len(pcm))
+			}
+			for ch := range pcm {
+				for i, v := range pcm[ch] {
+					if want := int64(int16(tt.samples[ch][i] * 32767)); v != want {
+						t.Fatalf("channel %d sample %d: expected %d, got %d", ch, i, want, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAppendUTF8Number(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want []byte
+	}{
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0xC2, 0x80}},
+		{0x7FF, []byte{0xDF, 0xBF}},
+		{0x800, []byte{0xE0, 0xA0, 0x80}},
+		{0x10000, []byte{0xF0, 0x90, 0x80, 0x80}},
+	}
+	for _, tt := range tests {
+		if got := appendUTF8Number(nil, tt.n); string(got) != string(tt.want) {
+			t.Errorf("appendUTF8Number(%#x) = % x, expected % x", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+// A 3×5 pixel bitmap font for labelling PNG images without a font package.
+// Each glyph is five 3-bit rows, top row in the high bits. Lower case letters
+// are drawn as upper case and anything else as '?'.
+const (
+	glyphWidth   = 3
+	glyphHeight  = 5
+	glyphAdvance = glyphWidth + 1
+)
+
+var glyphs = map[rune]uint16{
+	' ':  0,
+	'0':  0b111_101_101_101_111,
+	'1':  0b010_110_010_010_111,
+	'2':  0b111_001_111_100_111,
+	'3':  0b111_001_111_001_111,
+	'4':  0b101_101_111_001_001,
+	'5':  0b111_100_111_001_111,
+	'6':  0b111_100_111_101_111,
+	'7':  0b111_001_001_001_001,
+	'8':  0b111_101_111_101_111,
+	'9':  0b111_101_111_001_111,
+	'A':  0b010_101_111_101_101,
+	'B':  0b110_101_110_101_110,
+	'C':  0b011_100_100_100_011,
+	'D':  0b110_101_101_101_110,
+	'E':  0b111_100_110_100_111,
+	'F':  0b111_100_110_100_100,
+	'G':  0b011_100_101_101_011,
+	'H':  0b101_101_111_101_101,
+	'I':  0b111_010_010_010_111,
+	'J':  0b001_001_001_101_010,
+	'K':  0b101_101_110_101_101,
+	'L':  0b100_100_100_100_111,
+	'M':  0b101_111_111_101_101,
+	'N':  0b110_101_101_101_101,
+	'O':  0b010_101_101_101_010,
+	'P':  0b110_101_110_100_100,
+	'Q':  0b010_101_101_110_011,
+	'R':  0b110_101_110_101_101,
+	'S':  0b011_100_010_001_110,
+	'T':  0b111_010_010_010_010,
+	'U':  0b101_101_101_101_111,
+	'V':  0b101_101_101_101_010,
+	'W':  0b101_101_111_111_101,
+	'X':  0b101_101_010_101_101,
+	'Y':  0b101_101_010_010_010,
+	'Z':  0b111_001_010_100_111,
+	'#':  0b010_111_010_111_010,
+	'-':  0b000_000_111_000_000,
+	'_':  0b000_000_000_000_111,
+	'.':  0b000_000_000_000_010,
+	',':  0b000_000_000_010_100,
+	'(':  0b001_010_010_010_001,
+	')':  0b100_010_010_010_100,
+	'&':  0b010_101_010_101_011,
+	'+':  0b000_010_111_010_000,
+	'\'': 0b010_010_000_000_000,
+	'?':  0b111_001_010_000_010,
+}
+
+// textWidth returns the width in pixels of s drawn at scale, without trailing space
+func textWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (n*glyphAdvance - 1) * scale
+}
+
+// fitText shortens s to at most width pixels at scale, marking the cut with '.'
+func fitText(s string, width, scale int) string {
+	r := []rune(s)
+	maxChars := (width/scale + 1) / glyphAdvance
+	if len(r) <= maxChars {
+		return s
+	}
+	if maxChars <= 1 {
+		return string(r[:max(maxChars, 0)])
+	}
+	return string(r[:maxChars-1]) + "."
+}
+
+// drawText draws s with its top left corner at x, y, each font pixel scale
+// pixels square
+func drawText(img *image.RGBA, x, y, scale int, s string, c color.Color) {
+	for _, ch := range strings.ToUpper(s) {
+		bits, ok := glyphs[ch]
+		if !ok && !unicode.IsSpace(ch) {
+			bits = glyphs['?']
+		}
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if bits>>((glyphHeight-1-row)*glyphWidth+glyphWidth-1-col)&1 == 0 {
+					continue
+				}
+				fillRect(img, x+col*scale, y+row*scale, scale, scale, c)
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
+
+// fillRect fills a w×h rectangle, clipped to the image
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.Set(px, py, c)
+		}
+	}
+}
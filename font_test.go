@@ -0,0 +1,49 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// ============================================================================
+// Bitmap font tests
+// ============================================================================
+
+func TestDrawText(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	black := color.RGBA{0, 0, 0, 0xFF}
+	drawText(img, 1, 1, 1, "t1", black)
+
+	// 'T' has a full top row and a centre stem; '1' starts one advance later
+	set := func(x, y int) bool { return img.RGBAAt(x, y) == black }
+	for _, p := range []image.Point{{1, 1}, {2, 1}, {3, 1}, {2, 5}, {1 + glyphAdvance + 1, 1}} {
+		if !set(p.X, p.Y) {
+			t.Errorf("expected pixel at %v", p)
+		}
+	}
+	for _, p := range []image.Point{{1, 5}, {3, 5}, {0, 0}} {
+		if set(p.X, p.Y) {
+			t.Errorf("unexpected pixel at %v", p)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"kick", textWidth("kick", 2), "kick"},
+		{"kick", textWidth("kick", 2) - 1, "ki."},
+		{"kick", textWidth("ki", 2), "k."},
+		{"kick", glyphAdvance * 2, "k"},
+		{"kick", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := fitText(tt.s, tt.width, 2); got != tt.want {
+			t.Errorf("fitText(%q, %d) = %q, expected %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
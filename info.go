@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// maxDecodedChunkSize caps how much of a non-audio chunk is loaded for decoding
+const maxDecodedChunkSize = 1 << 20
+
+// WavReport is the result of inspecting a WAV file's structure
+type WavReport struct {
+	Path     string
+	FileSize int64
+	RiffSize int64 // from ds64 for RF64/BW64 files
+	Header   WavHeader
+	Chunks   []RiffChunk
+	Details  map[int][]string // decoded contents, keyed by chunk index
+	Errors   []string         // problems that make the file invalid
+	Warnings []string         // recoverable inconsistencies
+}
+
+// Valid reports whether inspection found no errors
+func (r *WavReport) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+func (r *WavReport) errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *WavReport) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// inspectWavFile walks every chunk of a file, decodes known chunks and checks consistency
+func inspectWavFile(path string) (*WavReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &WavReport{Path: path, FileSize: stat.Size(), Details: make(map[int][]string)}
+
+	var riff [12]byte
+	if _, err := io.ReadFull(f, riff[:]); err != nil {
+		report.errorf("file too short for a RIFF header")
+		return report, nil
+	}
+	report.RiffSize = int64(binary.LittleEndian.Uint32(riff[4:8]))
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	report.Chunks, err = listChunks(f)
+	if err != nil {
+		report.errorf("%v", err)
+		return report, nil
+	}
+
+	if isRF64([4]byte(riff[0:4])) {
+		checkDS64(report, f, string(riff[0:4]))
+	}
+
+	switch declared := report.RiffSize + 8; {
+	case report.RiffSize == 0 || report.RiffSize == 0xFFFFFFFF:
+		report.warnf("RIFF size is unset (0x%08x), as written by streaming recorders", report.RiffSize)
+	case declared > report.FileSize:
+		report.errorf("RIFF size %d exceeds file size %d: file is truncated", report.RiffSize, report.FileSize-8)
+	case declared < report.FileSize:
+		report.warnf("RIFF size %d is %d bytes smaller than the file", report.RiffSize, report.FileSize-declared)
+	}
+
+	var fmtFound, dataFound, factFound bool
+	var dataSize int64
+	for i, c := range report.Chunks {
+		end := c.Offset + 8 + c.Size
+		if c.Unpadded {
+			report.warnf("%q chunk at offset %d has odd size %d but no pad byte", c.ID, c.Offset, c.Size)
+		}
+
+		if c.ID == "data" {
+			if !fmtFound {
+				report.warnf("data chunk at offset %d comes before the fmt chunk", c.Offset)
+			}
+			dataFound = true
+			dataSize = c.Size
+			remaining := report.FileSize - c.Offset - 8
+			switch {
+			case c.Streamed:
+				report.warnf("data size is unset, as written by streaming recorders; the samples run to the end of the file")
+			case end > report.FileSize:
+				report.errorf("data chunk claims %d bytes but only %d remain", c.Size, remaining)
+			}
+			continue
+		}
+
+		if end > report.FileSize {
+			report.errorf("%q chunk at offset %d extends past end of file", c.ID, c.Offset)
+			continue
+		}
+		if c.Size > maxDecodedChunkSize {
+			report.Details[i] = []string{"(too large to decode)"}
+			continue
+		}
+
+		data := make([]byte, c.Size)
+		if _, err := f.Seek(c.Offset+8, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(f, data); err != nil {
+			report.errorf("reading %q chunk: %v", c.ID, err)
+			continue
+		}
+
+		if c.ID == "fact" {
+			factFound = true
+		}
+		if c.ID == "fmt " {
+			if err := parseFmtChunk(&report.Header, data); err != nil {
+				report.errorf("%v", err)
+			}
+			fmtFound = true
+		}
+		report.Details[i] = describeChunk(c.ID, data)
+	}
+
+	if !fmtFound {
+		report.errorf("fmt chunk not found")
+	}
+	if !dataFound {
+		report.errorf("data chunk not found")
+	}
+	if fmtFound {
+		checkFormat(report, dataSize)
+		if !factFound && !isUncompressed(report.Header.AudioFormat) {
+			report.warnf("compressed format %d has no fact chunk; the frame count is estimated from the data size", report.Header.AudioFormat)
+		}
+	}
+
+	return report, nil
+}
+
+// checkDS64 takes the RIFF size of an RF64/BW64 file from its ds64 chunk, which
+// must come first
+func checkDS64(report *WavReport, r io.ReadSeeker, marker string) {
+	if len(report.Chunks) == 0 || report.Chunks[0].ID != "ds64" {
+		report.errorf("%s file has no ds64 chunk", marker)
+		return
+	}
+
+	data := make([]byte, min(report.Chunks[0].Size, 1024))
+	if _, err := r.Seek(report.Chunks[0].Offset+8, io.SeekStart); err != nil {
+		report.errorf("reading ds64 chunk: %v", err)
+		return
+	}
+	if _, err := io.ReadFull(r, data); err != nil {
+		report.errorf("reading ds64 chunk: %v", err)
+		return
+	}
+	sizes, err := parseDS64(data)
+	if err != nil {
+		report.errorf("%v", err)
+		return
+	}
+	if report.RiffSize == rf64SizeUnset {
+		report.RiffSize = int64(min(sizes.RiffSize, 1<<62))
+	}
+}
+
+// checkFormat flags fmt fields that disagree with each other or with the data size
+func checkFormat(report *WavReport, dataSize int64) {
+	h := report.Header
+	if h.NumChannels == 0 {
+		report.errorf("channel count is zero")
+	}
+	if h.SampleRate == 0 {
+		report.errorf("sample rate is zero")
+	}
+
+	// Only uncompressed formats have a fixed relation between block size and bit depth
+	if !isUncompressed(h.AudioFormat) {
+		return
+	}
+
+	expectedAlign := uint32(h.NumChannels) * uint32((h.BitsPerSample+7)/8)
+	if uint32(h.BlockAlign) != expectedAlign {
+		report.errorf("block align %d does not match %d channels x %d bits (expected %d)", h.BlockAlign, h.NumChannels, h.BitsPerSample, expectedAlign)
+	}
+	if h.ByteRate != h.SampleRate*uint32(h.BlockAlign) {
+		report.warnf("byte rate %d does not match sample rate x block align (expected %d)", h.ByteRate, h.SampleRate*uint32(h.BlockAlign))
+	}
+	if h.BlockAlign > 0 && dataSize != 0xFFFFFFFF && dataSize%int64(h.BlockAlign) != 0 {
+		report.warnf("data size %d is not a multiple of block align %d", dataSize, h.BlockAlign)
+	}
+}
+
+// isUncompressed reports whether a format tag stores plain PCM or float samples
+func isUncompressed(format uint16) bool {
+	return format == 1 || format == 3 || format == 0xFFFE
+}
+
+// describeChunk decodes the contents of chunks wavslice knows about
+func describeChunk(id string, data []byte) []string {
+	switch id {
+	case "fmt ":
+		return describeFmt(data)
+	case "ds64":
+		sizes, err := parseDS64(data)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return []string{
+			fmt.Sprintf("RIFF size: %d", sizes.RiffSize),
+			fmt.Sprintf("data size: %d", sizes.DataSize),
+			fmt.Sprintf("sample frames: %d", sizes.SampleCount),
+		}
+	case "fact":
+		if len(data) >= 4 {
+			return []string{fmt.Sprintf("sample frames: %d", binary.LittleEndian.Uint32(data))}
+		}
+	case "smpl":
+		return describeSmpl(data)
+	case "cue ":
+		cues, err := parseCuePoints(data)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		lines := []string{fmt.Sprintf("%d cue points", len(cues))}
+		for i, c := range cues {
+			lines = append(lines, fmt.Sprintf("cue %d at frame %d", i+1, c))
+		}
+		return lines
+	case "LIST":
+		return describeList(data)
+	case "bext":
+		return describeBext(data)
+	case "iXML":
+		return []string{truncateText(string(data), 200)}
+	case "acid":
+		return describeAcid(data)
+	}
+	return nil
+}
+
+func describeFmt(data []byte) []string {
+	if len(data) < 16 {
+		return []string{"too short"}
+	}
+	le := binary.LittleEndian
+	lines := []string{
+		fmt.Sprintf("format %d, %d ch, %d Hz, %d bits", le.Uint16(data[0:2]), le.Uint16(data[2:4]), le.Uint32(data[4:8]), le.Uint16(data[14:16])),
+		fmt.Sprintf("byte rate %d, block align %d", le.Uint32(data[8:12]), le.Uint16(data[12:14])),
+	}
+	if le.Uint16(data[0:2]) == 0xFFFE && len(data) >= 40 {
+		lines = append(lines, fmt.Sprintf("valid bits %d, channel mask 0x%08x, subformat %x", le.Uint16(data[18:20]), le.Uint32(data[20:24]), data[24:40]))
+	}
+	if format := le.Uint16(data[0:2]); (format == formatIMAADPCM || format == formatMSADPCM) && len(data) >= 20 {
+		lines = append(lines, fmt.Sprintf("ADPCM, %d samples per block", le.Uint16(data[18:20])))
+	}
+	return lines
+}
+
+func describeSmpl(data []byte) []string {
+	if len(data) < 36 {
+		return []string{"too short"}
+	}
+	le := binary.LittleEndian
+	unity := int(le.Uint32(data[12:16]))
+	loops := int(le.Uint32(data[28:32]))
+	lines := []string{
+		fmt.Sprintf("manufacturer 0x%x, product 0x%x, sample period %d ns", le.Uint32(data[0:4]), le.Uint32(data[4:8]), le.Uint32(data[8:12])),
+		fmt.Sprintf("root note %d (%s), pitch fraction %d", unity, noteName(unity), le.Uint32(data[16:20])),
+		fmt.Sprintf("%d loops", loops),
+	}
+	for i := 0; i < loops && 36+(i+1)*24 <= len(data); i++ {
+		l := data[36+i*24:]
+		lines = append(lines, fmt.Sprintf("loop %d: type %d, frames %d-%d, play count %d", i+1, le.Uint32(l[4:8]), le.Uint32(l[8:12]), le.Uint32(l[12:16]), le.Uint32(l[20:24])))
+	}
+	return lines
+}
+
+// listEntry is one subchunk of a LIST chunk
+type listEntry struct {
+	ID   string
+	Data []byte
+}
+
+// parseListChunk splits a LIST chunk body into its type and subchunks
+func parseListChunk(data []byte) (string, []listEntry, error) {
+	if len(data) < 4 {
+		return "", nil, errors.New("LIST chunk too short")
+	}
+
+	listType := string(data[0:4])
+	var entries []listEntry
+	for p := 4; p+8 <= len(data); {
+		id := string(data[p : p+4])
+		size := int(binary.LittleEndian.Uint32(data[p+4 : p+8]))
+		p += 8
+		if size > len(data)-p {
+			return listType, entries, fmt.Errorf("%q subchunk overruns LIST", id)
+		}
+		entries = append(entries, listEntry{ID: id, Data: data[p : p+size]})
+		p += size + size%2
+	}
+	return listType, entries, nil
+}
+
+func describeList(data []byte) []string {
+	listType, entries, err := parseListChunk(data)
+	lines := []string{"type " + listType}
+	for _, e := range entries {
+		switch {
+		case listType == "INFO":
+			lines = append(lines, fmt.Sprintf("%s: %s", e.ID, cString(e.Data)))
+		case listType == "adtl" && (e.ID == "labl" || e.ID == "note") && len(e.Data) >= 4:
+			lines = append(lines, fmt.Sprintf("%s cue %d: %s", e.ID, binary.LittleEndian.Uint32(e.Data), cString(e.Data[4:])))
+		default:
+			lines = append(lines, fmt.Sprintf("%s (%d bytes)", e.ID, len(e.Data)))
+		}
+	}
+	if err != nil {
+		lines = append(lines, err.Error())
+	}
+	return lines
+}
+
+func describeBext(data []byte) []string {
+	// Description(256) Originator(32) OriginatorReference(32) OriginationDate(10)
+	// OriginationTime(8) TimeReference(8) Version(2) ...
+	if len(data) < 348 {
+		return []string{"too short"}
+	}
+	timeRef := binary.LittleEndian.Uint64(data[338:346])
+	return []string{
+		"description: " + cString(data[0:256]),
+		"originator: " + cString(data[256:288]),
+		"reference: " + cString(data[288:320]),
+		fmt.Sprintf("date: %s %s", cString(data[320:330]), cString(data[330:338])),
+		fmt.Sprintf("time reference: %d, version %d", timeRef, binary.LittleEndian.Uint16(data[346:348])),
+	}
+}
+
+func describeAcid(data []byte) []string {
+	if len(data) < 24 {
+		return []string{"too short"}
+	}
+	le := binary.LittleEndian
+	flags := le.Uint32(data[0:4])
+	root := int(le.Uint16(data[4:6]))
+	tempo := math.Float32frombits(le.Uint32(data[20:24]))
+	kind := "loop"
+	if flags&0x01 != 0 {
+		kind = "one-shot"
+	}
+	return []string{
+		fmt.Sprintf("%s, root note %d (%s)", kind, root, noteName(root)),
+		fmt.Sprintf("%d beats, %d/%d, %.2f BPM", le.Uint32(data[12:16]), le.Uint16(data[18:20]), le.Uint16(data[16:18]), tempo),
+	}
+}
+
+// cString returns text up to the first NUL, trimmed of whitespace
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// truncateText collapses whitespace and shortens text for one-line display
+func truncateText(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > max {
+		s = s[:max-3] + "..."
+	}
+	return s
+}
+
+// runInfo prints the chunk layout and decoded contents of each file
+func runInfo(args []string) error {
+	if err := textOnly("info", args); err != nil {
+		return err
+	}
+	return inspectFiles(args, true)
+}
+
+// runValidate prints only problems, one line per file
+func runValidate(args []string) error {
+	if err := textOnly("validate", args); err != nil {
+		return err
+	}
+	return inspectFiles(args, false)
+}
+
+// inspectFiles reports on each file and fails if any are invalid
+func inspectFiles(paths []string, verbose bool) error {
+	if len(paths) == 0 {
+		return errors.New("no files given")
+	}
+
+	invalid := 0
+	for i, path := range paths {
+		report, err := inspectWavFile(path)
+		if err != nil {
+			fmt.Printf("INVALID  %s\n         - %v\n", path, err)
+			invalid++
+			continue
+		}
+		if !report.Valid() {
+			invalid++
+		}
+
+		if verbose {
+			if i > 0 {
+				fmt.Println()
+			}
+			printReport(report)
+			continue
+		}
+
+		status := "OK"
+		if !report.Valid() {
+			status = "INVALID"
+		}
+		fmt.Printf("%-8s %s\n", status, path)
+		for _, e := range report.Errors {
+			fmt.Printf("         - error: %s\n", e)
+		}
+		for _, w := range report.Warnings {
+			fmt.Printf("         - warning: %s\n", w)
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files are invalid", invalid, len(paths))
+	}
+	return nil
+}
+
+// printReport dumps a full inspection report
+func printReport(r *WavReport) {
+	fmt.Printf("%s\n", r.Path)
+	fmt.Printf("  File size: %d bytes, RIFF size: %d\n", r.FileSize, r.RiffSize)
+	fmt.Println("  Chunks:")
+	for i, c := range r.Chunks {
+		fmt.Printf("    %-4s  offset %-10d size %d\n", c.ID, c.Offset, c.Size)
+		for _, line := range r.Details[i] {
+			fmt.Printf("            %s\n", line)
+		}
+	}
+
+	h := r.Header
+	if h.SampleRate > 0 && h.BlockAlign > 0 {
+		for _, c := range r.Chunks {
+			if c.ID == "data" {
+				frames := int64(c.Size) / int64(h.BlockAlign)
+				fmt.Printf("  Audio: %d frames (%.3fs)\n", frames, float64(frames)/float64(h.SampleRate))
+				break
+			}
+		}
+	}
+
+	for _, e := range r.Errors {
+		fmt.Printf("  Error: %s\n", e)
+	}
+	for _, w := range r.Warnings {
+		fmt.Printf("  Warning: %s\n", w)
+	}
+	if r.Valid() {
+		fmt.Println("  Valid")
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// listChunks tests
+// ============================================================================
+
+func TestListChunks(t *testing.T) {
+	t.Run("basic file", func(t *testing.T) {
+		buf := createTestWavBuffer(1, 16, 44100, 1, make([]byte, 8))
+		chunks, err := listChunks(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("listChunks failed: %v", err)
+		}
+		if len(chunks) != 2 {
+			t.Fatalf("expected 2 chunks, got %d", len(chunks))
+		}
+		if chunks[0].ID != "fmt " || chunks[0].Offset != 12 || chunks[0].Size != 16 {
+			t.Errorf("unexpected fmt chunk %+v", chunks[0])
+		}
+		if chunks[1].ID != "data" || chunks[1].Offset != 36 || chunks[1].Size != 8 {
+			t.Errorf("unexpected data chunk %+v", chunks[1])
+		}
+	})
+
+	t.Run("odd-size chunk is padded", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		buf.Write([]byte("RIFF"))
+		binary.Write(buf, binary.LittleEndian, uint32(0))
+		buf.Write([]byte("WAVE"))
+		buf.Write([]byte("LIST"))
+		binary.Write(buf, binary.LittleEndian, uint32(3))
+		buf.Write([]byte{1, 2, 3, 0}) // 3 bytes + pad
+		buf.Write([]byte("data"))
+		binary.Write(buf, binary.LittleEndian, uint32(2))
+		buf.Write([]byte{0, 0})
+
+		chunks, err := listChunks(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("listChunks failed: %v", err)
+		}
+		if len(chunks) != 2 || chunks[1].ID != "data" || chunks[1].Offset != 24 {
+			t.Errorf("expected data chunk at offset 24, got %+v", chunks)
+		}
+	})
+
+	t.Run("missing pad byte is tolerated", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		buf.Write([]byte("RIFF"))
+		binary.Write(buf, binary.LittleEndian, uint32(0))
+		buf.Write([]byte("WAVE"))
+		buf.Write([]byte("LIST"))
+		binary.Write(buf, binary.LittleEndian, uint32(3))
+		buf.Write([]byte{1, 2, 3}) // no pad
+		buf.Write([]byte("data"))
+		binary.Write(buf, binary.LittleEndian, uint32(2))
+		buf.Write([]byte{0, 0})
+
+		chunks, err := listChunks(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("listChunks failed: %v", err)
+		}
+		if len(chunks) != 2 || chunks[1].ID != "data" || chunks[1].Offset != 23 {
+			t.Fatalf("expected data chunk at offset 23, got %+v", chunks)
+		}
+		if !chunks[0].Unpadded {
+			t.Error("expected LIST chunk to be flagged as unpadded")
+		}
+	})
+
+	t.Run("not a wave file", func(t *testing.T) {
+		if _, err := listChunks(bytes.NewReader([]byte("RIFX0000WAVE"))); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+// ============================================================================
+// inspectWavFile tests
+// ============================================================================
+
+// hasIssue reports whether any message contains substr
+func hasIssue(messages []string, substr string) bool {
+	for _, m := range messages {
+		if strings.Contains(m, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// infoListBody builds a LIST/INFO chunk body from id/value pairs
+func infoListBody(pairs ...string) []byte {
+	body := []byte("INFO")
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := append([]byte(pairs[i+1]), 0)
+		body = append(body, []byte(pairs[i])...)
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(value)))
+		body = append(body, value...)
+		if len(value)%2 == 1 {
+			body = append(body, 0)
+		}
+	}
+	return body
+}
+
+func TestInspectWavFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("valid file with metadata", func(t *testing.T) {
+		path := filepath.Join(dir, "meta.wav")
+		writeWavFile(path, [][]float64{make([]float64, 10)}, 44100, 1)
+		appendChunk(t, path, "LIST", infoListBody("INAM", "Big Kick", "ICMT", "test"))
+		appendChunk(t, path, "cue ", cueChunkBody(0, 5))
+
+		smpl := make([]byte, 36)
+		binary.LittleEndian.PutUint32(smpl[12:16], 36)
+		appendChunk(t, path, "smpl", smpl)
+
+		acid := make([]byte, 24)
+		binary.LittleEndian.PutUint32(acid[0:4], 1)
+		binary.LittleEndian.PutUint16(acid[4:6], 48)
+		binary.LittleEndian.PutUint32(acid[20:24], math.Float32bits(120))
+		appendChunk(t, path, "acid", acid)
+
+		bext := make([]byte, 602)
+		copy(bext, "kick drum")
+		appendChunk(t, path, "bext", bext)
+
+		report, err := inspectWavFile(path)
+		if err != nil {
+			t.Fatalf("inspectWavFile failed: %v", err)
+		}
+		if !report.Valid() || len(report.Warnings) != 0 {
+			t.Errorf("expected clean report, got errors %v warnings %v", report.Errors, report.Warnings)
+		}
+		if len(report.Chunks) != 7 {
+			t.Fatalf("expected 7 chunks, got %d", len(report.Chunks))
+		}
+
+		var all []string
+		for _, lines := range report.Details {
+			all = append(all, lines...)
+		}
+		for _, want := range []string{"INAM: Big Kick", "cue 2 at frame 5", "root note 36 (C2)", "one-shot, root note 48 (C3)", "120.00 BPM", "description: kick drum", "format 1, 1 ch, 44100 Hz, 16 bits"} {
+			if !hasIssue(all, want) {
+				t.Errorf("expected decoded detail %q in %v", want, all)
+			}
+		}
+	})
+
+	t.Run("truncated file", func(t *testing.T) {
+		path := filepath.Join(dir, "truncated.wav")
+		writeWavFile(path, [][]float64{make([]float64, 10)}, 44100, 1)
+		data, _ := os.ReadFile(path)
+		os.WriteFile(path, data[:len(data)-4], 0644)
+
+		report, err := inspectWavFile(path)
+		if err != nil {
+			t.Fatalf("inspectWavFile failed: %v", err)
+		}
+		if report.Valid() {
+			t.Error("expected truncated file to be invalid")
+		}
+		if !hasIssue(report.Errors, "truncated") || !hasIssue(report.Errors, "data chunk claims") {
+			t.Errorf("unexpected errors %v", report.Errors)
+		}
+	})
+
+	t.Run("block align mismatch", func(t *testing.T) {
+		path := filepath.Join(dir, "align.wav")
+		writeWavFile(path, [][]float64{make([]float64, 10)}, 44100, 1)
+		data, _ := os.ReadFile(path)
+		binary.LittleEndian.PutUint16(data[32:34], 4) // BlockAlign
+		os.WriteFile(path, data, 0644)
+
+		report, _ := inspectWavFile(path)
+		if !hasIssue(report.Errors, "block align 4") {
+			t.Errorf("expected block align error, got %v", report.Errors)
+		}
+		if !hasIssue(report.Warnings, "byte rate") {
+			t.Errorf("expected byte rate warning, got %v", report.Warnings)
+		}
+	})
+
+	t.Run("unpadded chunk and streaming sizes", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		buf.Write([]byte("RIFF"))
+		binary.Write(buf, binary.LittleEndian, uint32(0xFFFFFFFF))
+		buf.Write([]byte("WAVE"))
+		buf.Write([]byte("LIST"))
+		binary.Write(buf, binary.LittleEndian, uint32(5))
+		buf.Write([]byte("INFO!"))
+		src := createTestWavBuffer(1, 16, 44100, 1, make([]byte, 4)).Bytes()
+		buf.Write(src[12:36]) // fmt chunk
+		buf.Write([]byte("data"))
+		binary.Write(buf, binary.LittleEndian, uint32(0))
+		buf.Write(make([]byte, 4))
+
+		path := filepath.Join(dir, "streaming.wav")
+		os.WriteFile(path, buf.Bytes(), 0644)
+
+		report, _ := inspectWavFile(path)
+		if !report.Valid() {
+			t.Errorf("expected recoverable file to be valid, got %v", report.Errors)
+		}
+		for _, want := range []string{"RIFF size is unset", "no pad byte", "data size is unset"} {
+			if !hasIssue(report.Warnings, want) {
+				t.Errorf("expected warning %q in %v", want, report.Warnings)
+			}
+		}
+	})
+
+	t.Run("streaming data size with audio after it", func(t *testing.T) {
+		// Audio that reads as an oversized chunk header if walked as chunks
+		audio := make([]byte, 4000)
+		for i := 0; i < len(audio); i += 4 {
+			copy(audio[i:], []byte{0xf0, 0xd8, 0x15, 0xd9})
+		}
+		path := filepath.Join(dir, "recorder.wav")
+		os.WriteFile(path, riffFile(0,
+			riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true),
+			append([]byte("data\x00\x00\x00\x00"), audio...)), 0644)
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("open failed: %v", err)
+		}
+		_, dataSize, err := readWavHeader(f)
+		f.Close()
+		if err != nil || dataSize != 4000 {
+			t.Fatalf("expected readWavHeader to infer 4000 bytes, got %d, %v", dataSize, err)
+		}
+
+		report, err := inspectWavFile(path)
+		if err != nil {
+			t.Fatalf("inspectWavFile failed: %v", err)
+		}
+		if !report.Valid() || !hasIssue(report.Warnings, "data size is unset") {
+			t.Errorf("expected a valid file with a streaming warning, got errors %v warnings %v", report.Errors, report.Warnings)
+		}
+		if last := report.Chunks[len(report.Chunks)-1]; last.ID != "data" || last.Size != 4000 || !last.Streamed {
+			t.Errorf("expected the data chunk to run to the end of the file, got %+v", report.Chunks)
+		}
+		if err := inspectFiles([]string{path}, false); err != nil {
+			t.Errorf("expected validate to accept the file, got %v", err)
+		}
+	})
+
+	t.Run("missing chunks", func(t *testing.T) {
+		path := filepath.Join(dir, "empty.wav")
+		os.WriteFile(path, []byte("RIFF\x04\x00\x00\x00WAVE"), 0644)
+
+		report, _ := inspectWavFile(path)
+		if !hasIssue(report.Errors, "fmt chunk not found") || !hasIssue(report.Errors, "data chunk not found") {
+			t.Errorf("unexpected errors %v", report.Errors)
+		}
+	})
+}
+
+// ============================================================================
+// parseListChunk tests
+// ============================================================================
+
+func TestParseListChunk(t *testing.T) {
+	listType, entries, err := parseListChunk(infoListBody("INAM", "odd", "IGNR", "drums"))
+	if err != nil {
+		t.Fatalf("parseListChunk failed: %v", err)
+	}
+	if listType != "INFO" || len(entries) != 2 {
+		t.Fatalf("unexpected result %s %v", listType, entries)
+	}
+	if cString(entries[1].Data) != "drums" {
+		t.Errorf("expected drums, got %q", cString(entries[1].Data))
+	}
+
+	bad := infoListBody("INAM", "x")
+	binary.LittleEndian.PutUint32(bad[8:12], 100)
+	if _, _, err := parseListChunk(bad); err == nil {
+		t.Error("expected overrun error")
+	}
+}
+
+// ============================================================================
+// info and validate command tests
+// ============================================================================
+
+func TestInspectFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.wav")
+	writeWavFile(path, [][]float64{{0.1, 0.2}}, 44100, 1)
+
+	bad := filepath.Join(dir, "bad.wav")
+	os.WriteFile(bad, []byte("not a wav"), 0644)
+
+	for _, run := range []func([]string) error{runInfo, runValidate} {
+		if err := run([]string{path}); err != nil {
+			t.Errorf("expected valid file to pass: %v", err)
+		}
+		if err := run([]string{path, bad}); err == nil {
+			t.Error("expected error for invalid file")
+		}
+		if err := run(nil); err == nil {
+			t.Error("expected usage error")
+		}
+	}
+}
+
+func TestInspectRF64(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rf64.wav")
+	os.WriteFile(path, rf64File("RF64", pcmFmtBody(1, 44100, 16), make([]byte, 8)), 0644)
+
+	report, err := inspectWavFile(path)
+	if err != nil {
+		t.Fatalf("inspectWavFile failed: %v", err)
+	}
+	if !report.Valid() || len(report.Warnings) != 0 {
+		t.Errorf("expected clean report, got errors %v warnings %v", report.Errors, report.Warnings)
+	}
+	if !hasIssue(report.Details[0], "data size: 8") {
+		t.Errorf("expected decoded ds64, got %v", report.Details[0])
+	}
+
+	t.Run("missing ds64", func(t *testing.T) {
+		data, _ := os.ReadFile(path)
+		copy(data[12:16], "JUNK")
+		os.WriteFile(path, data, 0644)
+
+		report, _ := inspectWavFile(path)
+		if !hasIssue(report.Errors, "no ds64 chunk") {
+			t.Errorf("expected missing ds64 error, got %v", report.Errors)
+		}
+	})
+}
+
+func TestInspectCompressedWithoutFact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alaw.wav")
+	os.WriteFile(path, riffFile(0,
+		riffChunk("fmt ", codecFmtBody(formatALaw, 1, 8000, 1, 8, []byte{}), true),
+		riffChunk("data", []byte{0xD5, 0x55}, true)), 0644)
+
+	report, _ := inspectWavFile(path)
+	if !report.Valid() || !hasIssue(report.Warnings, "no fact chunk") {
+		t.Errorf("expected missing fact warning only, got errors %v warnings %v", report.Errors, report.Warnings)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kit is a hand-picked list of slots loaded from a JSON kit definition file.
+//
+// Example:
+//
+//	{
+//	  "name": "house-kit",
+//	  "slices": 16,
+//	  "slots": [
+//	    {"file": "kicks/kick_01.wav", "gain": -3},
+//	    {"file": "snares/snare_04.wav", "trim": 12.5, "fade_out": 20},
+//	    {},
+//	    {"file": "fx/crash.wav", "reverse": true, "normalize": true}
+//	  ]
+//	}
+//
+// Relative file paths are resolved against the kit file's directory. A slot
+// without a file is rendered as silence so later slots keep their keys.
+type Kit struct {
+	Name   string    `json:"name"`
+	Slices *int      `json:"slices"` // nil leaves the -slices setting alone
+	Slots  []KitSlot `json:"slots"`
+
+	dir string // directory of the kit file
+}
+
+// KitSlot is one entry of a kit definition
+type KitSlot struct {
+	File      string  `json:"file"`
+	Gain      float64 `json:"gain"` // dB
+	Trim      float64 `json:"trim"` // ms skipped from the start of the source
+	Reverse   bool    `json:"reverse"`
+	Normalize bool    `json:"normalize"`
+	FadeIn    float64 `json:"fade_in"`  // ms
+	FadeOut   float64 `json:"fade_out"` // ms
+}
+
+// loadKit reads and validates a kit definition file
+func loadKit(path string) (*Kit, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kit Kit
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&kit); err != nil {
+		return nil, fmt.Errorf("invalid kit file %s: %v", path, err)
+	}
+	kit.dir = filepath.Dir(path)
+
+	if kit.Name == "" {
+		kit.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := kit.validate(); err != nil {
+		return nil, err
+	}
+
+	return &kit, nil
+}
+
+// validate checks the slot count, slice count and slot edits of a named kit
+func (k *Kit) validate() error {
+	if len(k.Slots) == 0 {
+		return fmt.Errorf("kit %s has no slots", k.Name)
+	}
+	if k.Slices != nil && (*k.Slices < 1 || *k.Slices > 64) {
+		return fmt.Errorf("kit %s: slices must be between 1 and 64", k.Name)
+	}
+
+	for i, s := range k.Slots {
+		if s.Trim < 0 || s.FadeIn < 0 || s.FadeOut < 0 {
+			return fmt.Errorf("kit %s slot %d: trim and fades must not be negative", k.Name, i+1)
+		}
+	}
+	return nil
+}
+
+// resolveSlots reads each slot's source header and returns slots ready for processBatch
+func (k *Kit) resolveSlots() ([]Slot, error) {
+	slots := make([]Slot, len(k.Slots))
+
+	for i, s := range k.Slots {
+		slot := s.slot()
+		if s.File != "" {
+			path := s.File
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(k.dir, path)
+			}
+
+			info, err := readSourceInfo(path)
+			if err != nil {
+				return nil, fmt.Errorf("slot %d (%s): %v", i+1, s.File, err)
+			}
+			slot.FileInfo = info
+			if err := slot.checkTrim(); err != nil {
+				return nil, fmt.Errorf("slot %d (%s): %v", i+1, s.File, err)
+			}
+		}
+
+		slots[i] = slot
+	}
+
+	return slots, nil
+}
+
+// readSourceInfo reads a hand-picked source's header and file size
+func readSourceInfo(path string) (FileInfo, error) {
+	info, err := readWavInfo(path)
+	if err != nil {
+		return info, err
+	}
+	if stat, err := os.Stat(path); err == nil {
+		info.Size = stat.Size()
+	}
+	return info, nil
+}
+
+// checkTrim rejects a trim that skips past the end of the slot's source
+func (s Slot) checkTrim() error {
+	if s.TrimMs > s.Duration*1000 {
+		return fmt.Errorf("trim of %g ms is longer than the source (%.0f ms)", s.TrimMs, s.Duration*1000)
+	}
+	return nil
+}
+
+// slot returns an empty slot carrying the entry's edits
+func (s KitSlot) slot() Slot {
+	return Slot{
+		GainDB:    s.Gain,
+		TrimMs:    s.Trim,
+		Reverse:   s.Reverse,
+		Normalize: s.Normalize,
+		FadeInMs:  s.FadeIn,
+		FadeOutMs: s.FadeOut,
+	}
+}
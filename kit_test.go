@@ -0,0 +1,246 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// loadKit tests
+// ============================================================================
+
+func TestLoadKit(t *testing.T) {
+	t.Run("valid kit", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "house.json")
+		data := `{
+			"name": "house",
+			"slices": 16,
+			"slots": [
+				{"file": "kick.wav", "gain": -3},
+				{},
+				{"file": "crash.wav", "reverse": true, "trim": 10, "fade_in": 1, "fade_out": 5}
+			]
+		}`
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+
+		kit, err := loadKit(path)
+		if err != nil {
+			t.Fatalf("loadKit failed: %v", err)
+		}
+		if kit.Name != "house" {
+			t.Errorf("expected name house, got %s", kit.Name)
+		}
+		if kit.Slices == nil || *kit.Slices != 16 {
+			t.Errorf("expected 16 slices, got %v", kit.Slices)
+		}
+		if len(kit.Slots) != 3 {
+			t.Fatalf("expected 3 slots, got %d", len(kit.Slots))
+		}
+		if kit.Slots[0].Gain != -3 {
+			t.Errorf("expected gain -3, got %f", kit.Slots[0].Gain)
+		}
+		if !kit.Slots[2].Reverse || kit.Slots[2].FadeOut != 5 {
+			t.Errorf("slot 3 settings not parsed: %+v", kit.Slots[2])
+		}
+	})
+
+	t.Run("name defaults to filename", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "my-kit.json")
+		os.WriteFile(path, []byte(`{"slots": [{"file": "a.wav"}]}`), 0644)
+
+		kit, err := loadKit(path)
+		if err != nil {
+			t.Fatalf("loadKit failed: %v", err)
+		}
+		if kit.Name != "my-kit" {
+			t.Errorf("expected name my-kit, got %s", kit.Name)
+		}
+		if kit.Slices != nil {
+			t.Errorf("expected slices to be unset, got %d", *kit.Slices)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+		}{
+			{"invalid json", `{"slots": [`},
+			{"unknown field", `{"slots": [{"file": "a.wav", "volume": 1}]}`},
+			{"no slots", `{"name": "empty"}`},
+			{"zero slices", `{"slices": 0, "slots": [{"file": "a.wav"}]}`},
+			{"too many slices", `{"slices": 65, "slots": [{"file": "a.wav"}]}`},
+			{"negative fade", `{"slots": [{"file": "a.wav", "fade_out": -1}]}`},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "kit.json")
+				os.WriteFile(path, []byte(tc.data), 0644)
+				if _, err := loadKit(path); err == nil {
+					t.Error("expected error")
+				}
+			})
+		}
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		if _, err := loadKit("/nonexistent/kit.json"); err == nil {
+			t.Error("expected error for nonexistent file")
+		}
+	})
+}
+
+// ============================================================================
+// Kit.resolveSlots tests
+// ============================================================================
+
+func TestKitResolveSlots(t *testing.T) {
+	t.Run("resolves relative paths and settings", func(t *testing.T) {
+		dir := t.TempDir()
+		os.MkdirAll(filepath.Join(dir, "kicks"), 0755)
+		writeWavFile(filepath.Join(dir, "kicks", "kick.wav"), [][]float64{{0.5, 0.5}}, 44100, 1)
+
+		kitPath := filepath.Join(dir, "kit.json")
+		os.WriteFile(kitPath, []byte(`{"slots": [{"file": "kicks/kick.wav", "gain": 6}, {}]}`), 0644)
+
+		kit, err := loadKit(kitPath)
+		if err != nil {
+			t.Fatalf("loadKit failed: %v", err)
+		}
+		slots, err := kit.resolveSlots()
+		if err != nil {
+			t.Fatalf("resolveSlots failed: %v", err)
+		}
+		if len(slots) != 2 {
+			t.Fatalf("expected 2 slots, got %d", len(slots))
+		}
+		if slots[0].Path != filepath.Join(dir, "kicks", "kick.wav") {
+			t.Errorf("unexpected path %s", slots[0].Path)
+		}
+		if slots[0].NumSamples != 2 || slots[0].Size == 0 {
+			t.Errorf("expected header info to be read, got %+v", slots[0].FileInfo)
+		}
+		if slots[0].GainDB != 6 {
+			t.Errorf("expected gain 6, got %f", slots[0].GainDB)
+		}
+		if slots[1].Path != "" {
+			t.Errorf("expected empty slot, got %s", slots[1].Path)
+		}
+	})
+
+	t.Run("trim longer than the source", func(t *testing.T) {
+		dir := t.TempDir()
+		writeWavFile(filepath.Join(dir, "kick.wav"), [][]float64{make([]float64, 441)}, 44100, 1)
+		kitPath := filepath.Join(dir, "kit.json")
+		os.WriteFile(kitPath, []byte(`{"slots": [{"file": "kick.wav", "trim": 1e30}]}`), 0644)
+
+		kit, err := loadKit(kitPath)
+		if err != nil {
+			t.Fatalf("loadKit failed: %v", err)
+		}
+		if _, err := kit.resolveSlots(); err == nil || !strings.Contains(err.Error(), "longer than the source") {
+			t.Errorf("expected a trim error, got %v", err)
+		}
+	})
+
+	t.Run("missing source file", func(t *testing.T) {
+		dir := t.TempDir()
+		kitPath := filepath.Join(dir, "kit.json")
+		os.WriteFile(kitPath, []byte(`{"slots": [{"file": "missing.wav"}]}`), 0644)
+
+		kit, err := loadKit(kitPath)
+		if err != nil {
+			t.Fatalf("loadKit failed: %v", err)
+		}
+		if _, err := kit.resolveSlots(); err == nil {
+			t.Error("expected error for missing source")
+		}
+	})
+}
+
+// ============================================================================
+// Kit processing integration tests
+// ============================================================================
+
+func TestProcessBatchWithKitSlots(t *testing.T) {
+	dir := t.TempDir()
+
+	src := filepath.Join(dir, "ramp.wav")
+	writeWavFile(src, [][]float64{{0.1, 0.2, 0.3, 0.4, 0.5}}, 44100, 1)
+	info, err := readWavInfo(src)
+	if err != nil {
+		t.Fatalf("readWavInfo failed: %v", err)
+	}
+
+	slots := []Slot{
+		{FileInfo: info},
+		{},
+		{FileInfo: info, Reverse: true, GainDB: -6},
+	}
+
+	outputFile := filepath.Join(dir, "kit.wav")
+	if err := processBatch(slots, 44100, 1, 10, t.TempDir(), outputFile, false, MixOptions{}, nil); err != nil {
+		t.Fatalf("processBatch failed: %v", err)
+	}
+
+	wav, err := readWavFile(outputFile)
+	if err != nil {
+		t.Fatalf("readWavFile failed: %v", err)
+	}
+	out := wav.Samples[0]
+	if len(out) != 30 {
+		t.Fatalf("expected 30 samples, got %d", len(out))
+	}
+
+	// Slot 1 is the untouched ramp
+	if math.Abs(out[0]-0.1) > 0.01 {
+		t.Errorf("expected slot 1 to start at 0.1, got %f", out[0])
+	}
+	// Slot 2 is silent
+	for i := 10; i < 20; i++ {
+		if out[i] != 0 {
+			t.Errorf("expected silence in empty slot at %d, got %f", i, out[i])
+			break
+		}
+	}
+	// Slot 3 is reversed and attenuated by ~6 dB
+	if math.Abs(out[20]-0.5*0.501) > 0.01 {
+		t.Errorf("expected reversed slot to start at ~0.25, got %f", out[20])
+	}
+}
+
+func TestProcessBatchNormalizesSlot(t *testing.T) {
+	dir := t.TempDir()
+
+	src := filepath.Join(dir, "ramp.wav")
+	writeWavFile(src, [][]float64{{0.1, 0.2, 0.3, 0.4, 0.5}}, 44100, 1)
+	info, err := readWavInfo(src)
+	if err != nil {
+		t.Fatalf("readWavInfo failed: %v", err)
+	}
+
+	outputFile := filepath.Join(dir, "kit.wav")
+	if err := processBatch([]Slot{{FileInfo: info, Normalize: true}}, 44100, 1, 3, t.TempDir(), outputFile, false, MixOptions{}, nil); err != nil {
+		t.Fatalf("processBatch failed: %v", err)
+	}
+
+	wav, err := readWavFile(outputFile)
+	if err != nil {
+		t.Fatalf("readWavFile failed: %v", err)
+	}
+
+	// Only the three samples that fit are normalized, so 0.3 becomes full scale
+	for i, want := range []float64{1.0 / 3, 2.0 / 3, 1} {
+		if math.Abs(wav.Samples[0][i]-want) > 0.01 {
+			t.Errorf("sample %d: expected %.3f, got %.3f", i, want, wav.Samples[0][i])
+		}
+	}
+}
@@ -1,9 +1,7 @@
 package main
 
 import (
-	"bufio"
 	"encoding/binary"
-	"flag"
 	"fmt"
 	"io"
 	"math"
@@ -45,6 +43,12 @@ type WavHeader struct {
 	ExtValidBits   uint16
 	ExtChannelMask uint32
 	ExtSubFormat   [16]byte
+
+	SamplesPerBlock uint16     // ADPCM frames per block from the fmt extension, 0 to use all a block holds
+	Coefs           [][2]int16 // MS ADPCM predictor coefficient pairs
+	FactFrames      uint32     // frame count from the fact chunk, 0 if absent
+
+	Tags SourceTags // descriptive tags collected during the chunk walk
 }
 
 // WavFile represents a WAV file with its metadata and samples
@@ -52,10 +56,11 @@ type WavFile struct {
 	Path       string
 	Header     WavHeader
 	Samples    [][]float64 // [channel][sample]
-	DataSize   uint32
+	DataSize   int64
 	FileSize   int64
 	Duration   float64
 	NumSamples int
+	Metadata   *WavMetadata // LIST/INFO and bext contents, nil if absent
 }
 
 // FileInfo stores information about found WAV files
@@ -67,116 +72,48 @@ type FileInfo struct {
 	BitDepth   uint16
 	Duration   float64
 	NumSamples int
-}
-
-func main() {
-	// Parse command line arguments
-	workDir := flag.String("dir", ".", "Working directory to search for WAV files")
-	pattern := flag.String("pattern", "", "File pattern to search for (e.g., 'kick')")
-	sampleRate := flag.Int("rate", 44100, "Output sample rate in Hz (e.g., 44100, 22050, 14700, 11025)")
-	stereo := flag.Bool("stereo", false, "Output stereo (default is mono)")
-	sliceCount := flag.Int("slices", 32, "Number of slices per output file (1-64)")
-	normalize := flag.Bool("normalize", false, "Normalize volume before saving combined output")
-	outputDir := flag.String("output", ".", "Output directory for combined WAV files")
-	flag.Parse()
-
-	// Validate arguments
-	if *pattern == "" {
-		fmt.Println("Error: -pattern is required")
-		flag.Usage()
-		os.Exit(1)
-	}
-
-	if *sliceCount < 1 || *sliceCount > 64 {
-		fmt.Println("Error: -slices must be between 1 and 64")
-		os.Exit(1)
-	}
-
-	validRates := map[int]bool{44100: true, 22050: true, 14700: true, 11025: true}
-	if !validRates[*sampleRate] {
-		fmt.Println("Error: -rate must be one of: 44100, 22050, 14700, 11025")
-		os.Exit(1)
-	}
-
-	// Calculate slice duration
-	numChannels := 1
-	if *stereo {
-		numChannels = 2
-	}
-
-	maxSamples := MaxTotalSamples / numChannels
-	samplesPerSlice := maxSamples / *sliceCount
-	sliceDurationMs := float64(samplesPerSlice) / float64(*sampleRate) * 1000.0
 
-	channelMode := "Mono"
-	if *stereo {
-		channelMode = "Stereo"
-	}
-
-	fmt.Println("=== WAV Sample Slicer ===")
-	fmt.Printf("Working Directory: %s\n", *workDir)
-	fmt.Printf("Pattern: %s\n", *pattern)
-	fmt.Printf("Output Sample Rate: %d Hz\n", *sampleRate)
-	fmt.Printf("Output Channels: %s\n", channelMode)
-	fmt.Printf("Slice Count: %d\n", *sliceCount)
-	fmt.Printf("Samples per Slice: %d\n", samplesPerSlice)
-	fmt.Printf("Slice Duration: %.2f ms\n", sliceDurationMs)
-	fmt.Printf("Max Total Duration: %.3f s\n", float64(maxSamples)/float64(*sampleRate))
-	fmt.Println()
-
-	// Build regex pattern from user input
-	regexPattern := fmt.Sprintf("(?i)^.*%s.*\\.wav$", regexp.QuoteMeta(*pattern))
-	re, err := regexp.Compile(regexPattern)
-	if err != nil {
-		fmt.Printf("Error compiling regex: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Printf("Searching with regex: %s\n\n", regexPattern)
-
-	// Find matching files
-	files, err := findWavFiles(*workDir, re)
-	if err != nil {
-		fmt.Printf("Error searching for files: %v\n", err)
-		os.Exit(1)
-	}
-
-	if len(files) == 0 {
-		fmt.Println("No matching WAV files found.")
-		os.Exit(0)
-	}
+	// Embedded tags, and which one matched the search pattern when the file name did not
+	Tags       SourceTags
+	MatchedTag string
 
-	// Display summary
-	displaySummary(files)
+	// Left/right correlation of a stereo or surround source, when checked before a mono run
+	Correlation        float64
+	CorrelationChecked bool
+}
 
-	// Ask for confirmation
-	fmt.Print("\nProceed with processing? (y/n): ")
-	reader := bufio.NewReader(os.Stdin)
-	response, _ := reader.ReadString('\n')
-	response = strings.TrimSpace(strings.ToLower(response))
-	if response != "y" && response != "yes" {
-		fmt.Println("Aborted.")
-		os.Exit(0)
-	}
+// Slot is a source file assigned to one slice position, with optional per-slot edits.
+// A slot with an empty Path renders as silence.
+type Slot struct {
+	FileInfo
+	GainDB    float64 // gain applied after trimming, in dB
+	TrimMs    float64 // offset into the source before silence removal, in milliseconds
+	Reverse   bool
+	Normalize bool // peak-normalize the part of the source that fits, before gain
+	FadeInMs  float64
+	FadeOutMs float64
+}
 
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(*outputDir, 0755); err != nil {
-		fmt.Printf("Error creating output directory: %v\n", err)
-		os.Exit(1)
+// slotsFromFiles wraps search results as unedited slots
+func slotsFromFiles(files []FileInfo) []Slot {
+	slots := make([]Slot, len(files))
+	for i, f := range files {
+		slots[i] = Slot{FileInfo: f}
 	}
+	return slots
+}
 
-	// Process files in batches
-	err = processFiles(files, *sampleRate, numChannels, *sliceCount, samplesPerSlice, *pattern, *outputDir, *normalize)
-	if err != nil {
-		fmt.Printf("Error processing files: %v\n", err)
+func main() {
+	if err := runCommand(os.Args[1:]); err != nil {
+		progress.fail(err)
 		os.Exit(1)
 	}
-
-	fmt.Println("\nProcessing complete!")
 }
 
-// findWavFiles recursively searches for WAV files matching the pattern
-func findWavFiles(root string, pattern *regexp.Regexp) ([]FileInfo, error) {
+// findWavFiles recursively searches for WAV files whose name matches the pattern.
+// When tagPattern is not nil, WAV files with other names are also included if one
+// of their embedded tags matches it.
+func findWavFiles(root string, pattern, tagPattern *regexp.Regexp) ([]FileInfo, error) {
 	var files []FileInfo
 
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
@@ -192,11 +129,22 @@ func findWavFiles(root string, pattern *regexp.Regexp) ([]FileInfo, error) {
 			// Read WAV header to get metadata
 			wavInfo, err := readWavInfo(path)
 			if err != nil {
-				fmt.Printf("Warning: Could not read %s: %v\n", path, err)
+				progress.warn(path, "Could not read %s: %v (run 'wavslice info' on it for details)", path, err)
 				return nil
 			}
 			wavInfo.Size = info.Size()
 			files = append(files, wavInfo)
+		} else if tagPattern != nil && hasWavExtension(path) {
+			// Unreadable files are only reported when their name matched
+			wavInfo, err := readWavInfo(path)
+			if err != nil {
+				return nil
+			}
+			if tag, value := wavInfo.Tags.match(tagPattern); tag != "" {
+				wavInfo.Size = info.Size()
+				wavInfo.MatchedTag = fmt.Sprintf("%s %q", tag, value)
+				files = append(files, wavInfo)
+			}
 		}
 
 		return nil
@@ -223,7 +171,11 @@ func readWavInfo(path string) (FileInfo, error) {
 		return FileInfo{}, err
 	}
 
-	numSamples := int(dataSize) / int(header.NumChannels) / int(header.BitsPerSample/8)
+	dec, err := newFrameDecoder(header)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	numSamples := int(dec.frameCount(header, dataSize))
 	duration := float64(numSamples) / float64(header.SampleRate)
 
 	return FileInfo{
@@ -233,19 +185,29 @@ func readWavInfo(path string) (FileInfo, error) {
 		BitDepth:   header.BitsPerSample,
 		Duration:   duration,
 		NumSamples: numSamples,
+		Tags:       header.Tags,
 	}, nil
 }
 
-// readWavHeader reads and parses a WAV file header
-func readWavHeader(r io.ReadSeeker) (WavHeader, uint32, error) {
+// readWavHeader reads and parses a WAV file header, leaving r positioned at the
+// start of the sample data. The chunk walk honours RIFF pad bytes after odd-sized
+// chunks, accepts a fmt chunk after the data chunk, and infers the data size from
+// the stream length when a streaming recorder left it as 0 or 0xFFFFFFFF.
+// RF64/BW64 files take their data size from the ds64 chunk, and Sony Wave64
+// files are handed to readW64Header. The walk continues past data to the end of
+// the file to collect tag chunks (INFO, bext, iXML, smpl, acid) into header.Tags.
+func readWavHeader(r io.ReadSeeker) (WavHeader, int64, error) {
 	var header WavHeader
-	var dataSize uint32
 
 	// Read RIFF header
 	if err := binary.Read(r, binary.LittleEndian, &header.ChunkID); err != nil {
 		return header, 0, err
 	}
-	if string(header.ChunkID[:]) != "RIFF" {
+	switch string(header.ChunkID[:]) {
+	case "RIFF", "RF64", "BW64":
+	case "riff":
+		return readW64Header(r, header)
+	default:
 		return header, 0, fmt.Errorf("not a valid WAV file (missing RIFF)")
 	}
 
@@ -260,88 +222,119 @@ func readWavHeader(r io.ReadSeeker) (WavHeader, uint32, error) {
 		return header, 0, fmt.Errorf("not a valid WAV file (missing WAVE)")
 	}
 
-	// Read chunks until we find fmt and data
+	streamEnd, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return header, 0, err
+	}
+
+	// Read chunks until the end of the file; fmt and data are required
 	fmtFound := false
 	dataFound := false
+	sizeInferred := false
+	var ds64 *ds64Sizes
+	var dataOffset, dataSize int64
+	offset := int64(12)
+
+	for offset < streamEnd {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return header, 0, err
+		}
 
-	for !dataFound {
 		var chunkID [4]byte
 		var chunkSize uint32
 
 		if err := binary.Read(r, binary.LittleEndian, &chunkID); err != nil {
-			if err == io.EOF {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			}
 			return header, 0, err
 		}
 		if err := binary.Read(r, binary.LittleEndian, &chunkSize); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
 			return header, 0, err
 		}
 
-		switch string(chunkID[:]) {
-		case "fmt ":
-			header.Subchunk1ID = chunkID
-			header.Subchunk1Size = chunkSize
+		next := offset + 8 + int64(chunkSize)
+		id := string(chunkID[:])
 
-			if chunkSize < 16 {
-				return header, 0, fmt.Errorf("invalid fmt chunk size: %d", chunkSize)
+		// Once the audio is located, only tag chunks matter
+		if fmtFound && dataFound && !isTagChunk(id) {
+			id = ""
+		}
+
+		switch id {
+		case "LIST", "bext", "iXML", "acid", "smpl":
+			if chunkSize <= maxDecodedChunkSize {
+				data := make([]byte, chunkSize)
+				if _, err := io.ReadFull(r, data); err == nil {
+					header.Tags.collect(id, data)
+				}
 			}
 
-			if err := binary.Read(r, binary.LittleEndian, &header.AudioFormat); err != nil {
-				return header, 0, err
+		case "fact":
+			// Compressed formats store the decoded frame count here
+			if chunkSize >= 4 {
+				if err := binary.Read(r, binary.LittleEndian, &header.FactFrames); err != nil {
+					return header, 0, err
+				}
 			}
-			if err := binary.Read(r, binary.LittleEndian, &header.NumChannels); err != nil {
-				return header, 0, err
+
+		case "ds64":
+			if !isRF64(header.ChunkID) {
+				break
 			}
-			if err := binary.Read(r, binary.LittleEndian, &header.SampleRate); err != nil {
+			data := make([]byte, min(chunkSize, 1024))
+			if _, err := io.ReadFull(r, data); err != nil {
 				return header, 0, err
 			}
-			if err := binary.Read(r, binary.LittleEndian, &header.ByteRate); err != nil {
+			sizes, err := parseDS64(data)
+			if err != nil {
 				return header, 0, err
 			}
-			if err := binary.Read(r, binary.LittleEndian, &header.BlockAlign); err != nil {
-				return header, 0, err
+			ds64 = &sizes
+
+		case "fmt ":
+			header.Subchunk1ID = chunkID
+			header.Subchunk1Size = chunkSize
+
+			if chunkSize < 16 {
+				return header, 0, fmt.Errorf("invalid fmt chunk size: %d", chunkSize)
 			}
-			if err := binary.Read(r, binary.LittleEndian, &header.BitsPerSample); err != nil {
+
+			data := make([]byte, chunkSize)
+			if _, err := io.ReadFull(r, data); err != nil {
 				return header, 0, err
 			}
-
-			// Read any extra bytes in fmt chunk (for extensible format)
-			if chunkSize > 16 {
-				extraSize := int(chunkSize - 16)
-				extra := make([]byte, extraSize)
-				if _, err := io.ReadFull(r, extra); err != nil {
-					return header, 0, err
-				}
-				if header.AudioFormat == 0xFFFE {
-					// Extensible format extension layout (after basic 16-byte fmt):
-					// extra[0:2]  = cbSize (extension size, typically 22)
-					// extra[2:4]  = wValidBitsPerSample
-					// extra[4:8]  = dwChannelMask
-					// extra[8:24] = SubFormat GUID
-					if len(extra) < 24 {
-						return header, 0, fmt.Errorf("invalid extensible fmt chunk size")
-					}
-					header.ExtValidBits = binary.LittleEndian.Uint16(extra[2:4])
-					header.ExtChannelMask = binary.LittleEndian.Uint32(extra[4:8])
-					copy(header.ExtSubFormat[:], extra[8:24])
-				}
+			if err := parseFmtChunk(&header, data); err != nil {
+				return header, 0, err
 			}
 			fmtFound = true
 
 		case "data":
-			if !fmtFound {
-				return header, 0, fmt.Errorf("data chunk found before fmt chunk")
-			}
-			dataSize = chunkSize
 			dataFound = true
-
-		default:
-			// Skip unknown chunks
-			if _, err := r.Seek(int64(chunkSize), io.SeekCurrent); err != nil {
-				return header, 0, err
+			dataOffset = offset + 8
+			dataSize = int64(chunkSize)
+
+			remaining := streamEnd - dataOffset
+			switch {
+			case chunkSize == rf64SizeUnset && ds64 != nil && ds64.DataSize <= 1<<62:
+				dataSize = int64(ds64.DataSize)
+				next = dataOffset + dataSize
+			case chunkSize == 0xFFFFFFFF || (chunkSize == 0 && remaining > 0):
+				// Streaming recorders patch the size on close; if they never did,
+				// the samples run to the end of the file
+				dataSize = remaining
+				sizeInferred = true
+				next = streamEnd
 			}
 		}
+
+		if (next-offset)%2 == 1 && next < streamEnd && !padMissing(r, next) {
+			next++
+		}
+		offset = next
 	}
 
 	if !fmtFound {
@@ -351,15 +344,101 @@ func readWavHeader(r io.ReadSeeker) (WavHeader, uint32, error) {
 		return header, 0, fmt.Errorf("data chunk not found")
 	}
 
+	// An inferred size may end in a partial frame
+	if sizeInferred && header.BlockAlign > 0 {
+		dataSize -= dataSize % int64(header.BlockAlign)
+	}
+
+	if _, err := r.Seek(dataOffset, io.SeekStart); err != nil {
+		return header, 0, err
+	}
+
 	return header, dataSize, nil
 }
 
+// parseFmtChunk decodes a fmt chunk body into the header's format fields
+func parseFmtChunk(header *WavHeader, data []byte) error {
+	if len(data) < 16 {
+		return fmt.Errorf("invalid fmt chunk size: %d", len(data))
+	}
+
+	header.AudioFormat = binary.LittleEndian.Uint16(data[0:2])
+	header.NumChannels = binary.LittleEndian.Uint16(data[2:4])
+	header.SampleRate = binary.LittleEndian.Uint32(data[4:8])
+	header.ByteRate = binary.LittleEndian.Uint32(data[8:12])
+	header.BlockAlign = binary.LittleEndian.Uint16(data[12:14])
+	header.BitsPerSample = binary.LittleEndian.Uint16(data[14:16])
+
+	if header.AudioFormat == 0xFFFE {
+		// Extensible format extension layout (after basic 16-byte fmt):
+		// extra[0:2]  = cbSize (extension size, typically 22)
+		// extra[2:4]  = wValidBitsPerSample
+		// extra[4:8]  = dwChannelMask
+		// extra[8:24] = SubFormat GUID
+		extra := data[16:]
+		if len(extra) < 24 {
+			return fmt.Errorf("invalid extensible fmt chunk size")
+		}
+		header.ExtValidBits = binary.LittleEndian.Uint16(extra[2:4])
+		header.ExtChannelMask = binary.LittleEndian.Uint32(extra[4:8])
+		copy(header.ExtSubFormat[:], extra[8:24])
+	}
+
+	if header.AudioFormat == formatIMAADPCM || header.AudioFormat == formatMSADPCM {
+		return parseADPCMExtension(header, data[16:])
+	}
+
+	return nil
+}
+
+// parseADPCMExtension reads the fmt extension of an ADPCM format. Without one
+// the decoder works from the block align and the standard coefficients.
+func parseADPCMExtension(header *WavHeader, extra []byte) error {
+	// ADPCM extension layout (after basic 16-byte fmt):
+	// extra[0:2] = cbSize (extension size: 2 for IMA, 4 + 4*wNumCoef for MS)
+	// extra[2:4] = wSamplesPerBlock
+	// extra[4:6] = wNumCoef (MS ADPCM only)
+	// extra[6:]  = wNumCoef pairs of int16 coefficients (MS ADPCM only)
+	if len(extra) < 2 {
+		return nil
+	}
+	cbSize := int(binary.LittleEndian.Uint16(extra[0:2]))
+	if cbSize == 0 {
+		return nil
+	}
+	if cbSize > len(extra)-2 {
+		return fmt.Errorf("invalid ADPCM fmt extension: size %d but %d bytes follow", cbSize, len(extra)-2)
+	}
+	ext := extra[2 : 2+cbSize]
+	if len(ext) < 2 {
+		return fmt.Errorf("invalid ADPCM fmt extension size: %d", cbSize)
+	}
+	header.SamplesPerBlock = binary.LittleEndian.Uint16(ext[0:2])
+
+	if header.AudioFormat != formatMSADPCM {
+		return nil
+	}
+	if len(ext) < 4 {
+		return fmt.Errorf("invalid MS ADPCM fmt extension size: %d", cbSize)
+	}
+	numCoef := int(binary.LittleEndian.Uint16(ext[2:4]))
+	if numCoef == 0 || 4+4*numCoef > len(ext) {
+		return fmt.Errorf("invalid MS ADPCM fmt extension: %d coefficient pairs in %d bytes", numCoef, cbSize)
+	}
+	header.Coefs = make([][2]int16, numCoef)
+	for i := range header.Coefs {
+		pair := ext[4+4*i:]
+		header.Coefs[i] = [2]int16{int16(binary.LittleEndian.Uint16(pair[0:2])), int16(binary.LittleEndian.Uint16(pair[2:4]))}
+	}
+	return nil
+}
+
 // displaySummary shows a summary of found files
 func displaySummary(files []FileInfo) {
-	fmt.Printf("Found %d matching WAV files:\n", len(files))
-	fmt.Println(strings.Repeat("-", 100))
-	fmt.Printf("%-50s %10s %8s %8s %10s %12s\n", "File", "Size", "Rate", "Ch", "Bits", "Duration")
-	fmt.Println(strings.Repeat("-", 100))
+	progress.Printf("Found %d matching WAV files:\n", len(files))
+	progress.Println(strings.Repeat("-", 100))
+	progress.Printf("%-50s %10s %8s %8s %10s %12s\n", "File", "Size", "Rate", "Ch", "Bits", "Duration")
+	progress.Println(strings.Repeat("-", 100))
 
 	var totalSize int64
 	var totalDuration float64
@@ -371,7 +450,17 @@ func displaySummary(files []FileInfo) {
 		if len(name) > 48 {
 			name = name[:45] + "..."
 		}
-		fmt.Printf("%-50s %10s %6dHz %8d %8d %10.3fs\n",
+		e := Event{
+			Event:      eventFile,
+			Path:       f.Path,
+			Size:       f.Size,
+			Duration:   f.Duration,
+			SampleRate: int(f.SampleRate),
+			Channels:   int(f.Channels),
+			BitDepth:   int(f.BitDepth),
+			MatchedTag: f.MatchedTag,
+		}
+		progress.emit(e, "%-50s %10s %6dHz %8d %8d %10.3fs\n",
 			name,
 			formatSize(f.Size),
 			f.SampleRate,
@@ -385,17 +474,16 @@ func displaySummary(files []FileInfo) {
 		channelMap[f.Channels]++
 	}
 
-	fmt.Println(strings.Repeat("-", 100))
-	fmt.Printf("\nSummary:\n")
-	fmt.Printf("  Total files: %d\n", len(files))
-	fmt.Printf("  Total size: %s\n", formatSize(totalSize))
-	fmt.Printf("  Total duration: %.2fs\n", totalDuration)
-	fmt.Printf("  Sample rates: ")
+	progress.Println(strings.Repeat("-", 100))
+	printTagMatches(files)
+	progress.emit(Event{Event: eventDiscovered, Files: len(files), Size: totalSize, Duration: totalDuration},
+		"\nSummary:\n  Total files: %d\n  Total size: %s\n  Total duration: %.2fs\n", len(files), formatSize(totalSize), totalDuration)
+	progress.Printf("  Sample rates: ")
 	for rate, count := range rateMap {
-		fmt.Printf("%dHz (%d files) ", rate, count)
+		progress.Printf("%dHz (%d files) ", rate, count)
 	}
-	fmt.Println()
-	fmt.Printf("  Channels: ")
+	progress.Println()
+	progress.Printf("  Channels: ")
 	for ch, count := range channelMap {
 		chStr := "mono"
 		if ch == 2 {
@@ -403,9 +491,9 @@ func displaySummary(files []FileInfo) {
 		} else if ch > 2 {
 			chStr = fmt.Sprintf("%d-ch", ch)
 		}
-		fmt.Printf("%s (%d files) ", chStr, count)
+		progress.Printf("%s (%d files) ", chStr, count)
 	}
-	fmt.Println()
+	progress.Println()
 }
 
 // formatSize formats a byte size to human readable format
@@ -422,67 +510,114 @@ func formatSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// processFiles processes all files in batches
-func processFiles(files []FileInfo, targetRate, numChannels, sliceCount, samplesPerSlice int, pattern, outputDir string, normalize bool) error {
+// processFiles processes all slots in batches and returns what was written for each
+func processFiles(slots []Slot, targetRate, numChannels, sliceCount, samplesPerSlice int, pattern, outputDir string, normalize bool, mix MixOptions, out OutputOptions) ([]BatchResult, error) {
 	// Create temp directory
 	tempDir, err := os.MkdirTemp("", "wavslice-")
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %v", err)
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
 	}
 	defer os.RemoveAll(tempDir)
 
-	fmt.Printf("\nUsing temp directory: %s\n", tempDir)
+	progress.Printf("\nUsing temp directory: %s\n", tempDir)
 
+	var results []BatchResult
 	batchNum := 0
-	for i := 0; i < len(files); i += sliceCount {
+	for i := 0; i < len(slots); i += sliceCount {
 		batchNum++
 		end := i + sliceCount
-		if end > len(files) {
-			end = len(files)
+		if end > len(slots) {
+			end = len(slots)
 		}
 
-		batchFiles := files[i:end]
-		fmt.Printf("\n=== Processing Batch %d (%d files) ===\n", batchNum, len(batchFiles))
+		batchSlots := slots[i:end]
+		outputFile := filepath.Join(outputDir, batchFileName(pattern, sliceCount, batchNum))
+		progress.emit(Event{Event: eventBatchStart, Batch: batchNum, Path: outputFile, Files: len(batchSlots)},
+			"\n=== Processing Batch %d (%d files) ===\n", batchNum, len(batchSlots))
 
 		// Process batch
-		outputFile := filepath.Join(outputDir, fmt.Sprintf("%s_%dslices_batch%03d.wav", sanitizeFilename(pattern), sliceCount, batchNum))
-		err := processBatch(batchFiles, targetRate, numChannels, samplesPerSlice, tempDir, outputFile, normalize)
+		meta := batchMetadata(pattern, sliceCount, batchNum, batchSlots, out.Bext)
+		err := processBatch(batchSlots, targetRate, numChannels, samplesPerSlice, tempDir, outputFile, normalize, mix, meta)
 		if err != nil {
-			return fmt.Errorf("failed to process batch %d: %v", batchNum, err)
+			return results, fmt.Errorf("failed to process batch %d: %v", batchNum, err)
 		}
 
-		fmt.Printf("Created: %s\n", outputFile)
+		progress.created("batch", outputFile, batchNum)
+
+		result := BatchResult{BatchLayout: BatchLayout{
+			Path:        outputFile,
+			SampleRate:  targetRate,
+			Channels:    numChannels,
+			SliceLength: samplesPerSlice,
+			Slots:       batchSlots,
+		}}
+		for _, e := range out.Exporters {
+			paths, err := e.Export(result.BatchLayout)
+			result.Exports = append(result.Exports, paths...)
+			if err != nil {
+				return append(results, result), fmt.Errorf("failed to write %s export for batch %d: %v", e.Name(), batchNum, err)
+			}
+			for _, p := range paths {
+				progress.created(e.Name(), p, batchNum)
+			}
+		}
+		results = append(results, result)
 	}
 
-	return nil
+	return results, nil
 }
 
-// processBatch processes a single batch of files
-func processBatch(files []FileInfo, targetRate, numChannels, samplesPerSlice int, tempDir, outputFile string, normalize bool) error {
+// processBatch processes a single batch of slots. meta, if not nil, is written
+// to the combined output file.
+func processBatch(slots []Slot, targetRate, numChannels, samplesPerSlice int, tempDir, outputFile string, normalize bool, mix MixOptions, meta *WavMetadata) error {
 	var processedSamples [][][]float64 // [file][channel][sample]
 
-	for idx, f := range files {
-		fmt.Printf("  Processing %d/%d: %s\n", idx+1, len(files), filepath.Base(f.Path))
+	for idx, slot := range slots {
+		samples := make([][]float64, numChannels)
 
-		// Read the WAV file
-		wav, err := readWavFile(f.Path)
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %v", f.Path, err)
-		}
+		event := Event{Event: eventProcessing, Slot: idx + 1, Note: noteName(FirstSliceNote + idx), Path: slot.Path}
+		if slot.Path == "" {
+			progress.emit(event, "  Processing %d/%d: (empty slot)\n", idx+1, len(slots))
+		} else {
+			event.TruncatedMs = truncation(slot, float64(samplesPerSlice)/float64(targetRate)) * 1000
+			progress.emit(event, "  Processing %d/%d: %s\n", idx+1, len(slots), filepath.Base(slot.Path))
 
-		// Convert to target format
-		samples := wav.Samples
+			// Decode only the part of the file past the trim offset and
+			// leading silence that can reach the slice
+			source, header, err := readSlotSource(slot.Path, slot.TrimMs, targetRate, samplesPerSlice)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", slot.Path, err)
+			}
+			samples = source
 
-		// Resample if needed
-		if int(wav.Header.SampleRate) != targetRate {
-			samples = resample(samples, int(wav.Header.SampleRate), targetRate)
-		}
+			// Resample if needed
+			if int(header.SampleRate) != targetRate {
+				samples = resample(samples, int(header.SampleRate), targetRate)
+			}
+
+			// Convert channels if needed
+			samples = convertChannels(samples, header.ExtChannelMask, numChannels, mix)
+			samples = removeLeadingSilence(samples)
+
+			// Reverse only the part that will fit in the slice
+			if slot.Reverse {
+				if len(samples[0]) > samplesPerSlice {
+					samples = padOrTruncate(samples, samplesPerSlice)
+				}
+				samples = reverseSamples(samples)
+			}
 
-		// Convert channels if needed
-		samples = convertChannels(samples, numChannels)
+			// Normalize only the part that will fit in the slice
+			if slot.Normalize {
+				if len(samples[0]) > samplesPerSlice {
+					samples = padOrTruncate(samples, samplesPerSlice)
+				}
+				samples = normalizeSamples(samples)
+			}
 
-		// Remove leading silence
-		samples = removeLeadingSilence(samples)
+			samples = applyGain(samples, slot.GainDB)
+			samples = applyFades(samples, msToSamples(slot.FadeInMs, targetRate), msToSamples(slot.FadeOutMs, targetRate), samplesPerSlice)
+		}
 
 		// Truncate or pad to match slice duration
 		samples = padOrTruncate(samples, samplesPerSlice)
@@ -504,146 +639,42 @@ func processBatch(files []FileInfo, targetRate, numChannels, samplesPerSlice int
 	}
 
 	// Write output file
-	return writeWavFile(outputFile, concatenated, targetRate, numChannels)
+	return writeWavFileWithMetadata(outputFile, concatenated, targetRate, numChannels, meta)
 }
 
 // readWavFile reads a complete WAV file including samples
 func readWavFile(path string) (*WavFile, error) {
-	f, err := os.Open(path)
+	s, err := openWavStream(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	defer s.Close()
 
-	stat, err := f.Stat()
-	if err != nil {
-		return nil, err
+	if s.DataSize > MaxInputDataSize {
+		return nil, fmt.Errorf("input data too large: %d bytes", s.DataSize)
 	}
-	fileSize := stat.Size()
 
-	header, dataSize, err := readWavHeader(f)
+	// Truncated files yield fewer frames than the header declares
+	samples, err := s.ReadFrames(int(s.Frames))
 	if err != nil {
 		return nil, err
 	}
 
-	if header.BlockAlign == 0 {
-		return nil, fmt.Errorf("invalid WAV header: block align is zero")
-	}
-	if dataSize == 0 {
-		return nil, fmt.Errorf("invalid WAV header: data size is zero")
-	}
-	if dataSize > MaxInputDataSize {
-		return nil, fmt.Errorf("input data too large: %d bytes", dataSize)
-	}
-	if int64(dataSize) > fileSize {
-		return nil, fmt.Errorf("invalid WAV header: data size exceeds file size")
-	}
-	if dataSize%uint32(header.BlockAlign) != 0 {
-		return nil, fmt.Errorf("invalid WAV header: data size not aligned to block size")
-	}
-
-	// Determine the actual audio format
-	// 1 = PCM, 3 = IEEE float, 0xFFFE = Extensible (treat as PCM or float based on bits)
-	isFloat := header.AudioFormat == 3
-	isPCM := header.AudioFormat == 1
-	isExtensible := header.AudioFormat == 0xFFFE
-
-	if !isPCM && !isFloat && !isExtensible {
-		return nil, fmt.Errorf("unsupported audio format: %d (supported: 1=PCM, 3=IEEE Float, 65534=Extensible)", header.AudioFormat)
-	}
-
-	// For extensible format, determine if it's float or PCM based on subformat GUID
-	if isExtensible {
-		switch header.ExtSubFormat {
-		case subFormatPCM:
-			isPCM = true
-		case subFormatFloat:
-			isFloat = true
-		default:
-			return nil, fmt.Errorf("unsupported extensible subformat")
-		}
-	}
-
-	// Read sample data
-	numSamples := int(dataSize) / int(header.NumChannels) / int(header.BitsPerSample/8)
-	samples := make([][]float64, header.NumChannels)
-	for i := range samples {
-		samples[i] = make([]float64, numSamples)
-	}
-
-	bytesPerSample := int(header.BitsPerSample) / 8
-	buffer := make([]byte, bytesPerSample)
-
-	for i := 0; i < numSamples; i++ {
-		for ch := 0; ch < int(header.NumChannels); ch++ {
-			_, err := io.ReadFull(f, buffer)
-			if err != nil {
-				if err == io.EOF {
-					// Truncate to actual samples read
-					for c := range samples {
-						samples[c] = samples[c][:i]
-					}
-					goto done
-				}
-				return nil, err
-			}
-
-			var sample float64
-
-			if isFloat {
-				// IEEE Float format
-				switch header.BitsPerSample {
-				case 32:
-					bits := binary.LittleEndian.Uint32(buffer)
-					sample = float64(math.Float32frombits(bits))
-				case 64:
-					bits := binary.LittleEndian.Uint64(buffer)
-					sample = math.Float64frombits(bits)
-				default:
-					return nil, fmt.Errorf("unsupported float bit depth: %d", header.BitsPerSample)
-				}
-			} else {
-				// PCM format
-				switch header.BitsPerSample {
-				case 8:
-					// 8-bit is unsigned
-					sample = (float64(buffer[0]) - 128) / 128.0
-				case 16:
-					// 16-bit is signed
-					val := int16(binary.LittleEndian.Uint16(buffer))
-					sample = float64(val) / 32768.0
-				case 24:
-					// 24-bit is signed
-					val := int32(buffer[0]) | int32(buffer[1])<<8 | int32(buffer[2])<<16
-					if val&0x800000 != 0 {
-						val |= ^0xFFFFFF // Sign extend
-					}
-					sample = float64(val) / 8388608.0
-				case 32:
-					// 32-bit is signed integer
-					val := int32(binary.LittleEndian.Uint32(buffer))
-					sample = float64(val) / 2147483648.0
-				default:
-					return nil, fmt.Errorf("unsupported PCM bit depth: %d", header.BitsPerSample)
-				}
-			}
-
-			samples[ch][i] = sample
-		}
-	}
-
-done:
 	numSamplesActual := len(samples[0])
-	duration := float64(numSamplesActual) / float64(header.SampleRate)
+	duration := float64(numSamplesActual) / float64(s.Header.SampleRate)
+
+	// Metadata is informational; files the chunk walker cannot list (Wave64) have none
+	meta, _ := readWavMetadata(s.f)
 
 	return &WavFile{
 		Path:       path,
-		Header:     header,
+		Header:     s.Header,
 		Samples:    samples,
-		DataSize:   dataSize,
-		FileSize:   fileSize,
+		DataSize:   s.DataSize,
+		FileSize:   s.FileSize,
 		Duration:   duration,
 		NumSamples: numSamplesActual,
+		Metadata:   meta,
 	}, nil
 }
 
@@ -676,19 +707,35 @@ func resample(samples [][]float64, fromRate, toRate int) [][]float64 {
 	return result
 }
 
-// convertChannels converts between mono and stereo
-func convertChannels(samples [][]float64, targetChannels int) [][]float64 {
+// convertChannels converts a source to targetChannels. channelMask is the
+// source's WAVEFORMATEXTENSIBLE speaker mask, or 0 for the default layout.
+// Surround layouts are folded to stereo with ITU coefficients first, and stereo
+// becomes mono as selected by opts.Mono, or opts.Fallback when its channels
+// are poorly correlated.
+func convertChannels(samples [][]float64, channelMask uint32, targetChannels int, opts MixOptions) [][]float64 {
 	currentChannels := len(samples)
 
 	if currentChannels == targetChannels {
 		return samples
 	}
 
+	if currentChannels > 2 && targetChannels <= 2 {
+		if stereo := downmixToStereo(samples, channelMask); stereo != nil {
+			if targetChannels == 2 {
+				return stereo
+			}
+			samples = stereo
+			currentChannels = 2
+		}
+	}
+
 	result := make([][]float64, targetChannels)
 	numSamples := len(samples[0])
 
-	if targetChannels == 1 && currentChannels >= 2 {
-		// Convert to mono by averaging channels
+	if targetChannels == 1 && currentChannels == 2 {
+		result[0] = stereoToMono(samples[0], samples[1], opts.monoMode(samples[0], samples[1]))
+	} else if targetChannels == 1 && currentChannels > 2 {
+		// Unknown layout: average all channels
 		result[0] = make([]float64, numSamples)
 		for i := 0; i < numSamples; i++ {
 			sum := 0.0
@@ -761,6 +808,40 @@ func removeLeadingSilence(samples [][]float64) [][]float64 {
 	return result
 }
 
+// removeTrailingSilence removes trailing zero/near-zero samples
+func removeTrailingSilence(samples [][]float64) [][]float64 {
+	if len(samples) == 0 || len(samples[0]) == 0 {
+		return samples
+	}
+
+	threshold := 0.001 // About -60dB
+	endIdx := len(samples[0])
+
+	for ; endIdx > 1; endIdx-- {
+		isSilent := true
+		for ch := 0; ch < len(samples); ch++ {
+			if math.Abs(samples[ch][endIdx-1]) > threshold {
+				isSilent = false
+				break
+			}
+		}
+		if !isSilent {
+			break
+		}
+	}
+
+	if endIdx == len(samples[0]) {
+		return samples
+	}
+
+	result := make([][]float64, len(samples))
+	for ch := range samples {
+		result[ch] = samples[ch][:endIdx]
+	}
+
+	return result
+}
+
 // padOrTruncate ensures samples are exactly the target length
 func padOrTruncate(samples [][]float64, targetLength int) [][]float64 {
 	if len(samples) == 0 {
@@ -850,6 +931,89 @@ func normalizeSamples(samples [][]float64) [][]float64 {
 	return samples
 }
 
+// msToSamples converts a duration in milliseconds to a sample count at the given rate
+func msToSamples(ms float64, sampleRate int) int {
+	if ms <= 0 || math.IsNaN(ms) {
+		return 0
+	}
+	// Capped so an absurd duration can't overflow into a negative count
+	return int(min(ms*float64(sampleRate)/1000.0, math.MaxInt32))
+}
+
+// trimStart drops the first n samples of every channel
+func trimStart(samples [][]float64, n int) [][]float64 {
+	if n <= 0 || len(samples) == 0 {
+		return samples
+	}
+	if n > len(samples[0]) {
+		n = len(samples[0])
+	}
+
+	result := make([][]float64, len(samples))
+	for ch := range samples {
+		result[ch] = samples[ch][n:]
+	}
+	return result
+}
+
+// reverseSamples returns a time-reversed copy of the samples
+func reverseSamples(samples [][]float64) [][]float64 {
+	result := make([][]float64, len(samples))
+	for ch := range samples {
+		n := len(samples[ch])
+		result[ch] = make([]float64, n)
+		for i, v := range samples[ch] {
+			result[ch][n-1-i] = v
+		}
+	}
+	return result
+}
+
+// applyGain scales samples in place by a gain in dB
+func applyGain(samples [][]float64, gainDB float64) [][]float64 {
+	if gainDB == 0 {
+		return samples
+	}
+
+	scale := math.Pow(10, gainDB/20)
+	for ch := range samples {
+		for i := range samples[ch] {
+			samples[ch][i] *= scale
+		}
+	}
+	return samples
+}
+
+// applyFades applies linear fades in place. The fade-out ends at the last sample that
+// survives truncation to maxLength, so truncated slices don't end with a click.
+func applyFades(samples [][]float64, fadeIn, fadeOut, maxLength int) [][]float64 {
+	if len(samples) == 0 {
+		return samples
+	}
+
+	length := len(samples[0])
+	if maxLength > 0 && length > maxLength {
+		length = maxLength
+	}
+
+	if fadeIn > length {
+		fadeIn = length
+	}
+	if fadeOut > length {
+		fadeOut = length
+	}
+
+	for ch := range samples {
+		for i := 0; i < fadeIn; i++ {
+			samples[ch][i] *= float64(i) / float64(fadeIn)
+		}
+		for i := 0; i < fadeOut; i++ {
+			samples[ch][length-1-i] *= float64(i) / float64(fadeOut)
+		}
+	}
+	return samples
+}
+
 func writeBytes(w io.Writer, b []byte) error {
 	_, err := w.Write(b)
 	return err
@@ -861,6 +1025,12 @@ func writeLE(w io.Writer, data interface{}) error {
 
 // writeWavFile writes samples to a WAV file
 func writeWavFile(path string, samples [][]float64, sampleRate, numChannels int) error {
+	return writeWavFileWithMetadata(path, samples, sampleRate, numChannels, nil)
+}
+
+// writeWavFileWithMetadata writes samples to a WAV file with optional LIST/INFO
+// and bext chunks
+func writeWavFileWithMetadata(path string, samples [][]float64, sampleRate, numChannels int, meta *WavMetadata) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return err
@@ -878,11 +1048,16 @@ func writeWavFile(path string, samples [][]float64, sampleRate, numChannels int)
 	byteRate := uint32(sampleRate) * uint32(blockAlign)
 	dataSize := uint32(numSamples) * uint32(numChannels) * uint32(bytesPerSample)
 
+	var metaChunks []byte
+	if meta != nil {
+		metaChunks = meta.encode()
+	}
+
 	// Write RIFF header
 	if err := writeBytes(f, []byte("RIFF")); err != nil {
 		return err
 	}
-	if err := writeLE(f, uint32(36+dataSize)); err != nil {
+	if err := writeLE(f, uint32(36+len(metaChunks))+dataSize); err != nil {
 		return err
 	}
 	if err := writeBytes(f, []byte("WAVE")); err != nil {
@@ -915,6 +1090,11 @@ func writeWavFile(path string, samples [][]float64, sampleRate, numChannels int)
 		return err
 	}
 
+	// Write metadata chunks
+	if err := writeBytes(f, metaChunks); err != nil {
+		return err
+	}
+
 	// Write data chunk
 	if err := writeBytes(f, []byte("data")); err != nil {
 		return err
@@ -138,7 +138,7 @@ func TestResample(t *testing.T) {
 func TestConvertChannels(t *testing.T) {
 	t.Run("mono to stereo", func(t *testing.T) {
 		mono := [][]float64{{0.5, -0.5, 0.25}}
-		stereo := convertChannels(mono, 2)
+		stereo := convertChannels(mono, 0, 2, MixOptions{})
 		if len(stereo) != 2 {
 			t.Fatalf("expected 2 channels, got %d", len(stereo))
 		}
@@ -151,7 +151,7 @@ func TestConvertChannels(t *testing.T) {
 
 	t.Run("stereo to mono", func(t *testing.T) {
 		stereo := [][]float64{{1.0, 0.5}, {0.0, 0.5}}
-		mono := convertChannels(stereo, 1)
+		mono := convertChannels(stereo, 0, 1, MixOptions{})
 		if len(mono) != 1 {
 			t.Fatalf("expected 1 channel, got %d", len(mono))
 		}
@@ -167,7 +167,7 @@ func TestConvertChannels(t *testing.T) {
 
 	t.Run("stereo cancellation to mono", func(t *testing.T) {
 		stereo := [][]float64{{1, 0}, {-1, 0}}
-		mono := convertChannels(stereo, 1)
+		mono := convertChannels(stereo, 0, 1, MixOptions{})
 		// Average of 1 and -1 = 0
 		if math.Abs(mono[0][0]) > 1e-9 {
 			t.Errorf("expected 0, got %f", mono[0][0])
@@ -176,7 +176,7 @@ func TestConvertChannels(t *testing.T) {
 
 	t.Run("same channel count", func(t *testing.T) {
 		samples := [][]float64{{0.1, 0.2}, {0.3, 0.4}}
-		out := convertChannels(samples, 2)
+		out := convertChannels(samples, 0, 2, MixOptions{})
 		if len(out) != 2 {
 			t.Fatalf("expected 2 channels, got %d", len(out))
 		}
@@ -192,16 +192,17 @@ func TestConvertChannels(t *testing.T) {
 
 	t.Run("multichannel to mono", func(t *testing.T) {
 		multi := [][]float64{{1.0}, {2.0}, {3.0}, {4.0}}
-		mono := convertChannels(multi, 1)
-		// Average = (1+2+3+4)/4 = 2.5
-		if math.Abs(mono[0][0]-2.5) > 1e-9 {
-			t.Errorf("expected 2.5, got %f", mono[0][0])
+		mono := convertChannels(multi, 0, 1, MixOptions{})
+		// Quad layout: L = (1 + 0.707*3)/1.707, R = (2 + 0.707*4)/1.707, mono = (L+R)/2
+		expected := (3 + math.Sqrt2/2*7) / (1 + math.Sqrt2/2) / 2
+		if math.Abs(mono[0][0]-expected) > 1e-9 {
+			t.Errorf("expected %f, got %f", expected, mono[0][0])
 		}
 	})
 
 	t.Run("mono to more channels pads with zeros", func(t *testing.T) {
 		mono := [][]float64{{0.5, 0.5}}
-		out := convertChannels(mono, 4)
+		out := convertChannels(mono, 0, 4, MixOptions{})
 		if len(out) != 4 {
 			t.Fatalf("expected 4 channels, got %d", len(out))
 		}
@@ -290,6 +291,43 @@ func TestRemoveLeadingSilence(t *testing.T) {
 	})
 }
 
+// ============================================================================
+// removeTrailingSilence tests
+// ============================================================================
+
+func TestRemoveTrailingSilence(t *testing.T) {
+	t.Run("removes trailing zeros", func(t *testing.T) {
+		samples := [][]float64{{0.5, 1.0, 0, 0, 0}}
+		out := removeTrailingSilence(samples)
+		if len(out[0]) != 2 {
+			t.Errorf("expected 2 samples, got %d", len(out[0]))
+		}
+	})
+
+	t.Run("keeps sound in any channel", func(t *testing.T) {
+		samples := [][]float64{{0.5, 0, 0}, {0, 0, 0.5}}
+		out := removeTrailingSilence(samples)
+		if len(out[0]) != 3 {
+			t.Errorf("expected 3 samples, got %d", len(out[0]))
+		}
+	})
+
+	t.Run("all silent keeps one sample", func(t *testing.T) {
+		samples := [][]float64{{0, 0, 0}}
+		out := removeTrailingSilence(samples)
+		if len(out[0]) != 1 {
+			t.Errorf("expected 1 sample, got %d", len(out[0]))
+		}
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		out := removeTrailingSilence([][]float64{{}})
+		if len(out[0]) != 0 {
+			t.Errorf("expected empty result, got %v", out)
+		}
+	})
+}
+
 // ============================================================================
 // padOrTruncate tests
 // ============================================================================
@@ -477,6 +515,124 @@ func TestNormalizeSamples(t *testing.T) {
 	})
 }
 
+// ============================================================================
+// Per-slot edit tests
+// ============================================================================
+
+func TestMsToSamples(t *testing.T) {
+	tests := []struct {
+		ms       float64
+		rate     int
+		expected int
+	}{
+		{0, 44100, 0},
+		{-5, 44100, 0},
+		{10, 44100, 441},
+		{1000, 22050, 22050},
+		{0.5, 44100, 22},
+		{math.NaN(), 44100, 0},
+		{math.Inf(1), 44100, math.MaxInt32},
+		{1e30, 44100, math.MaxInt32},
+	}
+
+	for _, tc := range tests {
+		result := msToSamples(tc.ms, tc.rate)
+		if result != tc.expected {
+			t.Errorf("msToSamples(%v, %d) = %d, expected %d", tc.ms, tc.rate, result, tc.expected)
+		}
+	}
+}
+
+func TestTrimStart(t *testing.T) {
+	t.Run("drops leading samples", func(t *testing.T) {
+		samples := [][]float64{{1, 2, 3, 4}, {5, 6, 7, 8}}
+		out := trimStart(samples, 2)
+		if len(out[0]) != 2 || out[0][0] != 3 || out[1][0] != 7 {
+			t.Errorf("unexpected result %v", out)
+		}
+	})
+
+	t.Run("trim beyond length", func(t *testing.T) {
+		samples := [][]float64{{1, 2}}
+		out := trimStart(samples, 5)
+		if len(out[0]) != 0 {
+			t.Errorf("expected empty result, got %v", out)
+		}
+	})
+
+	t.Run("zero trim returns input", func(t *testing.T) {
+		samples := [][]float64{{1, 2}}
+		out := trimStart(samples, 0)
+		if len(out[0]) != 2 {
+			t.Errorf("expected unchanged input, got %v", out)
+		}
+	})
+}
+
+func TestReverseSamples(t *testing.T) {
+	samples := [][]float64{{1, 2, 3}, {4, 5, 6}}
+	out := reverseSamples(samples)
+	expected := [][]float64{{3, 2, 1}, {6, 5, 4}}
+	for ch := range expected {
+		for i := range expected[ch] {
+			if out[ch][i] != expected[ch][i] {
+				t.Errorf("ch %d index %d: expected %f, got %f", ch, i, expected[ch][i], out[ch][i])
+			}
+		}
+	}
+	if samples[0][0] != 1 {
+		t.Error("reverseSamples modified its input")
+	}
+}
+
+func TestApplyGain(t *testing.T) {
+	t.Run("+6 dB roughly doubles", func(t *testing.T) {
+		out := applyGain([][]float64{{0.25}}, 6)
+		if math.Abs(out[0][0]-0.5) > 0.01 {
+			t.Errorf("expected ~0.5, got %f", out[0][0])
+		}
+	})
+
+	t.Run("0 dB is unchanged", func(t *testing.T) {
+		out := applyGain([][]float64{{0.25}}, 0)
+		if out[0][0] != 0.25 {
+			t.Errorf("expected 0.25, got %f", out[0][0])
+		}
+	})
+}
+
+func TestApplyFades(t *testing.T) {
+	t.Run("fade in and out", func(t *testing.T) {
+		samples := [][]float64{{1, 1, 1, 1, 1, 1}}
+		out := applyFades(samples, 2, 2, 0)
+		expected := []float64{0, 0.5, 1, 1, 0.5, 0}
+		for i := range expected {
+			if math.Abs(out[0][i]-expected[i]) > 1e-9 {
+				t.Errorf("index %d: expected %f, got %f", i, expected[i], out[0][i])
+			}
+		}
+	})
+
+	t.Run("fade out ends at truncation point", func(t *testing.T) {
+		samples := [][]float64{{1, 1, 1, 1, 1, 1}}
+		out := applyFades(samples, 0, 2, 4)
+		if out[0][3] != 0 {
+			t.Errorf("expected silence at truncation point, got %f", out[0][3])
+		}
+		if out[0][5] != 1 {
+			t.Errorf("expected samples beyond truncation untouched, got %f", out[0][5])
+		}
+	})
+
+	t.Run("fade longer than samples", func(t *testing.T) {
+		samples := [][]float64{{1, 1}}
+		out := applyFades(samples, 10, 0, 0)
+		if out[0][0] != 0 || out[0][1] != 0.5 {
+			t.Errorf("unexpected result %v", out[0])
+		}
+	})
+}
+
 // ============================================================================
 // WAV header helper functions
 // ============================================================================
@@ -759,6 +915,146 @@ func TestReadWavHeader(t *testing.T) {
 	})
 }
 
+// ============================================================================
+// readWavHeader RIFF quirk tests
+// ============================================================================
+
+// riffChunk builds a chunk with its header and, for odd sizes, a pad byte if padded is set
+func riffChunk(id string, body []byte, padded bool) []byte {
+	chunk := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(len(body)))
+	chunk = append(chunk, body...)
+	if padded && len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// pcmFmtBody returns a 16-byte PCM fmt chunk body
+func pcmFmtBody(channels uint16, sampleRate uint32, bits uint16) []byte {
+	blockAlign := channels * bits / 8
+	body := binary.LittleEndian.AppendUint16(nil, 1)
+	body = binary.LittleEndian.AppendUint16(body, channels)
+	body = binary.LittleEndian.AppendUint32(body, sampleRate)
+	body = binary.LittleEndian.AppendUint32(body, sampleRate*uint32(blockAlign))
+	body = binary.LittleEndian.AppendUint16(body, blockAlign)
+	body = binary.LittleEndian.AppendUint16(body, bits)
+	return body
+}
+
+// riffFile wraps chunks in a RIFF/WAVE header with the given size field
+func riffFile(riffSize uint32, chunks ...[]byte) []byte {
+	file := []byte("RIFF")
+	file = binary.LittleEndian.AppendUint32(file, riffSize)
+	file = append(file, []byte("WAVE")...)
+	for _, c := range chunks {
+		file = append(file, c...)
+	}
+	return file
+}
+
+func TestReadWavHeaderQuirks(t *testing.T) {
+	samples := []byte{0x00, 0x40, 0x00, 0xC0} // 0.5, -0.5
+
+	t.Run("odd-size chunk with pad byte", func(t *testing.T) {
+		file := riffFile(0,
+			riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true),
+			riffChunk("LIST", []byte("INFOabc"), true),
+			riffChunk("data", samples, true))
+
+		_, dataSize, err := readWavHeader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("readWavHeader failed: %v", err)
+		}
+		if dataSize != 4 {
+			t.Errorf("expected dataSize 4, got %d", dataSize)
+		}
+	})
+
+	t.Run("odd-size chunk missing pad byte", func(t *testing.T) {
+		file := riffFile(0,
+			riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true),
+			riffChunk("bext", []byte("abc"), false),
+			riffChunk("data", samples, true))
+
+		_, dataSize, err := readWavHeader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("readWavHeader failed: %v", err)
+		}
+		if dataSize != 4 {
+			t.Errorf("expected dataSize 4, got %d", dataSize)
+		}
+	})
+
+	t.Run("data size zero from streaming recorder", func(t *testing.T) {
+		data := riffChunk("data", samples, true)
+		binary.LittleEndian.PutUint32(data[4:8], 0)
+		file := riffFile(0, riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true), data)
+
+		_, dataSize, err := readWavHeader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("readWavHeader failed: %v", err)
+		}
+		if dataSize != 4 {
+			t.Errorf("expected inferred dataSize 4, got %d", dataSize)
+		}
+	})
+
+	t.Run("data size 0xFFFFFFFF with partial frame", func(t *testing.T) {
+		data := riffChunk("data", append(samples, 0x12), false)
+		binary.LittleEndian.PutUint32(data[4:8], 0xFFFFFFFF)
+		file := riffFile(0xFFFFFFFF, riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true), data)
+
+		_, dataSize, err := readWavHeader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("readWavHeader failed: %v", err)
+		}
+		if dataSize != 4 {
+			t.Errorf("expected dataSize rounded down to 4, got %d", dataSize)
+		}
+	})
+
+	t.Run("fmt after data", func(t *testing.T) {
+		file := riffFile(0,
+			riffChunk("data", samples, true),
+			riffChunk("fmt ", pcmFmtBody(1, 22050, 16), true))
+
+		r := bytes.NewReader(file)
+		header, dataSize, err := readWavHeader(r)
+		if err != nil {
+			t.Fatalf("readWavHeader failed: %v", err)
+		}
+		if header.SampleRate != 22050 || dataSize != 4 {
+			t.Errorf("expected 22050 Hz with 4 bytes, got %d Hz with %d", header.SampleRate, dataSize)
+		}
+
+		// Reader must be left at the sample data
+		first := make([]byte, 2)
+		io.ReadFull(r, first)
+		if first[0] != 0x00 || first[1] != 0x40 {
+			t.Errorf("reader not positioned at sample data, read %x", first)
+		}
+	})
+
+	t.Run("readWavFile decodes quirky files", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "quirky.wav")
+		file := riffFile(0,
+			riffChunk("LIST", []byte("INFOx"), false),
+			riffChunk("data", samples, true),
+			riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true))
+		os.WriteFile(path, file, 0644)
+
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if len(wav.Samples[0]) != 2 || math.Abs(wav.Samples[0][0]-0.5) > 0.001 || math.Abs(wav.Samples[0][1]+0.5) > 0.001 {
+			t.Errorf("unexpected samples %v", wav.Samples[0])
+		}
+	})
+}
+
 // ============================================================================
 // writeWavFile and readWavFile round-trip tests
 // ============================================================================
@@ -1243,7 +1539,7 @@ func TestFindWavFiles(t *testing.T) {
 		writeWavFile(filepath.Join(dir, "snare_01.wav"), samples, 44100, 1)
 
 		pattern := regexp.MustCompile(`(?i)^.*kick.*\.wav$`)
-		files, err := findWavFiles(dir, pattern)
+		files, err := findWavFiles(dir, pattern, nil)
 		if err != nil {
 			t.Fatalf("findWavFiles failed: %v", err)
 		}
@@ -1262,7 +1558,7 @@ func TestFindWavFiles(t *testing.T) {
 		writeWavFile(filepath.Join(subdir, "kick_02.wav"), samples, 44100, 1)
 
 		pattern := regexp.MustCompile(`(?i)^.*kick.*\.wav$`)
-		files, err := findWavFiles(dir, pattern)
+		files, err := findWavFiles(dir, pattern, nil)
 		if err != nil {
 			t.Fatalf("findWavFiles failed: %v", err)
 		}
@@ -1278,7 +1574,7 @@ func TestFindWavFiles(t *testing.T) {
 		writeWavFile(filepath.Join(dir, "snare_01.wav"), samples, 44100, 1)
 
 		pattern := regexp.MustCompile(`(?i)^.*kick.*\.wav$`)
-		files, err := findWavFiles(dir, pattern)
+		files, err := findWavFiles(dir, pattern, nil)
 		if err != nil {
 			t.Fatalf("findWavFiles failed: %v", err)
 		}
@@ -1296,7 +1592,7 @@ func TestFindWavFiles(t *testing.T) {
 		writeWavFile(filepath.Join(dir, "kick_02.wav"), samples, 44100, 1)
 
 		pattern := regexp.MustCompile(`(?i)^.*kick.*\.wav$`)
-		files, err := findWavFiles(dir, pattern)
+		files, err := findWavFiles(dir, pattern, nil)
 		if err != nil {
 			t.Fatalf("findWavFiles failed: %v", err)
 		}
@@ -1313,7 +1609,7 @@ func TestFindWavFiles(t *testing.T) {
 
 	t.Run("invalid directory", func(t *testing.T) {
 		pattern := regexp.MustCompile(`.*`)
-		_, err := findWavFiles("/nonexistent/path", pattern)
+		_, err := findWavFiles("/nonexistent/path", pattern, nil)
 		if err == nil {
 			t.Error("expected error for nonexistent directory")
 		}
@@ -1328,7 +1624,7 @@ func TestFindWavFiles(t *testing.T) {
 		writeWavFile(filepath.Join(dir, "kick_03.wav"), samples, 44100, 1)
 
 		pattern := regexp.MustCompile(`(?i)^.*kick.*\.wav$`)
-		files, err := findWavFiles(dir, pattern)
+		files, err := findWavFiles(dir, pattern, nil)
 		if err != nil {
 			t.Fatalf("findWavFiles failed: %v", err)
 		}
@@ -1361,7 +1657,7 @@ func TestProcessBatch(t *testing.T) {
 		}
 
 		outputFile := filepath.Join(outputDir, "output.wav")
-		err := processBatch(files, 44100, 1, 100, tempDir, outputFile, false)
+		err := processBatch(slotsFromFiles(files), 44100, 1, 100, tempDir, outputFile, false, MixOptions{}, nil)
 		if err != nil {
 			t.Fatalf("processBatch failed: %v", err)
 		}
@@ -1396,7 +1692,7 @@ func TestProcessBatch(t *testing.T) {
 		}
 
 		outputFile := filepath.Join(outputDir, "normalized.wav")
-		err := processBatch(files, 44100, 1, 100, tempDir, outputFile, true)
+		err := processBatch(slotsFromFiles(files), 44100, 1, 100, tempDir, outputFile, true, MixOptions{}, nil)
 		if err != nil {
 			t.Fatalf("processBatch failed: %v", err)
 		}
@@ -1431,7 +1727,7 @@ func TestProcessBatch(t *testing.T) {
 		}
 
 		outputFile := filepath.Join(outputDir, "resampled.wav")
-		err := processBatch(files, 44100, 1, 100, tempDir, outputFile, false) // Target 44100
+		err := processBatch(slotsFromFiles(files), 44100, 1, 100, tempDir, outputFile, false, MixOptions{}, nil) // Target 44100
 		if err != nil {
 			t.Fatalf("processBatch failed: %v", err)
 		}
@@ -1464,10 +1760,10 @@ func TestProcessFiles(t *testing.T) {
 
 		// Find files
 		pattern := regexp.MustCompile(`(?i)^.*test.*\.wav$`)
-		files, _ := findWavFiles(dir, pattern)
+		files, _ := findWavFiles(dir, pattern, nil)
 
 		// Process with 2 slices per batch
-		err := processFiles(files, 44100, 1, 2, 100, "test", outputDir, false)
+		_, err := processFiles(slotsFromFiles(files), 44100, 1, 2, 100, "test", outputDir, false, MixOptions{}, OutputOptions{})
 		if err != nil {
 			t.Fatalf("processFiles failed: %v", err)
 		}
@@ -1572,7 +1868,7 @@ func TestChannelConversionIntegration(t *testing.T) {
 	}
 
 	// Convert to mono
-	mono := convertChannels(wav.Samples, 1)
+	mono := convertChannels(wav.Samples, 0, 1, MixOptions{})
 	if len(mono) != 1 {
 		t.Errorf("expected 1 channel, got %d", len(mono))
 	}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Version is the tool version written into output metadata. Release builds set it
+// with -ldflags "-X main.Version=...".
+var Version = "dev"
+
+// WavMetadata is the descriptive metadata wavslice writes to and reads from
+// LIST/INFO and bext chunks
+type WavMetadata struct {
+	Title    string // INAM
+	Comment  string // ICMT
+	Software string // ISFT
+	Created  string // ICRD, YYYY-MM-DD
+	Bext     *BextInfo
+}
+
+// BextInfo holds the text fields of a Broadcast WAV bext chunk
+type BextInfo struct {
+	Description         string // up to 256 bytes
+	Originator          string // up to 32 bytes
+	OriginatorReference string // up to 32 bytes
+	OriginationDate     string // YYYY-MM-DD
+	OriginationTime     string // HH:MM:SS
+	CodingHistory       string // free text, one line per entry
+}
+
+// infoFields maps LIST/INFO IDs to the metadata fields wavslice writes, in write order
+var infoFields = []struct {
+	id    string
+	field func(m *WavMetadata) *string
+}{
+	{"INAM", func(m *WavMetadata) *string { return &m.Title }},
+	{"ICMT", func(m *WavMetadata) *string { return &m.Comment }},
+	{"ISFT", func(m *WavMetadata) *string { return &m.Software }},
+	{"ICRD", func(m *WavMetadata) *string { return &m.Created }},
+}
+
+// bextFixedSize is the size of a version 1 bext chunk without coding history
+const bextFixedSize = 602
+
+// batchMetadata describes an output batch: its name, the slice layout and the
+// source of every slice. The bext chunk is only included when requested.
+func batchMetadata(name string, sliceCount, batchNum int, slots []Slot, bext bool) *WavMetadata {
+	now := time.Now()
+	summary := fmt.Sprintf("wavslice %s, %d slices, batch %d", name, sliceCount, batchNum)
+
+	var sources []string
+	for i, slot := range slots {
+		source := "(empty)"
+		if slot.Path != "" {
+			source = filepath.Base(slot.Path)
+		}
+		sources = append(sources, fmt.Sprintf("%02d %s", i+1, source))
+	}
+
+	meta := &WavMetadata{
+		Title:    strings.TrimSuffix(batchFileName(name, sliceCount, batchNum), ".wav"),
+		Comment:  summary + "; sources: " + strings.Join(sources, ", "),
+		Software: "wavslice " + Version,
+		Created:  now.Format("2006-01-02"),
+	}
+	if bext {
+		meta.Bext = &BextInfo{
+			Description:         summary,
+			Originator:          "wavslice " + Version,
+			OriginatorReference: fmt.Sprintf("%s-%03d", sanitizeFilename(name), batchNum),
+			OriginationDate:     now.Format("2006-01-02"),
+			OriginationTime:     now.Format("15:04:05"),
+			CodingHistory:       "slice " + strings.Join(sources, "\r\nslice ") + "\r\n",
+		}
+	}
+	return meta
+}
+
+// encode returns the metadata as complete bext and LIST chunks. They go between
+// fmt and data so that samplers which expect fmt first still read the file.
+func (m *WavMetadata) encode() []byte {
+	var chunks []byte
+	if m.Bext != nil {
+		chunks = append(chunks, encodeChunk("bext", m.Bext.encode())...)
+	}
+
+	info := []byte("INFO")
+	for _, f := range infoFields {
+		value := *f.field(m)
+		if value == "" {
+			continue
+		}
+		info = append(info, encodeChunk(f.id, append([]byte(value), 0))...)
+	}
+	if len(info) > 4 {
+		chunks = append(chunks, encodeChunk("LIST", info)...)
+	}
+	return chunks
+}
+
+// encodeChunk returns a chunk header and body, with a pad byte after odd-sized bodies
+func encodeChunk(id string, body []byte) []byte {
+	chunk := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(len(body)))
+	chunk = append(chunk, body...)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// encode returns a version 1 bext chunk body
+func (b *BextInfo) encode() []byte {
+	body := make([]byte, bextFixedSize, bextFixedSize+len(b.CodingHistory))
+	copy(body[0:256], b.Description)
+	copy(body[256:288], b.Originator)
+	copy(body[288:320], b.OriginatorReference)
+	copy(body[320:330], b.OriginationDate)
+	copy(body[330:338], b.OriginationTime)
+	binary.LittleEndian.PutUint16(body[346:348], 1) // version
+	return append(body, b.CodingHistory...)
+}
+
+// parseBext decodes the text fields of a bext chunk body
+func parseBext(data []byte) (*BextInfo, error) {
+	if len(data) < 348 {
+		return nil, fmt.Errorf("bext chunk too short: %d bytes", len(data))
+	}
+	b := &BextInfo{
+		Description:         cString(data[0:256]),
+		Originator:          cString(data[256:288]),
+		OriginatorReference: cString(data[288:320]),
+		OriginationDate:     cString(data[320:330]),
+		OriginationTime:     cString(data[330:338]),
+	}
+	if len(data) > bextFixedSize {
+		// Coding history lines end in CR/LF, which cString would trim
+		b.CodingHistory = strings.TrimRight(string(data[bextFixedSize:]), "\x00")
+	}
+	return b, nil
+}
+
+// readWavMetadata collects LIST/INFO and bext contents from a RIFF stream. It
+// returns nil when the file has neither.
+func readWavMetadata(r io.ReadSeeker) (*WavMetadata, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	chunks, err := listChunks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta *WavMetadata
+	for _, c := range chunks {
+		if (c.ID != "LIST" && c.ID != "bext") || c.Size > maxDecodedChunkSize {
+			continue
+		}
+
+		data := make([]byte, c.Size)
+		if _, err := r.Seek(c.Offset+8, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, data); err != nil {
+			continue
+		}
+
+		if meta == nil {
+			meta = &WavMetadata{}
+		}
+		if c.ID == "bext" {
+			if b, err := parseBext(data); err == nil {
+				meta.Bext = b
+			}
+			continue
+		}
+
+		listType, entries, err := parseListChunk(data)
+		if err != nil || listType != "INFO" {
+			continue
+		}
+		for _, e := range entries {
+			for _, f := range infoFields {
+				if e.ID == f.id {
+					*f.field(meta) = cString(e.Data)
+				}
+			}
+		}
+	}
+
+	if meta != nil && *meta == (WavMetadata{}) {
+		return nil, nil
+	}
+	return meta, nil
+}
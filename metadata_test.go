@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// Metadata encoding tests
+// ============================================================================
+
+func TestBatchMetadata(t *testing.T) {
+	slots := []Slot{{FileInfo: FileInfo{Path: "/samples/kick_01.wav"}}, {}}
+
+	t.Run("without bext", func(t *testing.T) {
+		meta := batchMetadata("kick", 32, 2, slots, false)
+		if meta.Title != "kick_32slices_batch002" {
+			t.Errorf("unexpected title %q", meta.Title)
+		}
+		if !strings.Contains(meta.Comment, "32 slices") || !strings.Contains(meta.Comment, "01 kick_01.wav, 02 (empty)") {
+			t.Errorf("unexpected comment %q", meta.Comment)
+		}
+		if meta.Software != "wavslice "+Version {
+			t.Errorf("unexpected software %q", meta.Software)
+		}
+		if meta.Bext != nil {
+			t.Error("expected no bext chunk")
+		}
+	})
+
+	t.Run("with bext", func(t *testing.T) {
+		meta := batchMetadata("kick", 32, 2, slots, true)
+		if meta.Bext == nil {
+			t.Fatal("expected bext chunk")
+		}
+		if meta.Bext.CodingHistory != "slice 01 kick_01.wav\r\nslice 02 (empty)\r\n" {
+			t.Errorf("unexpected coding history %q", meta.Bext.CodingHistory)
+		}
+		if meta.Bext.OriginatorReference != "kick-002" {
+			t.Errorf("unexpected originator reference %q", meta.Bext.OriginatorReference)
+		}
+	})
+}
+
+func TestEncodeChunk(t *testing.T) {
+	chunk := encodeChunk("INAM", []byte("abc"))
+	if len(chunk) != 12 || chunk[4] != 3 || chunk[11] != 0 {
+		t.Errorf("expected 8-byte header, 3-byte body and pad byte, got %v", chunk)
+	}
+}
+
+// ============================================================================
+// Metadata round-trip tests
+// ============================================================================
+
+func TestWavMetadataRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	samples := [][]float64{{0.1, 0.2, 0.3}}
+	meta := &WavMetadata{
+		Title:    "kick_32slices_batch001",
+		Comment:  "odd length",
+		Software: "wavslice test",
+		Created:  "2024-01-02",
+		Bext: &BextInfo{
+			Description:     "wavslice kick",
+			Originator:      "wavslice test",
+			OriginationDate: "2024-01-02",
+			OriginationTime: "03:04:05",
+			CodingHistory:   "slice 01 kick.wav\r\n",
+		},
+	}
+
+	path := filepath.Join(dir, "meta.wav")
+	if err := writeWavFileWithMetadata(path, samples, 44100, 1, meta); err != nil {
+		t.Fatalf("writeWavFileWithMetadata failed: %v", err)
+	}
+
+	wav, err := readWavFile(path)
+	if err != nil {
+		t.Fatalf("readWavFile failed: %v", err)
+	}
+	if wav.NumSamples != 3 {
+		t.Errorf("expected 3 samples, got %d", wav.NumSamples)
+	}
+	if wav.Metadata == nil {
+		t.Fatal("expected metadata")
+	}
+	if wav.Metadata.Title != meta.Title || wav.Metadata.Comment != meta.Comment ||
+		wav.Metadata.Software != meta.Software || wav.Metadata.Created != meta.Created {
+		t.Errorf("INFO mismatch: %+v", wav.Metadata)
+	}
+	if wav.Metadata.Bext == nil || *wav.Metadata.Bext != *meta.Bext {
+		t.Errorf("bext mismatch: %+v", wav.Metadata.Bext)
+	}
+
+	t.Run("valid structure", func(t *testing.T) {
+		report, err := inspectWavFile(path)
+		if err != nil {
+			t.Fatalf("inspectWavFile failed: %v", err)
+		}
+		if !report.Valid() || len(report.Warnings) != 0 {
+			t.Errorf("expected a clean report, got errors %v warnings %v", report.Errors, report.Warnings)
+		}
+	})
+
+	t.Run("plain file has none", func(t *testing.T) {
+		plain := filepath.Join(dir, "plain.wav")
+		writeWavFile(plain, samples, 44100, 1)
+		wav, err := readWavFile(plain)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.Metadata != nil {
+			t.Errorf("expected no metadata, got %+v", wav.Metadata)
+		}
+	})
+
+	t.Run("preserved by convert", func(t *testing.T) {
+		converted := filepath.Join(dir, "converted.wav")
+		if err := convertFile(path, converted, 22050, 2, MixOptions{}, false, false); err != nil {
+			t.Fatalf("convertFile failed: %v", err)
+		}
+		wav, err := readWavFile(converted)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.Metadata == nil || wav.Metadata.Title != meta.Title || wav.Metadata.Bext == nil {
+			t.Errorf("metadata not preserved: %+v", wav.Metadata)
+		}
+	})
+}
+
+func TestProcessFilesWritesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "kick_01.wav")
+	writeWavFile(src, [][]float64{{0.5, 0.5}}, 44100, 1)
+
+	outputDir := filepath.Join(dir, "out")
+	os.Mkdir(outputDir, 0755)
+	slots := slotsFromFiles([]FileInfo{{Path: src}})
+	if _, err := processFiles(slots, 44100, 1, 2, 10, "kick", outputDir, false, MixOptions{}, OutputOptions{Bext: true}); err != nil {
+		t.Fatalf("processFiles failed: %v", err)
+	}
+
+	wav, err := readWavFile(filepath.Join(outputDir, "kick_2slices_batch001.wav"))
+	if err != nil {
+		t.Fatalf("readWavFile failed: %v", err)
+	}
+	if wav.NumSamples != 10 {
+		t.Errorf("expected one 10-sample slice, got %d", wav.NumSamples)
+	}
+	if wav.Metadata == nil || wav.Metadata.Bext == nil {
+		t.Fatalf("expected INFO and bext metadata, got %+v", wav.Metadata)
+	}
+	if !strings.Contains(wav.Metadata.Bext.CodingHistory, "slice 01 kick_01.wav") {
+		t.Errorf("unexpected coding history %q", wav.Metadata.Bext.CodingHistory)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Standard MIDI File timing: 480 ticks per quarter note at 120 BPM, so one
+// tick is 1/960 s
+const (
+	midiTicksPerQuarter = 480
+	midiTempo           = 500000 // microseconds per quarter note
+)
+
+// MIDIOptions controls the audition MIDI file written for each batch
+type MIDIOptions struct {
+	Enabled   bool
+	SpacingMs int // time from one note to the next
+	Velocity  int
+}
+
+// validate checks the spacing and velocity are playable
+func (o MIDIOptions) validate() error {
+	if o.SpacingMs < 1 {
+		return errors.New("-midi-spacing must be at least 1 ms")
+	}
+	if o.Velocity < 1 || o.Velocity > 127 {
+		return errors.New("-midi-velocity must be between 1 and 127")
+	}
+	return nil
+}
+
+// midiPath returns the MIDI file path for a combined WAV
+func midiPath(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".mid"
+}
+
+// msToTicks converts milliseconds to ticks at the fixed tempo
+func msToTicks(ms float64) int {
+	return int(ms * midiTicksPerQuarter * 1000 / midiTempo)
+}
+
+// appendVarLen appends a MIDI variable-length quantity
+func appendVarLen(b []byte, v int) []byte {
+	buf := []byte{byte(v & 0x7F)}
+	for v >>= 7; v > 0; v >>= 7 {
+		buf = append([]byte{byte(v&0x7F | 0x80)}, buf...)
+	}
+	return append(b, buf...)
+}
+
+// appendMeta appends a meta event
+func appendMeta(b []byte, delta int, kind byte, data []byte) []byte {
+	b = appendVarLen(b, delta)
+	b = append(b, 0xFF, kind)
+	b = appendVarLen(b, len(data))
+	return append(b, data...)
+}
+
+// encodeMIDI builds a format 0 Standard MIDI File playing every slice of a
+// batch once, from FirstSliceNote upward in slot order. Each note lasts as long
+// as its slice, cut short if the next note comes sooner.
+func encodeMIDI(layout BatchLayout, opts MIDIOptions) []byte {
+	spacing := max(msToTicks(float64(opts.SpacingMs)), 1)
+	length := spacing
+	if layout.SampleRate > 0 {
+		length = min(length, max(msToTicks(float64(layout.SliceLength)*1000/float64(layout.SampleRate)), 1))
+	}
+
+	var track []byte
+	track = appendMeta(track, 0, 0x03, []byte(strings.TrimSuffix(filepath.Base(layout.Path), ".wav")))
+	track = appendMeta(track, 0, 0x51, []byte{midiTempo >> 16, midiTempo >> 8 & 0xFF, midiTempo & 0xFF})
+
+	rest := 0 // ticks since the last event
+	for i := range layout.Slots {
+		note := byte(FirstSliceNote + i)
+		track = appendVarLen(track, rest)
+		track = append(track, 0x90, note, byte(opts.Velocity))
+		track = appendVarLen(track, length)
+		track = append(track, 0x80, note, 0)
+		rest = spacing - length
+	}
+	track = appendMeta(track, rest, 0x2F, nil)
+
+	var b bytes.Buffer
+	b.WriteString("MThd")
+	binary.Write(&b, binary.BigEndian, uint32(6))
+	binary.Write(&b, binary.BigEndian, [3]uint16{0, 1, midiTicksPerQuarter})
+	b.WriteString("MTrk")
+	binary.Write(&b, binary.BigEndian, uint32(len(track)))
+	b.Write(track)
+	return b.Bytes()
+}
+
+// writeMIDIFile writes the audition MIDI file for a batch
+func writeMIDIFile(layout BatchLayout, opts MIDIOptions) (string, error) {
+	path := midiPath(layout.Path)
+	if err := os.WriteFile(path, encodeMIDI(layout, opts), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// midiEvent is a channel event decoded from a test file with its absolute time
+type midiEvent struct {
+	tick     int
+	status   byte
+	note     byte
+	velocity byte
+}
+
+// readVarLen decodes a variable-length quantity, returning it and its size
+func readVarLen(b []byte) (int, int) {
+	v := 0
+	for i, c := range b {
+		v = v<<7 | int(c&0x7F)
+		if c&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
+
+// decodeMIDITrack checks the header of a format 0 file and returns its note
+// events and the tick of the end-of-track event
+func decodeMIDITrack(t *testing.T, data []byte) ([]midiEvent, int) {
+	t.Helper()
+	if string(data[0:4]) != "MThd" || binary.BigEndian.Uint32(data[4:8]) != 6 {
+		t.Fatal("missing MThd header")
+	}
+	if format, tracks := binary.BigEndian.Uint16(data[8:10]), binary.BigEndian.Uint16(data[10:12]); format != 0 || tracks != 1 {
+		t.Fatalf("expected format 0 with 1 track, got format %d with %d", format, tracks)
+	}
+	if string(data[14:18]) != "MTrk" {
+		t.Fatal("missing MTrk chunk")
+	}
+	track := data[22:]
+	if size := binary.BigEndian.Uint32(data[18:22]); int(size) != len(track) {
+		t.Fatalf("track size %d, %d bytes follow", size, len(track))
+	}
+
+	var events []midiEvent
+	tick := 0
+	for i := 0; i < len(track); {
+		delta, n := readVarLen(track[i:])
+		tick += delta
+		i += n
+		if track[i] == 0xFF {
+			kind := track[i+1]
+			size, n := readVarLen(track[i+2:])
+			i += 2 + n + size
+			if kind == 0x2F {
+				return events, tick
+			}
+			continue
+		}
+		events = append(events, midiEvent{tick, track[i], track[i+1], track[i+2]})
+		i += 3
+	}
+	t.Fatal("missing end of track")
+	return nil, 0
+}
+
+// ============================================================================
+// MIDI tests
+// ============================================================================
+
+func TestAppendVarLen(t *testing.T) {
+	tests := []struct {
+		v    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x81, 0x00}},
+		{0x3FFF, []byte{0xFF, 0x7F}},
+		{0x200000, []byte{0x81, 0x80, 0x80, 0x00}},
+	}
+	for _, tt := range tests {
+		if got := appendVarLen(nil, tt.v); string(got) != string(tt.want) {
+			t.Errorf("appendVarLen(%#x) = % x, expected % x", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeMIDI(t *testing.T) {
+	// 50 ms slices, notes every 250 ms
+	layout := BatchLayout{Path: "/out/kick_3slices_batch001.wav", SampleRate: 44100, Channels: 1, SliceLength: 2205, Slots: make([]Slot, 3)}
+	events, end := decodeMIDITrack(t, encodeMIDI(layout, MIDIOptions{SpacingMs: 250, Velocity: 90}))
+
+	if len(events) != 6 {
+		t.Fatalf("expected 6 note events, got %d", len(events))
+	}
+	for i := 0; i < 3; i++ {
+		on, off := events[2*i], events[2*i+1]
+		if on.status != 0x90 || on.note != byte(FirstSliceNote+i) || on.velocity != 90 || on.tick != i*240 {
+			t.Errorf("slice %d: unexpected note on %+v", i+1, on)
+		}
+		if off.status != 0x80 || off.note != on.note || off.tick != on.tick+48 {
+			t.Errorf("slice %d: unexpected note off %+v", i+1, off)
+		}
+	}
+	if end != 3*240 {
+		t.Errorf("expected track to end at tick 720, got %d", end)
+	}
+
+	t.Run("notes closer than the slice length", func(t *testing.T) {
+		events, _ := decodeMIDITrack(t, encodeMIDI(layout, MIDIOptions{SpacingMs: 20, Velocity: 90}))
+		if off := events[1]; off.tick != msToTicks(20) {
+			t.Errorf("expected note off at the next note, got tick %d", off.tick)
+		}
+	})
+}
+
+func TestMIDIOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    MIDIOptions
+		wantErr bool
+	}{
+		{"defaults", defaultSettings().MIDI, false},
+		{"zero spacing", MIDIOptions{SpacingMs: 0, Velocity: 100}, true},
+		{"velocity 0", MIDIOptions{SpacingMs: 500, Velocity: 0}, true},
+		{"velocity 128", MIDIOptions{SpacingMs: 500, Velocity: 128}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteMIDIFile(t *testing.T) {
+	dir := t.TempDir()
+	layout := BatchLayout{Path: filepath.Join(dir, "kit.wav"), SampleRate: 44100, Channels: 1, SliceLength: 10, Slots: make([]Slot, 1)}
+
+	path, err := writeMIDIFile(layout, defaultSettings().MIDI)
+	if err != nil {
+		t.Fatalf("writeMIDIFile failed: %v", err)
+	}
+	if path != filepath.Join(dir, "kit.mid") {
+		t.Errorf("unexpected path %s", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected MIDI file: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MPC drum programs have 16 pads per bank, banks A to H
+const (
+	mpcPadsPerBank  = 16
+	mpcMaxPads      = 128
+	mpcFirstPadNote = 36 // pads are mapped chromatically from C1
+)
+
+// xpmPath returns the drum program path for a combined WAV
+func xpmPath(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".xpm"
+}
+
+// mpcPadName returns the bank and pad label of a pad index, e.g. "B03"
+func mpcPadName(i int) string {
+	return fmt.Sprintf("%c%02d", 'A'+i/mpcPadsPerBank, i%mpcPadsPerBank+1)
+}
+
+// encodeXPM builds an MPC drum program with one slice file per pad. sampleNames
+// are the slice WAVs without extension; the MPC looks for them next to the program.
+func encodeXPM(layout BatchLayout, sampleNames []string) (string, error) {
+	if len(sampleNames) > mpcMaxPads {
+		return "", fmt.Errorf("MPC drum programs have %d pads, batch has %d slices", mpcMaxPads, len(sampleNames))
+	}
+	name := strings.TrimSuffix(filepath.Base(layout.Path), ".wav")
+
+	w := newXMLWriter("  ")
+	w.open("MPCVObject")
+	w.open("Version")
+	w.text("File_Version", "2.1")
+	w.text("Application", "MPC-V")
+	w.text("Application_Version", "2.10.0.0")
+	w.text("Platform", "OSX")
+	w.close("Version")
+
+	w.open("Program", "type", "Drum")
+	w.text("ProgramName", name)
+	w.open("Instruments")
+	for i, sample := range sampleNames {
+		source := "(empty)"
+		if i < len(layout.Slots) && layout.Slots[i].Path != "" {
+			source = filepath.Base(layout.Slots[i].Path)
+		}
+		w.comment(fmt.Sprintf("pad %s: %s", mpcPadName(i), source))
+		w.open("Instrument", "number", i+1)
+		w.text("OneShot", "True")
+		w.open("Layers")
+		w.open("Layer", "number", 1)
+		w.text("Active", "True")
+		w.text("Volume", "1.000000")
+		w.text("Pan", "0.500000")
+		w.text("Pitch", "0.000000")
+		w.text("VelStart", 0)
+		w.text("VelEnd", 127)
+		w.text("SampleName", sample)
+		w.text("SampleFile", "")
+		w.close("Layer")
+		w.close("Layers")
+		w.close("Instrument")
+	}
+	w.close("Instruments")
+
+	w.open("PadNoteMap")
+	for i := range sampleNames {
+		w.open("PadNote", "number", i+1)
+		w.text("Note", mpcFirstPadNote+i)
+		w.close("PadNote")
+	}
+	w.close("PadNoteMap")
+	w.close("Program")
+	w.close("MPCVObject")
+	return w.String(), nil
+}
+
+// writeXPMProgram writes every slice of a batch as its own WAV and an .xpm
+// program assigning them to pads, 16 per bank
+func writeXPMProgram(layout BatchLayout) ([]string, error) {
+	paths, err := splitFile(layout.Path, filepath.Dir(layout.Path), len(layout.Slots), false, false)
+	if err != nil {
+		return paths, err
+	}
+
+	var names []string
+	for _, p := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(p), ".wav"))
+	}
+	program, err := encodeXPM(layout, names)
+	if err != nil {
+		return paths, err
+	}
+
+	path := xpmPath(layout.Path)
+	if err := os.WriteFile(path, []byte(program), 0644); err != nil {
+		return paths, err
+	}
+	return append(paths, path), nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// MPC drum program tests
+// ============================================================================
+
+// xpmProgram is the part of an .xpm checked by the tests
+type xpmProgram struct {
+	Program struct {
+		Type        string `xml:"type,attr"`
+		Name        string `xml:"ProgramName"`
+		Instruments []struct {
+			Number  int    `xml:"number,attr"`
+			OneShot string `xml:"OneShot"`
+			Sample  string `xml:"Layers>Layer>SampleName"`
+		} `xml:"Instruments>Instrument"`
+		Pads []struct {
+			Number int `xml:"number,attr"`
+			Note   int `xml:"Note"`
+		} `xml:"PadNoteMap>PadNote"`
+	} `xml:"Program"`
+}
+
+func TestMPCPadName(t *testing.T) {
+	for i, want := range map[int]string{0: "A01", 15: "A16", 16: "B01", 63: "D16"} {
+		if got := mpcPadName(i); got != want {
+			t.Errorf("mpcPadName(%d) = %s, expected %s", i, got, want)
+		}
+	}
+}
+
+func TestEncodeXPM(t *testing.T) {
+	layout := BatchLayout{Path: "/out/kit_64slices_batch001.wav", SampleRate: 44100, Channels: 1, SliceLength: 100, Slots: make([]Slot, 64)}
+	var names []string
+	for i := range layout.Slots {
+		names = append(names, fmt.Sprintf("kit_64slices_batch001_slice%02d", i+1))
+	}
+
+	data, err := encodeXPM(layout, names)
+	if err != nil {
+		t.Fatalf("encodeXPM failed: %v", err)
+	}
+	var prog xpmProgram
+	if err := xml.Unmarshal([]byte(data), &prog); err != nil {
+		t.Fatalf("program is not valid XML: %v", err)
+	}
+
+	if prog.Program.Type != "Drum" || prog.Program.Name != "kit_64slices_batch001" {
+		t.Errorf("unexpected program %q %q", prog.Program.Type, prog.Program.Name)
+	}
+	if len(prog.Program.Instruments) != 64 || len(prog.Program.Pads) != 64 {
+		t.Fatalf("expected 64 pads over four banks, got %d instruments and %d pad notes", len(prog.Program.Instruments), len(prog.Program.Pads))
+	}
+	last := prog.Program.Instruments[63]
+	if last.Number != 64 || last.Sample != "kit_64slices_batch001_slice64" || last.OneShot != "True" {
+		t.Errorf("unexpected last instrument %+v", last)
+	}
+	if prog.Program.Pads[0].Note != 36 || prog.Program.Pads[63].Note != 99 {
+		t.Errorf("expected chromatic pads from C1, got %d..%d", prog.Program.Pads[0].Note, prog.Program.Pads[63].Note)
+	}
+	if !strings.Contains(data, "<!-- pad D16: (empty) -->") {
+		t.Error("expected pad label comments")
+	}
+
+	t.Run("too many pads", func(t *testing.T) {
+		if _, err := encodeXPM(layout, make([]string, 129)); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestWriteXPMProgram(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kit_3slices_batch001.wav")
+	writeWavFile(path, [][]float64{make([]float64, 30)}, 44100, 1)
+	layout := BatchLayout{Path: path, SampleRate: 44100, Channels: 1, SliceLength: 10, Slots: make([]Slot, 3)}
+
+	created, err := writeXPMProgram(layout)
+	if err != nil {
+		t.Fatalf("writeXPMProgram failed: %v", err)
+	}
+	if len(created) != 4 || created[3] != filepath.Join(dir, "kit_3slices_batch001.xpm") {
+		t.Fatalf("expected 3 slice files and the program, got %v", created)
+	}
+
+	data, err := os.ReadFile(created[3])
+	if err != nil {
+		t.Fatalf("expected program file: %v", err)
+	}
+	var prog xpmProgram
+	if err := xml.Unmarshal(data, &prog); err != nil {
+		t.Fatalf("program is not valid XML: %v", err)
+	}
+	if len(prog.Program.Instruments) != 3 || prog.Program.Instruments[2].Sample != "kit_3slices_batch001_slice03" {
+		t.Errorf("unexpected instruments %+v", prog.Program.Instruments)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// Octatrack .ot slice file layout. All values are big-endian.
+const (
+	otFileSize      = 832
+	otMaxSlices     = 64
+	otSampleRate    = 44100
+	otDefaultTempo  = 120  // BPM; only affects time-stretch and quantized playback
+	otGainUnity     = 0x30 // gain is stored as dB + 48 in half-dB steps
+	otQuantizeNow   = 0xFF // play immediately
+	otNoLoop        = 0xFFFFFFFF
+	otSliceOffset   = 0x3A
+	otSliceSize     = 12
+	otCountOffset   = 0x33A
+	otSumOffset     = 0x33E
+	otChecksumStart = 16
+)
+
+// otHeader is the fixed start of every .ot file: an IFF-style FORM with a DPS1
+// SMPA chunk, followed by bytes the Octatrack always writes
+var otHeader = []byte{
+	'F', 'O', 'R', 'M', 0, 0, 0, 0, 'D', 'P', 'S', '1', 'S', 'M', 'P', 'A',
+	0, 0, 0, 0, 0, 2, 0,
+}
+
+// otPath returns the sidecar path the Octatrack looks for next to a WAV
+func otPath(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".ot"
+}
+
+// encodeOT builds an .ot file for a batch: one slice per slot, the whole file as
+// trim range, no loop, no time-stretch and unity gain
+func encodeOT(layout BatchLayout) ([]byte, error) {
+	if len(layout.Slots) > otMaxSlices {
+		return nil, fmt.Errorf("octatrack supports at most %d slices, batch has %d", otMaxSlices, len(layout.Slots))
+	}
+
+	be := binary.BigEndian
+	data := make([]byte, otFileSize)
+	copy(data, otHeader)
+
+	// Trim and loop lengths are in hundredths of a bar at the stored tempo
+	total := layout.NumSamples()
+	bars := float64(otDefaultTempo) * float64(total) / (float64(layout.SampleRate) * 60 * 4)
+	length := uint32(math.Round(bars * 100))
+
+	be.PutUint32(data[0x17:], otDefaultTempo*24)
+	be.PutUint32(data[0x1B:], length) // trim length
+	be.PutUint32(data[0x1F:], length) // loop length
+	be.PutUint32(data[0x23:], 0)      // time-stretch off
+	be.PutUint32(data[0x27:], 0)      // loop off
+	be.PutUint16(data[0x2B:], otGainUnity)
+	data[0x2D] = otQuantizeNow
+	be.PutUint32(data[0x2E:], 0)             // trim start
+	be.PutUint32(data[0x32:], uint32(total)) // trim end
+	be.PutUint32(data[0x36:], 0)             // loop point
+
+	for i := range layout.Slots {
+		start, end := layout.sliceBounds(i)
+		slice := data[otSliceOffset+i*otSliceSize:]
+		be.PutUint32(slice[0:4], uint32(start))
+		be.PutUint32(slice[4:8], uint32(end))
+		be.PutUint32(slice[8:12], otNoLoop)
+	}
+	be.PutUint32(data[otCountOffset:], uint32(len(layout.Slots)))
+
+	be.PutUint16(data[otSumOffset:], otChecksum(data))
+	return data, nil
+}
+
+// otChecksum sums every byte after the FORM header up to the checksum itself
+func otChecksum(data []byte) uint16 {
+	var sum uint16
+	for _, b := range data[otChecksumStart:otSumOffset] {
+		sum += uint16(b)
+	}
+	return sum
+}
+
+// writeOTFile writes the .ot sidecar for a batch
+func writeOTFile(layout BatchLayout) (string, error) {
+	if layout.SampleRate != otSampleRate {
+		progress.warn(layout.Path, "the Octatrack plays %d Hz files; %s is %d Hz", otSampleRate, layout.Path, layout.SampleRate)
+	}
+
+	data, err := encodeOT(layout)
+	if err != nil {
+		return "", err
+	}
+	path := otPath(layout.Path)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
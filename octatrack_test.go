@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// Octatrack .ot tests
+// ============================================================================
+
+func TestEncodeOT(t *testing.T) {
+	layout := BatchLayout{Path: "kick.wav", SampleRate: 44100, Channels: 1, SliceLength: 11025, Slots: make([]Slot, 4)}
+	data, err := encodeOT(layout)
+	if err != nil {
+		t.Fatalf("encodeOT failed: %v", err)
+	}
+
+	be := binary.BigEndian
+	if len(data) != 832 {
+		t.Fatalf("expected 832 bytes, got %d", len(data))
+	}
+	if string(data[0:4]) != "FORM" || string(data[8:16]) != "DPS1SMPA" {
+		t.Errorf("unexpected header %q", data[0:16])
+	}
+	if tempo := be.Uint32(data[0x17:]); tempo != 2880 {
+		t.Errorf("expected tempo 2880 (120 BPM × 24), got %d", tempo)
+	}
+	// One second at 120 BPM is half a bar
+	if trimLen := be.Uint32(data[0x1B:]); trimLen != 50 {
+		t.Errorf("expected trim length 50, got %d", trimLen)
+	}
+	if trimEnd := be.Uint32(data[0x32:]); trimEnd != 44100 {
+		t.Errorf("expected trim end 44100, got %d", trimEnd)
+	}
+	if count := be.Uint32(data[0x33A:]); count != 4 {
+		t.Errorf("expected 4 slices, got %d", count)
+	}
+
+	slice := data[0x3A+3*12:]
+	if start, end, loop := be.Uint32(slice[0:4]), be.Uint32(slice[4:8]), be.Uint32(slice[8:12]); start != 33075 || end != 44100 || loop != 0xFFFFFFFF {
+		t.Errorf("unexpected last slice %d-%d loop %x", start, end, loop)
+	}
+	if unused := be.Uint32(data[0x3A+4*12+4:]); unused != 0 {
+		t.Errorf("expected unused slices to be zero, got end %d", unused)
+	}
+
+	var sum uint16
+	for _, b := range data[16:0x33E] {
+		sum += uint16(b)
+	}
+	if got := be.Uint16(data[0x33E:]); got != sum {
+		t.Errorf("checksum %d, expected %d", got, sum)
+	}
+
+	t.Run("too many slices", func(t *testing.T) {
+		layout.Slots = make([]Slot, 65)
+		if _, err := encodeOT(layout); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestProcessFilesWritesOT(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "kick_01.wav")
+	writeWavFile(src, [][]float64{{0.5, 0.5}}, 44100, 1)
+
+	outputDir := filepath.Join(dir, "out")
+	os.Mkdir(outputDir, 0755)
+	slots := slotsFromFiles([]FileInfo{{Path: src}, {Path: src}})
+	ot, _ := newExporter("octatrack", defaultSettings())
+	if _, err := processFiles(slots, 44100, 1, 2, 10, "kick", outputDir, false, MixOptions{}, OutputOptions{Exporters: []Exporter{ot}}); err != nil {
+		t.Fatalf("processFiles failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "kick_2slices_batch001.ot"))
+	if err != nil {
+		t.Fatalf("expected .ot file: %v", err)
+	}
+	if count := binary.BigEndian.Uint32(data[0x33A:]); count != 2 {
+		t.Errorf("expected 2 slices, got %d", count)
+	}
+}
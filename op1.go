@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OP-1 drum sampler limits. The OP-Z reads the same drum files.
+const (
+	op1SampleRate = 44100
+	op1Slices     = 24
+	op1MaxFrames  = 12 * op1SampleRate
+
+	// op1PositionScale converts frames to the slice positions stored in the
+	// drum JSON: 12 seconds spans nearly the full signed 32-bit range
+	op1PositionScale = 4058
+
+	// op1Neutral is the centre value of the OP-1's 0-16384 parameter range
+	op1Neutral = 8192
+)
+
+// op1DrumData is the JSON the OP-1 keeps in an AIFF "APPL" chunk after the
+// "op-1" signature. Every per-key array holds exactly op1Slices values.
+type op1DrumData struct {
+	DrumVersion int    `json:"drum_version"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Octave      int    `json:"octave"`
+	Pitch       []int  `json:"pitch"`
+	Start       []int  `json:"start"`
+	End         []int  `json:"end"`
+	Playmode    []int  `json:"playmode"`
+	Reverse     []int  `json:"reverse"`
+	Volume      []int  `json:"volume"`
+	DynaEnv     []int  `json:"dyna_env"`
+	FXActive    bool   `json:"fx_active"`
+	FXType      string `json:"fx_type"`
+	FXParams    []int  `json:"fx_params"`
+	LFOActive   bool   `json:"lfo_active"`
+	LFOType     string `json:"lfo_type"`
+	LFOParams   []int  `json:"lfo_params"`
+}
+
+// op1Path returns the drum AIFF path for a combined WAV
+func op1Path(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".aif"
+}
+
+// filled returns n copies of v
+func filled(n, v int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = v
+	}
+	return s
+}
+
+// newOP1DrumData maps slices to the drum keys in order with the OP-1's default
+// settings. Keys past the last slice are left empty at the end of the sample.
+func newOP1DrumData(layout BatchLayout) op1DrumData {
+	d := op1DrumData{
+		DrumVersion: 2,
+		Type:        "drum",
+		Name:        strings.TrimSuffix(filepath.Base(layout.Path), ".wav"),
+		Pitch:       filled(op1Slices, 0),
+		Start:       filled(op1Slices, layout.NumSamples()*op1PositionScale),
+		End:         filled(op1Slices, layout.NumSamples()*op1PositionScale),
+		Playmode:    filled(op1Slices, op1Neutral),
+		Reverse:     filled(op1Slices, op1Neutral),
+		Volume:      filled(op1Slices, op1Neutral),
+		DynaEnv:     []int{0, op1Neutral, 0, op1Neutral, 0, 0, 0, 0},
+		FXType:      "delay",
+		FXParams:    filled(8, 8000),
+		LFOType:     "tremolo",
+		LFOParams:   []int{16000, 16000, 16000, 16000, 0, 0, 0, 0},
+	}
+	for i := range layout.Slots {
+		start, end := layout.sliceBounds(i)
+		d.Start[i] = start * op1PositionScale
+		d.End[i] = end * op1PositionScale
+	}
+	return d
+}
+
+// checkOP1Layout reports why a batch cannot be loaded as an OP-1 drum kit
+func checkOP1Layout(layout BatchLayout) error {
+	switch {
+	case layout.Channels != 1:
+		return errors.New("OP-1 drum kits are mono; run without -stereo")
+	case layout.SampleRate != op1SampleRate:
+		return fmt.Errorf("OP-1 drum kits are %d Hz, batch is %d Hz", op1SampleRate, layout.SampleRate)
+	case len(layout.Slots) > op1Slices:
+		return fmt.Errorf("OP-1 drum kits hold at most %d slices, batch has %d", op1Slices, len(layout.Slots))
+	case layout.NumSamples() > op1MaxFrames:
+		return fmt.Errorf("OP-1 drum kits hold at most %d s, batch is %.2f s",
+			op1MaxFrames/op1SampleRate, float64(layout.NumSamples())/float64(layout.SampleRate))
+	}
+	return nil
+}
+
+// encodeOP1Drum builds a drum AIFF from a batch's audio with the slice points
+// in its op-1 APPL chunk
+func encodeOP1Drum(layout BatchLayout, samples [][]float64) ([]byte, error) {
+	if err := checkOP1Layout(layout); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(newOP1DrumData(layout))
+	if err != nil {
+		return nil, err
+	}
+	appl := append([]byte("op-1"), data...)
+	appl = append(appl, '\n')
+	return encodeAIFF(samples, layout.SampleRate, aiffChunk("APPL", appl)), nil
+}
+
+// writeOP1Drum writes the drum AIFF for a batch from its combined WAV
+func writeOP1Drum(layout BatchLayout) (string, error) {
+	if err := checkOP1Layout(layout); err != nil {
+		return "", err
+	}
+	wav, err := readWavFile(layout.Path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := encodeOP1Drum(layout, wav.Samples)
+	if err != nil {
+		return "", err
+	}
+	path := op1Path(layout.Path)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
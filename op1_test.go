@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// aiffChunks splits an AIFF file into its chunks by ID
+func aiffChunks(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	if len(data) < 12 || string(data[0:4]) != "FORM" || string(data[8:12]) != "AIFF" {
+		t.Fatal("not an AIFF file")
+	}
+	if size := binary.BigEndian.Uint32(data[4:8]); int(size) != len(data)-8 {
+		t.Fatalf("FORM size %d, file has %d bytes after the header", size, len(data)-8)
+	}
+
+	chunks := make(map[string][]byte)
+	for off := 12; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := int(binary.BigEndian.Uint32(data[off+4 : off+8]))
+		chunks[id] = data[off+8 : off+8+size]
+		off += 8 + size + size%2
+	}
+	return chunks
+}
+
+// ============================================================================
+// AIFF tests
+// ============================================================================
+
+func TestExtended80(t *testing.T) {
+	got := extended80(44100)
+	want := [10]byte{0x40, 0x0E, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}
+	if got != want {
+		t.Errorf("expected % x, got % x", want, got)
+	}
+}
+
+func TestEncodeAIFF(t *testing.T) {
+	data := encodeAIFF([][]float64{{0.5, -1}, {0, 1}}, 22050, aiffChunk("APPL", []byte("abc")))
+	chunks := aiffChunks(t, data)
+
+	comm := chunks["COMM"]
+	if channels := binary.BigEndian.Uint16(comm[0:2]); channels != 2 {
+		t.Errorf("expected 2 channels, got %d", channels)
+	}
+	if frames := binary.BigEndian.Uint32(comm[2:6]); frames != 2 {
+		t.Errorf("expected 2 frames, got %d", frames)
+	}
+	if rate := extended80(22050); string(comm[8:18]) != string(rate[:]) {
+		t.Errorf("unexpected rate bytes % x", comm[8:18])
+	}
+	if string(chunks["APPL"]) != "abc" {
+		t.Errorf("unexpected APPL chunk %q", chunks["APPL"])
+	}
+
+	// Offset and block size, then interleaved big-endian frames
+	ssnd := chunks["SSND"]
+	want := []int16{16383, 0, -32767, 32767}
+	for i, w := range want {
+		if got := int16(binary.BigEndian.Uint16(ssnd[8+2*i:])); got != w {
+			t.Errorf("sample %d: expected %d, got %d", i, w, got)
+		}
+	}
+}
+
+// ============================================================================
+// OP-1 drum tests
+// ============================================================================
+
+func TestEncodeOP1Drum(t *testing.T) {
+	layout := BatchLayout{Path: "/out/kit_3slices_batch001.wav", SampleRate: 44100, Channels: 1, SliceLength: 100, Slots: make([]Slot, 3)}
+	data, err := encodeOP1Drum(layout, [][]float64{make([]float64, 300)})
+	if err != nil {
+		t.Fatalf("encodeOP1Drum failed: %v", err)
+	}
+
+	appl := aiffChunks(t, data)["APPL"]
+	if string(appl[:4]) != "op-1" {
+		t.Fatalf("expected op-1 signature, got %q", appl[:4])
+	}
+	var drum op1DrumData
+	if err := json.Unmarshal(appl[4:], &drum); err != nil {
+		t.Fatalf("APPL chunk is not valid JSON: %v", err)
+	}
+
+	if drum.Type != "drum" || drum.Name != "kit_3slices_batch001" {
+		t.Errorf("unexpected type %q or name %q", drum.Type, drum.Name)
+	}
+	if len(drum.Start) != op1Slices || len(drum.End) != op1Slices || len(drum.Volume) != op1Slices {
+		t.Fatalf("expected %d keys, got %d starts and %d ends", op1Slices, len(drum.Start), len(drum.End))
+	}
+	if drum.Start[1] != 100*op1PositionScale || drum.End[1] != 200*op1PositionScale {
+		t.Errorf("unexpected second slice %d-%d", drum.Start[1], drum.End[1])
+	}
+	if drum.Start[3] != 300*op1PositionScale || drum.End[23] != 300*op1PositionScale {
+		t.Errorf("unused keys should sit at the end of the sample, got %d-%d", drum.Start[3], drum.End[23])
+	}
+}
+
+func TestCheckOP1Layout(t *testing.T) {
+	base := BatchLayout{SampleRate: 44100, Channels: 1, SliceLength: 100, Slots: make([]Slot, 24)}
+	if err := checkOP1Layout(base); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(l *BatchLayout)
+	}{
+		{"stereo", func(l *BatchLayout) { l.Channels = 2 }},
+		{"rate", func(l *BatchLayout) { l.SampleRate = 22050 }},
+		{"too many slices", func(l *BatchLayout) { l.Slots = make([]Slot, 25) }},
+		{"too long", func(l *BatchLayout) { l.SliceLength = op1MaxFrames/24 + 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := base
+			tt.modify(&l)
+			if err := checkOP1Layout(l); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestWriteOP1Drum(t *testing.T) {
+	dir := t.TempDir()
+	layout := BatchLayout{Path: filepath.Join(dir, "kit.wav"), SampleRate: 44100, Channels: 1, SliceLength: 10, Slots: make([]Slot, 2)}
+	writeWavFile(layout.Path, [][]float64{make([]float64, 20)}, 44100, 1)
+
+	path, err := writeOP1Drum(layout)
+	if err != nil {
+		t.Fatalf("writeOP1Drum failed: %v", err)
+	}
+	if path != filepath.Join(dir, "kit.aif") {
+		t.Errorf("unexpected path %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected AIFF file: %v", err)
+	}
+	if frames := binary.BigEndian.Uint32(aiffChunks(t, data)["COMM"][2:6]); frames != 20 {
+		t.Errorf("expected 20 frames, got %d", frames)
+	}
+}
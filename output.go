@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// OutputFormat selects how the slice and plan commands report progress
+type OutputFormat string
+
+const (
+	OutputText OutputFormat = "text" // formatted for reading
+	OutputJSON OutputFormat = "json" // one JSON event per line
+)
+
+// outputFormats lists the accepted formats in help order
+var outputFormats = []OutputFormat{OutputText, OutputJSON}
+
+// parseOutputFormat validates an output format name
+func parseOutputFormat(s string) (OutputFormat, error) {
+	for _, f := range outputFormats {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(outputFormats))
+	for i, f := range outputFormats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown output format %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// textOnly rejects -output-format for the commands that only print text, which
+// would otherwise report it as an unknown flag or a missing file
+func textOnly(command string, args []string) error {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == "output-format" {
+			return fmt.Errorf("%s does not support -output-format; only slice and plan write JSON events", command)
+		}
+	}
+	return nil
+}
+
+// Event types written with -output-format json, in the order a run emits them
+const (
+	eventStart      = "start"       // settings and slice timing
+	eventFile       = "file"        // a matching source was found
+	eventDiscovered = "discovered"  // totals of the sources found
+	eventWarning    = "warning"     // something the run works around or can't read
+	eventBatchStart = "batch_start" // a batch's slots are about to be processed
+	eventProcessing = "processing"  // a slot of the current batch is being processed
+	eventCreated    = "created"     // a file was written
+	eventPlanBatch  = "plan_batch"  // a batch the plan command would write
+	eventPlanSlot   = "plan_slot"   // a slot of the planned batch
+	eventComplete   = "complete"    // the run finished
+	eventError      = "error"       // the run failed
+)
+
+// Event is one line of -output-format json. Fields that don't apply to an
+// event type are left out. Batch and slot numbers count from 1, and a slot
+// number is its position in its batch.
+type Event struct {
+	Event string `json:"event"`
+
+	Path    string `json:"path,omitempty"`
+	Kind    string `json:"kind,omitempty"` // what a created file is: batch, report or an exporter name
+	Message string `json:"message,omitempty"`
+
+	Batch       int     `json:"batch,omitempty"`
+	Slot        int     `json:"slot,omitempty"`
+	Note        string  `json:"note,omitempty"`
+	TruncatedMs float64 `json:"truncated_ms,omitempty"` // estimated, see truncation
+
+	Files       int     `json:"files,omitempty"`
+	Size        int64   `json:"size,omitempty"`
+	Duration    float64 `json:"duration,omitempty"` // seconds
+	SampleRate  int     `json:"sample_rate,omitempty"`
+	Channels    int     `json:"channels,omitempty"`
+	BitDepth    int     `json:"bit_depth,omitempty"`
+	MatchedTag  string  `json:"matched_tag,omitempty"`
+	Correlation float64 `json:"correlation,omitempty"`
+
+	Device          string  `json:"device,omitempty"`
+	Output          string  `json:"output,omitempty"`
+	Slices          int     `json:"slices,omitempty"`
+	SamplesPerSlice int     `json:"samples_per_slice,omitempty"`
+	SliceMs         float64 `json:"slice_ms,omitempty"`
+}
+
+// reporter writes progress as text or, in JSON mode, as one Event per line.
+// Text that has no event is left out of JSON output, so every line parses.
+type reporter struct {
+	w    io.Writer
+	json bool
+}
+
+// progress is where the slice and plan commands report what they do
+var progress = &reporter{w: os.Stdout}
+
+// setFormat switches the reporter between text and JSON events
+func (r *reporter) setFormat(f OutputFormat) {
+	r.json = f == OutputJSON
+}
+
+// Printf writes text that has no event
+func (r *reporter) Printf(format string, args ...any) {
+	if !r.json {
+		fmt.Fprintf(r.w, format, args...)
+	}
+}
+
+// Println writes a line of text that has no event
+func (r *reporter) Println(args ...any) {
+	if !r.json {
+		fmt.Fprintln(r.w, args...)
+	}
+}
+
+// emit writes e as a JSON line, or the formatted text in its place
+func (r *reporter) emit(e Event, format string, args ...any) {
+	if !r.json {
+		fmt.Fprintf(r.w, format, args...)
+		return
+	}
+	if err := json.NewEncoder(r.w).Encode(e); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: writing %s event: %v\n", e.Event, err)
+	}
+}
+
+// warn reports a warning about path, which may be empty
+func (r *reporter) warn(path, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	r.emit(Event{Event: eventWarning, Path: path, Message: msg}, "Warning: %s\n", msg)
+}
+
+// created reports a written file
+func (r *reporter) created(kind, path string, batch int) {
+	r.emit(Event{Event: eventCreated, Kind: kind, Path: path, Batch: batch}, "Created: %s\n", path)
+}
+
+// fail reports the error a command stopped with
+func (r *reporter) fail(err error) {
+	r.emit(Event{Event: eventError, Message: err.Error()}, "Error: %v\n", err)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// Progress output tests
+// ============================================================================
+
+// captureProgress sends progress to a buffer for the rest of the test
+func captureProgress(t *testing.T, format OutputFormat) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := progress
+	progress = &reporter{w: &buf}
+	progress.setFormat(format)
+	t.Cleanup(func() { progress = old })
+	return &buf
+}
+
+// readEvents decodes JSON progress output, failing on any line that isn't an event
+func readEvents(t *testing.T, buf *bytes.Buffer) []Event {
+	t.Helper()
+	var events []Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line is not a JSON event: %q: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, s := range []string{"text", "json", "JSON"} {
+		if _, err := parseOutputFormat(s); err != nil {
+			t.Errorf("parseOutputFormat(%q) failed: %v", s, err)
+		}
+	}
+	if _, err := parseOutputFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestReporter(t *testing.T) {
+	report := func() {
+		progress.Printf("Found %d files\n", 2)
+		progress.warn("a.wav", "could not read %s", "a.wav")
+		progress.created("batch", "out/kit.wav", 1)
+		progress.fail(errors.New("disk full"))
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := captureProgress(t, OutputText)
+		report()
+		want := "Found 2 files\nWarning: could not read a.wav\nCreated: out/kit.wav\nError: disk full\n"
+		if buf.String() != want {
+			t.Errorf("expected %q, got %q", want, buf.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := captureProgress(t, OutputJSON)
+		report()
+		want := []Event{
+			{Event: eventWarning, Path: "a.wav", Message: "could not read a.wav"},
+			{Event: eventCreated, Kind: "batch", Path: "out/kit.wav", Batch: 1},
+			{Event: eventError, Message: "disk full"},
+		}
+		if got := readEvents(t, buf); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+}
+
+func TestProcessFilesEvents(t *testing.T) {
+	dir := t.TempDir()
+	var files []FileInfo
+	for _, name := range []string{"kick_a.wav", "kick_b.wav", "kick_c.wav"} {
+		path := filepath.Join(dir, name)
+		writeWavFile(path, [][]float64{make([]float64, 30)}, 44100, 1)
+		info, err := readWavInfo(path)
+		if err != nil {
+			t.Fatalf("readWavInfo failed: %v", err)
+		}
+		files = append(files, info)
+	}
+
+	buf := captureProgress(t, OutputJSON)
+	displaySummary(files)
+	if _, err := processFiles(slotsFromFiles(files), 44100, 1, 2, 20, "kick", dir, false, MixOptions{}, OutputOptions{}); err != nil {
+		t.Fatalf("processFiles failed: %v", err)
+	}
+
+	var got []string
+	for _, e := range readEvents(t, buf) {
+		got = append(got, e.Event)
+		switch e.Event {
+		case eventProcessing:
+			if e.Slot == 1 && (e.Note != "C4" || e.TruncatedMs <= 0) {
+				t.Errorf("expected slot 1 on C4 with truncation, got %+v", e)
+			}
+		case eventCreated:
+			if e.Kind != "batch" || e.Path != filepath.Join(dir, batchFileName("kick", 2, e.Batch)) {
+				t.Errorf("unexpected created event %+v", e)
+			}
+		}
+	}
+	want := []string{
+		eventFile, eventFile, eventFile, eventDiscovered,
+		eventBatchStart, eventProcessing, eventProcessing, eventCreated,
+		eventBatchStart, eventProcessing, eventCreated,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected events %v, got %v", want, got)
+	}
+}
+
+func TestSliceJSONNeedsYes(t *testing.T) {
+	captureProgress(t, OutputText)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	args := []string{"-pattern", "kick", "-dir", dir, "-output", dir, "-output-format", "json"}
+
+	if _, err := newSliceJob("slice", args); err == nil || !strings.Contains(err.Error(), "-yes") {
+		t.Errorf("expected JSON output without -yes to fail, got %v", err)
+	}
+	if _, err := newSliceJob("slice", append(args, "-yes")); err != nil {
+		t.Errorf("expected -yes to allow JSON output, got %v", err)
+	}
+	if _, err := newSliceJob("plan", args); err != nil {
+		t.Errorf("expected plan to need no confirmation, got %v", err)
+	}
+	if _, err := newSliceJob("slice", []string{"-pattern", "kick", "-dir", dir, "-interactive", "-yes"}); err == nil {
+		t.Error("expected -interactive with -yes to fail")
+	}
+}
+
+func TestTextOnlyCommands(t *testing.T) {
+	commands := map[string]func([]string) error{
+		"verify":   runVerify,
+		"info":     runInfo,
+		"validate": runValidate,
+		"split":    runSplit,
+		"convert":  runConvert,
+		"serve":    runServe,
+	}
+	for name, run := range commands {
+		for _, args := range [][]string{
+			{"-output-format", "json", "a.wav"},
+			{"--output-format=json", "a.wav"},
+		} {
+			err := run(args)
+			if err == nil || !strings.Contains(err.Error(), "does not support -output-format") {
+				t.Errorf("%s %v: expected -output-format to be refused, got %v", name, args, err)
+			}
+		}
+	}
+
+	if err := textOnly("info", []string{"--", "-output-format"}); err != nil {
+		t.Errorf("expected file names after -- to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// PoorCorrelation is the left/right correlation below which summing to mono
+// cancels a noticeable part of the signal
+const PoorCorrelation = -0.3
+
+// MonoFallback selects what to do with a poorly correlated source when summing to mono
+type MonoFallback string
+
+const (
+	FallbackNone   MonoFallback = "none"   // keep the selected mono mode
+	FallbackLouder MonoFallback = "louder" // use whichever channel has more energy
+	FallbackMid    MonoFallback = "mid"    // flip the right channel's polarity, then take the mid
+)
+
+// monoFallbacks lists the accepted fallbacks in help order
+var monoFallbacks = []MonoFallback{FallbackNone, FallbackLouder, FallbackMid}
+
+// parseMonoFallback validates a fallback policy name
+func parseMonoFallback(s string) (MonoFallback, error) {
+	for _, f := range monoFallbacks {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(monoFallbacks))
+	for i, f := range monoFallbacks {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown mono fallback %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// stereoCorrelation returns the zero-lag correlation of two channels, from 1
+// (identical, mono-safe) to -1 (phase-inverted, cancels completely). A silent
+// channel cannot cancel anything and counts as fully correlated.
+func stereoCorrelation(left, right []float64) float64 {
+	var lr, ll, rr float64
+	for i := range left {
+		lr += left[i] * right[i]
+		ll += left[i] * left[i]
+		rr += right[i] * right[i]
+	}
+	if ll == 0 || rr == 0 {
+		return 1
+	}
+	return lr / math.Sqrt(ll*rr)
+}
+
+// monoMode returns the mono mode for a stereo source, switching to the
+// fallback when the channels are poorly correlated
+func (o MixOptions) monoMode(left, right []float64) MonoMode {
+	if o.Fallback == "" || o.Fallback == FallbackNone || stereoCorrelation(left, right) >= PoorCorrelation {
+		return o.Mono
+	}
+
+	// With the right channel inverted, (L+R)/2 becomes (L-R)/2: what cancelled
+	// in the plain mid now adds up
+	if o.Fallback == FallbackMid {
+		return MonoSide
+	}
+
+	var leftEnergy, rightEnergy float64
+	for i := range left {
+		leftEnergy += left[i] * left[i]
+		rightEnergy += right[i] * right[i]
+	}
+	if rightEnergy > leftEnergy {
+		return MonoRight
+	}
+	return MonoLeft
+}
+
+// checkMonoCompatibility measures the correlation of every stereo or surround
+// slot over the part of the source that reaches its slice. Unreadable files are
+// skipped here and reported when the batch is processed.
+func checkMonoCompatibility(slots []Slot, targetRate, samplesPerSlice int) {
+	for i := range slots {
+		slot := &slots[i]
+		if slot.Path == "" || slot.Channels < 2 {
+			continue
+		}
+
+		samples, header, err := readSlotSource(slot.Path, slot.TrimMs, targetRate, samplesPerSlice)
+		if err != nil {
+			continue
+		}
+		if len(samples) > 2 {
+			if samples = downmixToStereo(samples, header.ExtChannelMask); samples == nil {
+				continue
+			}
+		}
+
+		slot.Correlation = stereoCorrelation(samples[0], samples[1])
+		slot.CorrelationChecked = true
+	}
+}
+
+// printMonoWarnings lists files whose mono sum would partly cancel
+func printMonoWarnings(files []FileInfo, fallback MonoFallback) {
+	var poor []FileInfo
+	for _, f := range files {
+		if f.CorrelationChecked && f.Correlation < PoorCorrelation {
+			poor = append(poor, f)
+		}
+	}
+	if len(poor) == 0 {
+		return
+	}
+
+	progress.Printf("\nWarning: %d stereo file(s) have poor mono compatibility (correlation below %.1f):\n", len(poor), PoorCorrelation)
+	for _, f := range poor {
+		e := Event{Event: eventWarning, Path: f.Path, Correlation: f.Correlation, Message: fmt.Sprintf("poor mono compatibility (correlation %+.2f)", f.Correlation)}
+		progress.emit(e, "  %-50s correlation %+.2f\n", filepath.Base(f.Path), f.Correlation)
+	}
+	switch fallback {
+	case FallbackLouder:
+		progress.Println("  These will use their louder channel instead of a mono sum.")
+	case FallbackMid:
+		progress.Println("  These will have their right channel's polarity flipped before summing.")
+	default:
+		progress.Println("  Use -mono-fallback louder or -mono-fallback mid to avoid cancellation, or -stereo.")
+	}
+}
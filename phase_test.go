@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// Correlation tests
+// ============================================================================
+
+func TestStereoCorrelation(t *testing.T) {
+	tone := make([]float64, 100)
+	inverted := make([]float64, 100)
+	for i := range tone {
+		tone[i] = math.Sin(float64(i) * 0.3)
+		inverted[i] = -tone[i]
+	}
+
+	tests := []struct {
+		name     string
+		right    []float64
+		expected float64
+	}{
+		{"identical", tone, 1},
+		{"phase inverted", inverted, -1},
+		{"silent channel", make([]float64, 100), 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := stereoCorrelation(tone, tc.right); math.Abs(got-tc.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMonoFallback(t *testing.T) {
+	if f, err := parseMonoFallback("Louder"); err != nil || f != FallbackLouder {
+		t.Errorf("expected louder, got %q, %v", f, err)
+	}
+	if _, err := parseMonoFallback("quietest"); err == nil {
+		t.Error("expected error for unknown fallback")
+	}
+
+	// Right is louder and inverted against left
+	stereo := [][]float64{{0.2, -0.2, 0.2}, {-0.5, 0.5, -0.5}}
+
+	tests := []struct {
+		name     string
+		opts     MixOptions
+		expected float64
+	}{
+		{"no fallback sums", MixOptions{Mono: MonoSum6dB}, -0.15},
+		{"louder channel", MixOptions{Mono: MonoSum6dB, Fallback: FallbackLouder}, -0.5},
+		{"polarity-corrected mid", MixOptions{Mono: MonoLeft, Fallback: FallbackMid}, 0.35},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mono := convertChannels(stereo, 0, 1, tc.opts)
+			if math.Abs(mono[0][0]-tc.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tc.expected, mono[0][0])
+			}
+		})
+	}
+
+	t.Run("anti-phase source is not silent", func(t *testing.T) {
+		antiPhase := [][]float64{{0.5, -0.25, 0.1}, {-0.5, 0.25, -0.1}}
+		if silent := convertChannels(antiPhase, 0, 1, MixOptions{Mono: MonoSum6dB}); silent[0][0] != 0 {
+			t.Fatalf("expected the plain sum to cancel, got %v", silent[0])
+		}
+		mono := convertChannels(antiPhase, 0, 1, MixOptions{Mono: MonoSum6dB, Fallback: FallbackMid})
+		for i, want := range antiPhase[0] {
+			if math.Abs(mono[0][i]-want) > 1e-9 {
+				t.Errorf("frame %d: expected %f, got %f", i, want, mono[0][i])
+			}
+		}
+	})
+
+	t.Run("correlated source keeps mono mode", func(t *testing.T) {
+		correlated := [][]float64{{0.2, -0.2}, {0.4, -0.4}}
+		mono := convertChannels(correlated, 0, 1, MixOptions{Mono: MonoSum6dB, Fallback: FallbackLouder})
+		if math.Abs(mono[0][0]-0.3) > 1e-9 {
+			t.Errorf("expected 0.3, got %f", mono[0][0])
+		}
+	})
+}
+
+// ============================================================================
+// checkMonoCompatibility tests
+// ============================================================================
+
+func TestCheckMonoCompatibility(t *testing.T) {
+	dir := t.TempDir()
+
+	wide := make([]float64, 1000)
+	for i := range wide {
+		wide[i] = 0.5 * math.Sin(float64(i)*0.1)
+	}
+	inverted := make([]float64, len(wide))
+	for i := range wide {
+		inverted[i] = -wide[i]
+	}
+
+	widePath := filepath.Join(dir, "wide_pad.wav")
+	writeWavFile(widePath, [][]float64{wide, inverted}, 44100, 2)
+	monoPath := filepath.Join(dir, "mono.wav")
+	writeWavFile(monoPath, [][]float64{wide}, 44100, 1)
+
+	slots := []Slot{
+		{FileInfo: FileInfo{Path: widePath, Channels: 2}},
+		{FileInfo: FileInfo{Path: monoPath, Channels: 1}},
+		{},
+	}
+	checkMonoCompatibility(slots, 44100, 500)
+
+	if !slots[0].CorrelationChecked || slots[0].Correlation > -0.99 {
+		t.Errorf("expected inverted stereo to be flagged, got %+v", slots[0].FileInfo)
+	}
+	if slots[1].CorrelationChecked || slots[2].CorrelationChecked {
+		t.Error("expected mono and empty slots to be skipped")
+	}
+}
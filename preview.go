@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// PreviewFormat selects whether and how an audition preview is written for each batch
+type PreviewFormat string
+
+const (
+	PreviewNone PreviewFormat = "none"
+	PreviewWAV  PreviewFormat = "wav"
+	PreviewFLAC PreviewFormat = "flac"
+)
+
+// previewFormats lists the accepted formats in help order
+var previewFormats = []PreviewFormat{PreviewNone, PreviewWAV, PreviewFLAC}
+
+// parsePreviewFormat validates a preview format name
+func parsePreviewFormat(s string) (PreviewFormat, error) {
+	for _, f := range previewFormats {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(previewFormats))
+	for i, f := range previewFormats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown preview format %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// Preview levels: slices peak at -1 dBFS so headphone playback does not clip
+// after resampling, and the click sits well below them
+const (
+	previewPeakDB     = -1.0
+	previewClickLevel = 0.25
+	previewClickHz    = 2000
+	previewClickMs    = 5
+)
+
+// PreviewOptions controls the audition preview. Each slice follows a GapMs
+// silence, which starts with a click when Click is set.
+type PreviewOptions struct {
+	Format PreviewFormat
+	GapMs  int
+	Click  bool
+}
+
+// enabled reports whether a preview should be written
+func (o PreviewOptions) enabled() bool {
+	return o.Format != "" && o.Format != PreviewNone
+}
+
+// validate checks the gap is not negative
+func (o PreviewOptions) validate() error {
+	if o.GapMs < 0 {
+		return errors.New("-preview-gap must not be negative")
+	}
+	return nil
+}
+
+// previewPath returns the preview path for a combined WAV
+func previewPath(wavPath string, format PreviewFormat) string {
+	return strings.TrimSuffix(wavPath, ".wav") + "_preview." + string(format)
+}
+
+// previewGap returns the silence before each slice, starting with a short
+// decaying sine burst when click is set
+func previewGap(length, numChannels, sampleRate int, click bool) [][]float64 {
+	gap := make([][]float64, numChannels)
+	for ch := range gap {
+		gap[ch] = make([]float64, length)
+	}
+	if !click {
+		return gap
+	}
+
+	n := min(msToSamples(previewClickMs, sampleRate), length)
+	for i := 0; i < n; i++ {
+		env := 1 - float64(i)/float64(n)
+		v := previewClickLevel * env * math.Sin(2*math.Pi*previewClickHz*float64(i)/float64(sampleRate))
+		for ch := range gap {
+			gap[ch][i] = v
+		}
+	}
+	return gap
+}
+
+// renderPreview lays out the slices of a batch with a gap before each,
+// normalizing the slices (not the clicks) for listening
+func renderPreview(layout BatchLayout, audio [][]float64, opts PreviewOptions) [][]float64 {
+	levelled := make([][]float64, len(audio))
+	for ch := range audio {
+		levelled[ch] = append([]float64(nil), audio[ch]...)
+	}
+	levelled = applyGain(normalizeSamples(levelled), previewPeakDB)
+
+	gap := previewGap(msToSamples(float64(opts.GapMs), layout.SampleRate), layout.Channels, layout.SampleRate, opts.Click)
+	var parts [][][]float64
+	for i := range layout.Slots {
+		start, end := layout.sliceBounds(i)
+		slice := make([][]float64, len(levelled))
+		for ch := range slice {
+			n := len(levelled[ch])
+			slice[ch] = levelled[ch][min(start, n):min(end, n)]
+		}
+		parts = append(parts, gap, slice)
+	}
+	return concatenateSamples(parts, layout.Channels)
+}
+
+// writePreview writes the audition preview for a batch from its combined WAV
+func writePreview(layout BatchLayout, opts PreviewOptions) (string, error) {
+	wav, err := readWavFile(layout.Path)
+	if err != nil {
+		return "", err
+	}
+
+	preview := renderPreview(layout, wav.Samples, opts)
+	path := previewPath(layout.Path, opts.Format)
+	if opts.Format == PreviewFLAC {
+		err = os.WriteFile(path, encodeFLAC(preview, layout.SampleRate), 0644)
+	} else {
+		err = writeWavFile(path, preview, layout.SampleRate, layout.Channels)
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
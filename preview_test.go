@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// Preview tests
+// ============================================================================
+
+func TestRenderPreview(t *testing.T) {
+	layout := BatchLayout{SampleRate: 1000, Channels: 1, SliceLength: 4, Slots: make([]Slot, 2)}
+	audio := [][]float64{{0.5, 0.25, 0, 0, -0.25, 0, 0, 0}}
+
+	t.Run("gaps and level", func(t *testing.T) {
+		out := renderPreview(layout, audio, PreviewOptions{Format: PreviewWAV, GapMs: 3})
+		if len(out[0]) != 2*(3+4) {
+			t.Fatalf("expected 14 samples, got %d", len(out[0]))
+		}
+		peak := math.Pow(10, previewPeakDB/20)
+		want := []float64{0, 0, 0, peak, peak / 2, 0, 0, 0, 0, 0, -peak / 2, 0, 0, 0}
+		for i, w := range want {
+			if math.Abs(out[0][i]-w) > 1e-9 {
+				t.Errorf("sample %d: expected %.4f, got %.4f", i, w, out[0][i])
+			}
+		}
+		if audio[0][0] != 0.5 {
+			t.Error("source audio was modified")
+		}
+	})
+
+	t.Run("click", func(t *testing.T) {
+		layout.SampleRate = 44100
+		out := renderPreview(layout, audio, PreviewOptions{Format: PreviewWAV, GapMs: 10, Click: true})
+		click := msToSamples(previewClickMs, 44100)
+		nonzero := false
+		for _, v := range out[0][:click] {
+			if math.Abs(v) > previewClickLevel {
+				t.Fatalf("click louder than %v: %v", previewClickLevel, v)
+			}
+			nonzero = nonzero || v != 0
+		}
+		if !nonzero {
+			t.Error("expected a click at the start of the gap")
+		}
+		for i, v := range out[0][click:msToSamples(10, 44100)] {
+			if v != 0 {
+				t.Fatalf("expected silence after the click, sample %d is %v", click+i, v)
+			}
+		}
+	})
+}
+
+func TestParsePreviewFormat(t *testing.T) {
+	if f, err := parsePreviewFormat("FLAC"); err != nil || f != PreviewFLAC {
+		t.Errorf("expected flac, got %q (%v)", f, err)
+	}
+	if _, err := parsePreviewFormat("mp3"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestWritePreview(t *testing.T) {
+	dir := t.TempDir()
+	layout := BatchLayout{Path: filepath.Join(dir, "kit.wav"), SampleRate: 44100, Channels: 2, SliceLength: 10, Slots: make([]Slot, 2)}
+	writeWavFile(layout.Path, [][]float64{make([]float64, 20), make([]float64, 20)}, 44100, 2)
+
+	for _, format := range []PreviewFormat{PreviewWAV, PreviewFLAC} {
+		path, err := writePreview(layout, PreviewOptions{Format: format, GapMs: 1})
+		if err != nil {
+			t.Fatalf("writePreview(%s) failed: %v", format, err)
+		}
+		if want := filepath.Join(dir, "kit_preview."+string(format)); path != want {
+			t.Errorf("expected %s, got %s", want, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected preview file: %v", err)
+		}
+	}
+
+	wav, err := readWavFile(filepath.Join(dir, "kit_preview.wav"))
+	if err != nil {
+		t.Fatalf("failed to read preview: %v", err)
+	}
+	if want := 2 * (msToSamples(1, 44100) + 10); wav.NumSamples != want {
+		t.Errorf("expected %d frames, got %d", want, wav.NumSamples)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Output files are 16-bit, so a sample written at or beyond ±1.0 reads back
+// as ±32767/32768; a full-scale source comes through a step lower, since 16-bit
+// input is read over 32768 and written over 32767. A run of clipRunLength
+// samples within a step of full scale is reported as clipping; a single peak,
+// as normalization leaves, is not.
+const (
+	clipLevel     = 32766.0 / 32768.0
+	clipRunLength = 3
+)
+
+// Slice thumbnail size in the report's tables
+const (
+	reportWaveWidth  = 160
+	reportWaveHeight = 32
+)
+
+// levelStats are the levels of a range of frames across all channels
+type levelStats struct {
+	Peak    float64 // dBFS
+	RMS     float64 // dBFS
+	Clipped int     // samples in runs of clipRunLength or more at full scale
+}
+
+// dbfs converts a linear level to dBFS, -Inf for silence
+func dbfs(v float64) float64 {
+	if v <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(v)
+}
+
+// measureLevels returns the peak, RMS and clipping of frames start to end-1
+func measureLevels(audio [][]float64, start, end int) levelStats {
+	var peak, sum float64
+	var n, clipped int
+	for _, ch := range audio {
+		run := 0
+		for i := start; i < min(end, len(ch)); i++ {
+			v := math.Abs(ch[i])
+			peak = max(peak, v)
+			sum += v * v
+			n++
+
+			if v < clipLevel {
+				run = 0
+				continue
+			}
+			run++
+			if run == clipRunLength {
+				clipped += clipRunLength
+			} else if run > clipRunLength {
+				clipped++
+			}
+		}
+	}
+	if n == 0 {
+		return levelStats{Peak: math.Inf(-1), RMS: math.Inf(-1)}
+	}
+	return levelStats{Peak: dbfs(peak), RMS: dbfs(math.Sqrt(sum / float64(n))), Clipped: clipped}
+}
+
+// inlineSVG strips the XML declaration so an SVG document can sit in HTML
+func inlineSVG(doc string) template.HTML {
+	if i := strings.Index(doc, "?>"); i >= 0 {
+		doc = strings.TrimLeft(doc[i+2:], "\n")
+	}
+	return template.HTML(doc)
+}
+
+// sliceWave draws the small waveform shown beside a slice in the report
+func sliceWave(audio [][]float64, start, end int) template.HTML {
+	c := newSVGCanvas(reportWaveWidth, reportWaveHeight)
+	c.rect(0, 0, reportWaveWidth, reportWaveHeight, waveformShade)
+	c.wave(0, 0, reportWaveHeight, waveformPeaks(audio, start, end, reportWaveWidth), waveformWave)
+	return inlineSVG(c.document())
+}
+
+type reportSlice struct {
+	sliceLabel
+	Levels      levelStats
+	TruncatedMs float64
+	Wave        template.HTML
+}
+
+type reportBatch struct {
+	Name     string
+	Duration float64
+	Levels   levelStats
+	Overview template.HTML
+	Audio    template.URL
+	Slices   []reportSlice
+	Exports  []string
+	Warnings []string
+}
+
+type reportSetting struct {
+	Key, Value, Source string
+}
+
+type reportData struct {
+	Title         string
+	Generated     string
+	Version       string
+	Settings      []reportSetting
+	Files         []FileInfo
+	TotalSize     int64
+	TotalDuration float64
+	Warnings      []string
+	Batches       []reportBatch
+}
+
+// reportPath returns where the report for a run is written
+func reportPath(job *sliceJob) string {
+	return filepath.Join(job.Settings.Output, sanitizeFilename(job.Name)+"_report.html")
+}
+
+// newReportBatch measures a written batch and embeds its audio
+func newReportBatch(result BatchResult, sliceSeconds float64) (reportBatch, error) {
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		return reportBatch{}, err
+	}
+	wav, err := readWavFile(result.Path)
+	if err != nil {
+		return reportBatch{}, err
+	}
+
+	overview, _, err := renderWaveformImages(result.BatchLayout, wav.Samples, ".svg")
+	if err != nil {
+		return reportBatch{}, err
+	}
+	b := reportBatch{
+		Name:     filepath.Base(result.Path),
+		Duration: float64(wav.NumSamples) / float64(result.SampleRate),
+		Levels:   measureLevels(wav.Samples, 0, wav.NumSamples),
+		Overview: inlineSVG(string(overview)),
+		Audio:    template.URL("data:audio/wav;base64," + base64.StdEncoding.EncodeToString(data)),
+	}
+	for _, p := range result.Exports {
+		b.Exports = append(b.Exports, filepath.Base(p))
+	}
+
+	for i, label := range sliceLabels(result.BatchLayout) {
+		start, end := result.sliceBounds(i)
+		s := reportSlice{
+			sliceLabel:  label,
+			Levels:      measureLevels(wav.Samples, start, end),
+			TruncatedMs: truncation(result.Slots[i], sliceSeconds) * 1000,
+			Wave:        sliceWave(wav.Samples, start, end),
+		}
+		if s.TruncatedMs > 0 {
+			b.Warnings = append(b.Warnings, fmt.Sprintf("Slice %s (%s): %s is truncated by about %.0f ms", s.Number, s.Note, s.Name, s.TruncatedMs))
+		}
+		if s.Levels.Clipped > 0 {
+			b.Warnings = append(b.Warnings, fmt.Sprintf("Slice %s (%s): %s has %d clipped samples", s.Number, s.Note, s.Name, s.Levels.Clipped))
+		}
+		b.Slices = append(b.Slices, s)
+	}
+	return b, nil
+}
+
+// buildReport gathers everything the report shows for a finished run
+func buildReport(job *sliceJob, results []BatchResult) (*reportData, error) {
+	s := job.Settings
+	data := &reportData{
+		Title:     job.Name,
+		Generated: time.Now().Format("2006-01-02 15:04:05"),
+		Version:   Version,
+		Files:     job.files(),
+	}
+	for _, key := range settingKeys {
+		data.Settings = append(data.Settings, reportSetting{key, s.value(key), s.Sources[key]})
+	}
+	for _, f := range data.Files {
+		data.TotalSize += f.Size
+		data.TotalDuration += f.Duration
+		if f.CorrelationChecked && f.Correlation < PoorCorrelation {
+			data.Warnings = append(data.Warnings, fmt.Sprintf("%s has poor mono compatibility (correlation %+.2f)", filepath.Base(f.Path), f.Correlation))
+		}
+	}
+
+	sliceSeconds := float64(job.SamplesPerSlice) / float64(s.Rate)
+	for _, r := range results {
+		b, err := newReportBatch(r, sliceSeconds)
+		if err != nil {
+			return nil, err
+		}
+		data.Batches = append(data.Batches, b)
+	}
+	return data, nil
+}
+
+// writeReport writes a self-contained HTML report of a finished run
+func writeReport(job *sliceJob, results []BatchResult) (string, error) {
+	data, err := buildReport(job, results)
+	if err != nil {
+		return "", err
+	}
+
+	path := reportPath(job)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := reportTemplate.Execute(f, data); err != nil {
+		return "", err
+	}
+	return path, f.Close()
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"base": filepath.Base,
+	"size": formatSize,
+	"db": func(v float64) string {
+		if math.IsInf(v, -1) {
+			return "-∞"
+		}
+		return fmt.Sprintf("%.1f", v)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} – wavslice report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin: 0.5rem 0 1.5rem; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.25rem 0.75rem; text-align: left; vertical-align: middle; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+tr.warn td { background: #fff4e5; }
+.warnings { background: #fff4e5; border-left: 4px solid #e89b2f; padding: 0.5rem 1rem; }
+.overview svg { width: 100%; height: auto; }
+section { margin-bottom: 3rem; }
+small { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p><small>Generated {{.Generated}} by wavslice {{.Version}}</small></p>
+
+<h2>Settings</h2>
+<table>
+<tr><th>Setting</th><th>Value</th><th>From</th></tr>
+{{range .Settings}}<tr><td>{{.Key}}</td><td>{{.Value}}</td><td><small>{{.Source}}</small></td></tr>
+{{end}}</table>
+
+<h2>Sources</h2>
+<table>
+<tr><th>File</th><th>Size</th><th>Rate</th><th>Ch</th><th>Bits</th><th>Duration</th></tr>
+{{range .Files}}<tr><td>{{base .Path}}</td><td class="num">{{size .Size}}</td><td class="num">{{.SampleRate}} Hz</td><td class="num">{{.Channels}}</td><td class="num">{{.BitDepth}}</td><td class="num">{{printf "%.3f" .Duration}} s</td></tr>
+{{end}}<tr><th>{{len .Files}} files</th><th>{{size .TotalSize}}</th><th></th><th></th><th></th><th>{{printf "%.2f" .TotalDuration}} s</th></tr>
+</table>
+{{if .Warnings}}<div class="warnings"><ul>
+{{range .Warnings}}<li>{{.}}</li>
+{{end}}</ul></div>{{end}}
+
+{{range .Batches}}<section>
+<h2>{{.Name}}</h2>
+<p>{{printf "%.3f" .Duration}} s · peak {{db .Levels.Peak}} dBFS · RMS {{db .Levels.RMS}} dBFS{{if .Exports}} · also written: {{range $i, $e := .Exports}}{{if $i}}, {{end}}{{$e}}{{end}}{{end}}</p>
+<audio controls preload="none" src="{{.Audio}}"></audio>
+<div class="overview">{{.Overview}}</div>
+{{if .Warnings}}<div class="warnings"><ul>
+{{range .Warnings}}<li>{{.}}</li>
+{{end}}</ul></div>{{end}}
+<table>
+<tr><th>#</th><th>Note</th><th>Source</th><th>Waveform</th><th>Peak dBFS</th><th>RMS dBFS</th><th>Truncated</th><th>Clipped</th></tr>
+{{range .Slices}}<tr{{if or .TruncatedMs .Levels.Clipped}} class="warn"{{end}}><td class="num">{{.Number}}</td><td>{{.Note}}</td><td>{{.Name}}</td><td>{{.Wave}}</td><td class="num">{{db .Levels.Peak}}</td><td class="num">{{db .Levels.RMS}}</td><td class="num">{{if .TruncatedMs}}{{printf "%.0f" .TruncatedMs}} ms{{end}}</td><td class="num">{{if .Levels.Clipped}}{{.Levels.Clipped}}{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// Report tests
+// ============================================================================
+
+func TestMeasureLevels(t *testing.T) {
+	t.Run("peak and rms", func(t *testing.T) {
+		l := measureLevels([][]float64{{0.5, -0.5, 0.5, -0.5}}, 0, 4)
+		if math.Abs(l.Peak-dbfs(0.5)) > 1e-9 || math.Abs(l.RMS-dbfs(0.5)) > 1e-9 || l.Clipped != 0 {
+			t.Errorf("unexpected levels %+v", l)
+		}
+	})
+
+	t.Run("silence", func(t *testing.T) {
+		l := measureLevels([][]float64{make([]float64, 4)}, 0, 4)
+		if !math.IsInf(l.Peak, -1) || !math.IsInf(l.RMS, -1) {
+			t.Errorf("expected -Inf for silence, got %+v", l)
+		}
+	})
+
+	t.Run("clipping runs", func(t *testing.T) {
+		// A lone full-scale peak and a run of two are not clipping; a run of four is
+		audio := [][]float64{{1, 0, -1, -1, 0, 1, 1, 1, 1, 0}}
+		if l := measureLevels(audio, 0, 10); l.Clipped != 4 {
+			t.Errorf("expected 4 clipped samples, got %d", l.Clipped)
+		}
+		if l := measureLevels(audio, 0, 5); l.Clipped != 0 {
+			t.Errorf("expected no clipping, got %d", l.Clipped)
+		}
+	})
+}
+
+func TestInlineSVG(t *testing.T) {
+	c := newSVGCanvas(10, 10)
+	got := string(inlineSVG(c.document()))
+	if !strings.HasPrefix(got, "<svg ") {
+		t.Errorf("expected the document to start at <svg, got %q", got)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	dir := t.TempDir()
+	long := filepath.Join(dir, "kick <long>.wav")
+	writeWavFile(long, [][]float64{make([]float64, 100)}, 44100, 1)
+	loud := filepath.Join(dir, "kick_loud.wav")
+	writeWavFile(loud, [][]float64{{0, 1, 1, 1, 1, 1}}, 44100, 1)
+
+	var files []FileInfo
+	for _, p := range []string{long, loud} {
+		info, err := readWavInfo(p)
+		if err != nil {
+			t.Fatalf("readWavInfo failed: %v", err)
+		}
+		files = append(files, info)
+	}
+
+	s := defaultSettings()
+	s.Output = filepath.Join(dir, "out")
+	s.Slices = 2
+	os.Mkdir(s.Output, 0755)
+	job := &sliceJob{Settings: s, Name: "kick", Slots: slotsFromFiles(files), NumChannels: 1, SamplesPerSlice: 10}
+
+	results, err := processFiles(job.Slots, 44100, 1, 2, 10, "kick", s.Output, false, MixOptions{}, OutputOptions{})
+	if err != nil {
+		t.Fatalf("processFiles failed: %v", err)
+	}
+	path, err := writeReport(job, results)
+	if err != nil {
+		t.Fatalf("writeReport failed: %v", err)
+	}
+	if path != filepath.Join(s.Output, "kick_report.html") {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected report: %v", err)
+	}
+	html := string(data)
+	for _, want := range []string{
+		"<h2>kick_2slices_batch001.wav</h2>",
+		`src="data:audio/wav;base64,UklGR`,
+		"kick &lt;long&gt;.wav",
+		"Slice 1 (C4): kick &lt;long&gt; is truncated by about 2 ms",
+		"Slice 2 (C#4): kick_loud has 5 clipped samples",
+		"<td>pattern</td>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+	if strings.Contains(html, "ZgotmplZ") {
+		t.Error("report contains a value html/template rejected")
+	}
+}
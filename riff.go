@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// RiffChunk describes one chunk found while walking a RIFF file
+type RiffChunk struct {
+	ID       string
+	Offset   int64 // offset of the chunk header from the start of the file
+	Size     int64 // body size, taken from ds64 for an RF64 data chunk
+	Unpadded bool  // odd-sized chunk written without the RIFF pad byte
+	Streamed bool  // data size left unset by a streaming recorder; Size runs to the end of the stream
+}
+
+// listChunks walks the top-level chunks of a RIFF/WAVE or RF64/BW64 stream.
+// Odd-sized chunks are followed by a pad byte; writers that omit it are detected
+// and tolerated. A data chunk whose size was never patched runs to the end of
+// the stream and ends the walk, as in readWavHeader.
+func listChunks(r io.ReadSeeker) ([]RiffChunk, error) {
+	streamEnd, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+	if string(riff[0:4]) == "riff" {
+		return nil, errors.New("Wave64 files cannot be inspected chunk by chunk")
+	}
+	marker := [4]byte(riff[0:4])
+	if (string(riff[0:4]) != "RIFF" && !isRF64(marker)) || string(riff[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF/WAVE file")
+	}
+
+	var ds64 *ds64Sizes
+	var chunks []RiffChunk
+	offset := int64(12)
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return chunks, err
+		}
+
+		chunk := RiffChunk{
+			ID:     string(hdr[0:4]),
+			Offset: offset,
+			Size:   int64(binary.LittleEndian.Uint32(hdr[4:8])),
+		}
+
+		switch {
+		case chunk.ID == "ds64" && isRF64(marker):
+			var body [24]byte
+			if _, err := io.ReadFull(r, body[:]); err == nil {
+				if sizes, err := parseDS64(body[:]); err == nil {
+					ds64 = &sizes
+				}
+			}
+		case chunk.ID == "data" && chunk.Size == rf64SizeUnset && ds64 != nil && ds64.DataSize <= 1<<62:
+			chunk.Size = int64(ds64.DataSize)
+		case chunk.ID == "data" && (chunk.Size == 0xFFFFFFFF || (chunk.Size == 0 && streamEnd > offset+8)):
+			chunk.Size = streamEnd - offset - 8
+			chunk.Streamed = true
+			return append(chunks, chunk), nil
+		}
+
+		next := offset + 8 + chunk.Size
+		if chunk.Size%2 == 1 {
+			if padMissing(r, next) {
+				chunk.Unpadded = true
+			} else {
+				next++
+			}
+		}
+		chunks = append(chunks, chunk)
+
+		if _, err := r.Seek(next, io.SeekStart); err != nil {
+			return chunks, err
+		}
+		offset = next
+	}
+
+	return chunks, nil
+}
+
+// padMissing reports whether the odd-sized chunk ending at end was written without
+// its pad byte: true only when a chunk header starts at end but not at end+1.
+func padMissing(r io.ReadSeeker, end int64) bool {
+	var buf [5]byte
+	if _, err := r.Seek(end, io.SeekStart); err != nil {
+		return false
+	}
+	n, _ := io.ReadFull(r, buf[:])
+	if n < 4 {
+		return false
+	}
+	return isChunkID(buf[0:4]) && (n < 5 || !isChunkID(buf[1:5]))
+}
+
+// isChunkID reports whether b looks like a four-character chunk code
+func isChunkID(b []byte) bool {
+	for _, c := range b[:4] {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxKitRequest caps the size of a kit posted by the browser
+const maxKitRequest = 1 << 20
+
+// libraryFile is a scanned source as the browser UI sees it
+type libraryFile struct {
+	File     string  `json:"file"` // slash-separated path relative to the library directory
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration"`
+	Rate     uint32  `json:"rate"`
+	Channels uint16  `json:"channels"`
+	Bits     uint16  `json:"bits"`
+	Size     int64   `json:"size"`
+}
+
+// libraryResponse is the library listing with the slice layout builds use
+type libraryResponse struct {
+	Dir     string        `json:"dir"`
+	Output  string        `json:"output"`
+	Device  string        `json:"device"`
+	Slices  int           `json:"slices"`
+	SliceMs float64       `json:"slice_ms"`
+	Files   []libraryFile `json:"files"`
+}
+
+// buildResponse lists the files a build wrote
+type buildResponse struct {
+	Created []string `json:"created"`
+}
+
+// server is the browser kit builder behind the serve command. The library is
+// scanned with the same pattern and tag matching as slice, and builds run the
+// slice pipeline one at a time with the server's settings.
+type server struct {
+	job *sliceJob
+
+	mu      sync.Mutex
+	library map[string]FileInfo // by libraryFile.File
+	files   []libraryFile
+
+	building sync.Mutex
+}
+
+// newServer scans the library for a job's settings
+func newServer(job *sliceJob) (*server, error) {
+	srv := &server{job: job}
+	if err := srv.scan(); err != nil {
+		return nil, err
+	}
+	return srv, nil
+}
+
+// scan re-reads the library directory
+func (srv *server) scan() error {
+	s := srv.job.Settings
+	re := regexp.MustCompile(wavPattern(s.Pattern))
+	var tagRe *regexp.Regexp
+	if s.Tags && s.Pattern != "" {
+		tagRe = regexp.MustCompile("(?i)" + regexp.QuoteMeta(s.Pattern))
+	}
+
+	found, err := findWavFiles(s.Dir, re, tagRe)
+	if err != nil {
+		return fmt.Errorf("searching for files: %v", err)
+	}
+
+	library := make(map[string]FileInfo, len(found))
+	files := make([]libraryFile, 0, len(found))
+	for _, f := range found {
+		rel, err := filepath.Rel(s.Dir, f.Path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		library[rel] = f
+		files = append(files, libraryFile{
+			File:     rel,
+			Name:     filepath.Base(f.Path),
+			Duration: f.Duration,
+			Rate:     f.SampleRate,
+			Channels: f.Channels,
+			Bits:     f.BitDepth,
+			Size:     f.Size,
+		})
+	}
+
+	srv.mu.Lock()
+	srv.library, srv.files = library, files
+	srv.mu.Unlock()
+	return nil
+}
+
+// lookup returns a library file by its relative path
+func (srv *server) lookup(file string) (FileInfo, bool) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	f, ok := srv.library[file]
+	return f, ok
+}
+
+// handler routes the page and its JSON API
+func (srv *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", srv.handleIndex)
+	mux.HandleFunc("GET /api/library", srv.handleLibrary)
+	mux.HandleFunc("POST /api/library", srv.handleRescan)
+	mux.HandleFunc("GET /api/audio", srv.handleAudio)
+	mux.HandleFunc("POST /api/build", srv.handleBuild)
+	return localOnly(mux)
+}
+
+// localOnly refuses requests that a page from another site could make through
+// the browser. A Host that isn't loopback means a DNS name rebound to this
+// machine, and a POST with a foreign Origin is a cross-site form or fetch.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			http.Error(w, "the kit builder only answers on localhost", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); r.Method != http.MethodGet && origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Host != r.Host {
+				http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether a Host header names this machine
+func isLoopbackHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func (srv *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, serveIndex)
+}
+
+func (srv *server) handleLibrary(w http.ResponseWriter, r *http.Request) {
+	s := srv.job.Settings
+	srv.mu.Lock()
+	resp := libraryResponse{
+		Dir:     s.Dir,
+		Output:  s.Output,
+		Device:  srv.job.Device.Description,
+		Slices:  s.Slices,
+		SliceMs: float64(srv.job.SamplesPerSlice) / float64(s.Rate) * 1000,
+		Files:   srv.files,
+	}
+	srv.mu.Unlock()
+	writeJSON(w, resp)
+}
+
+func (srv *server) handleRescan(w http.ResponseWriter, r *http.Request) {
+	if err := srv.scan(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	srv.handleLibrary(w, r)
+}
+
+// handleAudio serves a library file for the browser to play. Only scanned
+// files are served, so the page cannot read anything else on disk.
+func (srv *server) handleAudio(w http.ResponseWriter, r *http.Request) {
+	f, ok := srv.lookup(r.URL.Query().Get("file"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "audio/wav")
+	http.ServeFile(w, r, f.Path)
+}
+
+func (srv *server) handleBuild(w http.ResponseWriter, r *http.Request) {
+	// A cross-site HTML form can only post form and text types
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "kits must be posted as application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var kit Kit
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxKitRequest))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&kit); err != nil {
+		http.Error(w, fmt.Sprintf("invalid kit: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	slots, err := srv.resolve(&kit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := srv.build(kit.Name, slots)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, buildResponse{Created: created})
+}
+
+// resolve checks a kit posted by the browser and returns its slots. Files are
+// library paths, and the slice count always comes from the server's settings.
+func (srv *server) resolve(kit *Kit) ([]Slot, error) {
+	if kit.Name == "" {
+		kit.Name = "kit"
+	}
+	if err := kit.validate(); err != nil {
+		return nil, err
+	}
+	if slices := srv.job.Settings.Slices; len(kit.Slots) > slices {
+		return nil, fmt.Errorf("kit %s has %d slots but the output has %d slices", kit.Name, len(kit.Slots), slices)
+	}
+
+	slots := make([]Slot, len(kit.Slots))
+	for i, s := range kit.Slots {
+		slots[i] = s.slot()
+		if s.File == "" {
+			continue
+		}
+		f, ok := srv.lookup(s.File)
+		if !ok {
+			return nil, fmt.Errorf("slot %d: %s is not in the library", i+1, s.File)
+		}
+		slots[i].FileInfo = f
+		if err := slots[i].checkTrim(); err != nil {
+			return nil, fmt.Errorf("slot %d (%s): %v", i+1, s.File, err)
+		}
+	}
+	return slots, nil
+}
+
+// build writes a kit with the slice pipeline and returns every file created
+func (srv *server) build(name string, slots []Slot) ([]string, error) {
+	srv.building.Lock()
+	defer srv.building.Unlock()
+
+	job := *srv.job
+	job.Name, job.Slots = name, slots
+	job.checkMonoCompatibility()
+	s := job.Settings
+
+	if err := os.MkdirAll(s.Output, 0755); err != nil {
+		return nil, fmt.Errorf("creating output directory: %v", err)
+	}
+
+	results, err := processFiles(job.Slots, s.Rate, job.NumChannels, s.Slices, job.SamplesPerSlice, job.Name, s.Output, s.Normalize, MixOptions{Mono: s.Mono, Fallback: s.Fallback}, s.outputOptions())
+	var created []string
+	for _, r := range results {
+		created = append(created, r.Path)
+		created = append(created, r.Exports...)
+	}
+	if err != nil {
+		return created, fmt.Errorf("processing files: %v", err)
+	}
+
+	if s.Report {
+		path, err := writeReport(&job, results)
+		if err != nil {
+			return created, fmt.Errorf("writing report: %v", err)
+		}
+		progress.created("report", path, 0)
+		created = append(created, path)
+	}
+	return created, nil
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// runServe starts the browser kit builder on a local address
+func runServe(args []string) error {
+	if err := textOnly("serve", args); err != nil {
+		return err
+	}
+	fs, preset := newSettingsFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Address to serve the kit builder on")
+	fs.Parse(args)
+
+	configs, err := loadConfigs()
+	if err != nil {
+		return fmt.Errorf("loading config: %v", err)
+	}
+	settings, err := resolveSettings(fs, *preset, configs)
+	if err != nil {
+		return err
+	}
+
+	job := &sliceJob{Settings: settings}
+	if err := job.configure(); err != nil {
+		return err
+	}
+	job.printHeader()
+
+	srv, err := newServer(job)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Found %d WAV files in %s\n", len(srv.files), settings.Dir)
+	fmt.Printf("Serving the kit builder at http://%s/ (Ctrl+C to stop)\n", *addr)
+	return http.ListenAndServe(*addr, srv.handler())
+}
+
+// serveIndex is the kit builder page. It has no dependencies, so it works
+// offline on a studio machine.
+const serveIndex = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>wavslice kit builder</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0; color: #222; }
+header { padding: 0.75rem 1.5rem; border-bottom: 1px solid #ddd; }
+header h1 { display: inline; font-size: 1.2rem; margin-right: 1rem; }
+header small { color: #666; }
+main { display: flex; height: calc(100vh - 3.5rem); }
+#library-pane { width: 32%; border-right: 1px solid #ddd; display: flex; flex-direction: column; }
+#kit-pane { flex: 1; overflow: auto; padding: 0 1rem; }
+.bar { display: flex; gap: 0.5rem; padding: 0.5rem; }
+.bar input[type=search], .bar input[type=text] { flex: 1; }
+#library { list-style: none; margin: 0; padding: 0; overflow: auto; flex: 1; }
+#library li { display: flex; gap: 0.5rem; align-items: center; padding: 0.2rem 0.5rem; cursor: grab; border-bottom: 1px solid #f0f0f0; }
+#library li span { flex: 1; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+small { color: #666; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #eee; padding: 0.15rem 0.4rem; text-align: left; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+td input[type=number] { width: 5rem; }
+tr.over td { background: #fff4e5; }
+tr.drop td { background: #e5f0ff; }
+tr[draggable=true] { cursor: grab; }
+td.source { min-width: 12rem; }
+td.empty { color: #aaa; }
+button { cursor: pointer; }
+#log { background: #f6f6f6; padding: 0.5rem; white-space: pre-wrap; min-height: 3rem; }
+</style>
+</head>
+<body>
+<header><h1>wavslice</h1><small id="layout"></small></header>
+<main>
+<section id="library-pane">
+<div class="bar"><input id="filter" type="search" placeholder="Filter library"><button id="rescan">Rescan</button></div>
+<ul id="library"></ul>
+</section>
+<section id="kit-pane">
+<div class="bar"><input id="name" type="text" value="kit" aria-label="Kit name"><button id="build">Build</button><button id="clear">Clear</button></div>
+<p><small>Drag files from the library onto a slot, or double-click to fill the next empty one. Drag slots to swap them.</small></p>
+<table>
+<thead><tr><th>#</th><th>Note</th><th>Source</th><th>Trim ms</th><th>Gain dB</th><th>Reverse</th><th>Truncated</th><th></th></tr></thead>
+<tbody id="slots"></tbody>
+</table>
+<h3>Log</h3>
+<div id="log"></div>
+</section>
+</main>
+<script>
+"use strict";
+const noteNames = ["C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"];
+const fileType = "application/x-wavslice-file";
+const slotType = "application/x-wavslice-slot";
+const $ = id => document.getElementById(id);
+
+let layout = null;
+let slots = [];
+let audio = null;
+let playing = null;
+const buffers = new Map();
+
+const emptySlot = () => ({file: "", trim: 0, gain: 0, reverse: false});
+const noteName = n => noteNames[n % 12] + (Math.floor(n / 12) - 1);
+const libraryFile = file => layout.files.find(f => f.file === file);
+
+function log(msg) {
+  $("log").textContent += msg + "\n";
+}
+
+function el(tag, props, ...children) {
+  const e = Object.assign(document.createElement(tag), props);
+  e.append(...children);
+  return e;
+}
+
+async function loadLibrary(method) {
+  const res = await fetch("/api/library", {method});
+  if (!res.ok) {
+    log("Error: " + await res.text());
+    return;
+  }
+  layout = await res.json();
+  $("layout").textContent = layout.device + " · " + layout.slices + " slices of " + layout.slice_ms.toFixed(1) + " ms · " + layout.files.length + " files in " + layout.dir + " · output to " + layout.output;
+  slots = Array.from({length: layout.slices}, (_, i) => slots[i] && (!slots[i].file || libraryFile(slots[i].file)) ? slots[i] : emptySlot());
+  renderLibrary();
+  renderSlots();
+}
+
+function renderLibrary() {
+  const q = $("filter").value.toLowerCase();
+  const items = layout.files.filter(f => f.file.toLowerCase().includes(q)).map(f => {
+    const li = el("li", {draggable: true, title: f.file + "\n" + f.rate + " Hz, " + f.channels + " ch, " + f.bits + " bit"},
+      el("button", {textContent: "▶", onclick: () => play(f.file, emptySlot(), 0)}),
+      el("span", {textContent: f.name}),
+      el("small", {textContent: f.duration.toFixed(2) + " s"}));
+    li.addEventListener("dragstart", e => e.dataTransfer.setData(fileType, f.file));
+    li.addEventListener("dblclick", () => {
+      const i = slots.findIndex(s => !s.file);
+      if (i >= 0) {
+        slots[i] = {...emptySlot(), file: f.file};
+        renderSlots();
+      }
+    });
+    return li;
+  });
+  $("library").replaceChildren(...items);
+}
+
+// truncation estimates the seconds of a slot that will not fit, as 'wavslice plan' does
+function truncation(slot) {
+  const f = slot.file && libraryFile(slot.file);
+  return f ? Math.max(f.duration - slot.trim / 1000 - layout.slice_ms / 1000, 0) : 0;
+}
+
+function renderSlots() {
+  const rows = slots.map((slot, i) => {
+    const f = slot.file && libraryFile(slot.file);
+    const over = el("td", {className: "num"});
+    const row = el("tr", {draggable: !!f});
+    const update = () => {
+      const t = truncation(slot);
+      over.textContent = t > 0 ? (t * 1000).toFixed(0) + " ms" : "";
+      row.classList.toggle("over", t > 0);
+    };
+    const number = (key, min) => el("input", {type: "number", step: "any", min, value: slot[key], oninput: e => {
+      slot[key] = Math.max(Number(e.target.value) || 0, min);
+      update();
+    }});
+
+    row.append(
+      el("td", {className: "num", textContent: i + 1}),
+      el("td", {textContent: noteName(60 + i)}),
+      f ? el("td", {className: "source", textContent: f.name, title: f.file}) : el("td", {className: "source empty", textContent: "drop a file here"}),
+      el("td", {}, number("trim", 0)),
+      el("td", {}, number("gain", -60)),
+      el("td", {}, el("input", {type: "checkbox", checked: slot.reverse, onchange: e => { slot.reverse = e.target.checked; }})),
+      over,
+      el("td", {},
+        el("button", {textContent: "▶", disabled: !f, onclick: () => play(slot.file, slot, layout.slice_ms / 1000)}),
+        el("button", {textContent: "✕", disabled: !f, onclick: () => { slots[i] = emptySlot(); renderSlots(); }})));
+    update();
+
+    row.addEventListener("dragstart", e => e.dataTransfer.setData(slotType, String(i)));
+    row.addEventListener("dragover", e => {
+      if (e.dataTransfer.types.includes(fileType) || e.dataTransfer.types.includes(slotType)) {
+        e.preventDefault();
+        row.classList.add("drop");
+      }
+    });
+    row.addEventListener("dragleave", () => row.classList.remove("drop"));
+    row.addEventListener("drop", e => {
+      e.preventDefault();
+      const file = e.dataTransfer.getData(fileType);
+      const from = e.dataTransfer.getData(slotType);
+      if (file) {
+        slots[i] = {...slots[i], file};
+      } else if (from !== "") {
+        [slots[i], slots[Number(from)]] = [slots[Number(from)], slots[i]];
+      }
+      renderSlots();
+    });
+    return row;
+  });
+  $("slots").replaceChildren(...rows);
+}
+
+// play previews a source with a slot's trim, reverse and gain, cut to limit
+// seconds unless limit is 0. Silence removal is not previewed.
+async function play(file, slot, limit) {
+  if (playing) {
+    playing.stop();
+    playing = null;
+  }
+  audio ??= new AudioContext();
+  let buf = buffers.get(file);
+  if (!buf) {
+    try {
+      const res = await fetch("/api/audio?file=" + encodeURIComponent(file));
+      buf = await audio.decodeAudioData(await res.arrayBuffer());
+    } catch (err) {
+      log("Cannot play " + file + ": " + err);
+      return;
+    }
+    buffers.set(file, buf);
+  }
+
+  const start = Math.min(Math.round(slot.trim / 1000 * buf.sampleRate), buf.length);
+  const end = limit ? Math.min(start + Math.round(limit * buf.sampleRate), buf.length) : buf.length;
+  if (end <= start) {
+    return;
+  }
+  const part = audio.createBuffer(buf.numberOfChannels, end - start, buf.sampleRate);
+  for (let ch = 0; ch < buf.numberOfChannels; ch++) {
+    const data = buf.getChannelData(ch).slice(start, end);
+    if (slot.reverse) {
+      data.reverse();
+    }
+    part.copyToChannel(data, ch);
+  }
+
+  const src = audio.createBufferSource();
+  const gain = audio.createGain();
+  src.buffer = part;
+  gain.gain.value = Math.pow(10, slot.gain / 20);
+  src.connect(gain).connect(audio.destination);
+  src.start();
+  playing = src;
+}
+
+async function build() {
+  let last = slots.length - 1;
+  while (last >= 0 && !slots[last].file) {
+    last--;
+  }
+  if (last < 0) {
+    log("Add at least one file before building.");
+    return;
+  }
+
+  const kit = {name: $("name").value.trim(), slots: slots.slice(0, last + 1)};
+  $("build").disabled = true;
+  log("Building " + (kit.name || "kit") + "...");
+  try {
+    const res = await fetch("/api/build", {method: "POST", headers: {"Content-Type": "application/json"}, body: JSON.stringify(kit)});
+    if (!res.ok) {
+      log("Error: " + await res.text());
+      return;
+    }
+    for (const path of (await res.json()).created) {
+      log("Created: " + path);
+    }
+  } finally {
+    $("build").disabled = false;
+  }
+}
+
+$("filter").addEventListener("input", renderLibrary);
+$("rescan").addEventListener("click", () => loadLibrary("POST"));
+$("build").addEventListener("click", build);
+$("clear").addEventListener("click", () => {
+  slots = slots.map(emptySlot);
+  renderSlots();
+});
+loadLibrary("GET");
+</script>
+</body>
+</html>
+`
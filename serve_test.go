@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// Kit builder server tests
+// ============================================================================
+
+// newTestServer serves a library with kicks/kick.wav and snare.wav
+func newTestServer(t *testing.T) (*server, *httptest.Server) {
+	t.Helper()
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "kicks"), 0755)
+	writeWavFile(filepath.Join(dir, "kicks", "kick.wav"), [][]float64{make([]float64, 100)}, 44100, 1)
+	writeWavFile(filepath.Join(dir, "snare.wav"), [][]float64{make([]float64, 100)}, 44100, 1)
+
+	s := defaultSettings()
+	s.Dir = dir
+	s.Output = filepath.Join(dir, "out")
+	s.Slices = 4
+	job := &sliceJob{Settings: s}
+	if err := job.configure(); err != nil {
+		t.Fatalf("configure failed: %v", err)
+	}
+
+	srv, err := newServer(job)
+	if err != nil {
+		t.Fatalf("newServer failed: %v", err)
+	}
+	ts := httptest.NewServer(srv.handler())
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+func TestServeLibrary(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	res, err := http.Get(ts.URL + "/api/library")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	var lib libraryResponse
+	if err := json.NewDecoder(res.Body).Decode(&lib); err != nil {
+		t.Fatalf("invalid library response: %v", err)
+	}
+	if lib.Slices != 4 || len(lib.Files) != 2 {
+		t.Fatalf("expected 4 slices and 2 files, got %d and %d", lib.Slices, len(lib.Files))
+	}
+	if lib.Files[0].File != "kicks/kick.wav" || lib.Files[1].File != "snare.wav" {
+		t.Errorf("unexpected library paths %+v", lib.Files)
+	}
+}
+
+func TestServeAudio(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	tests := []struct {
+		file   string
+		status int
+	}{
+		{"kicks/kick.wav", http.StatusOK},
+		{"../kick.wav", http.StatusNotFound},
+		{"/etc/passwd", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(ts.URL + "/api/audio?file=" + tt.file)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.file, tt.status, res.StatusCode)
+		}
+	}
+}
+
+func TestServeBuild(t *testing.T) {
+	srv, ts := newTestServer(t)
+	post := func(body string) *http.Response {
+		t.Helper()
+		res, err := http.Post(ts.URL+"/api/build", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		return res
+	}
+
+	t.Run("writes the kit", func(t *testing.T) {
+		res := post(`{"name": "house", "slots": [{"file": "snare.wav", "gain": -3}, {}, {"file": "kicks/kick.wav", "reverse": true}]}`)
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected OK, got %s", res.Status)
+		}
+
+		var built buildResponse
+		if err := json.NewDecoder(res.Body).Decode(&built); err != nil {
+			t.Fatalf("invalid build response: %v", err)
+		}
+		want := filepath.Join(srv.job.Settings.Output, "house_4slices_batch001.wav")
+		if len(built.Created) != 1 || built.Created[0] != want {
+			t.Fatalf("expected %s, got %v", want, built.Created)
+		}
+		info, err := readWavInfo(want)
+		if err != nil {
+			t.Fatalf("expected output: %v", err)
+		}
+		if info.NumSamples != 3*srv.job.SamplesPerSlice {
+			t.Errorf("expected 3 slices of audio, got %d frames", info.NumSamples)
+		}
+	})
+
+	t.Run("rejects other content types", func(t *testing.T) {
+		res, err := http.Post(ts.URL+"/api/build", "text/plain", strings.NewReader(`{"slots": [{"file": "snare.wav"}]}`))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnsupportedMediaType {
+			t.Errorf("expected unsupported media type, got %s", res.Status)
+		}
+	})
+
+	t.Run("rejects bad kits", func(t *testing.T) {
+		for _, body := range []string{
+			`{"slots": [{"file": "../outside.wav"}]}`,
+			`{"slots": [{"file": "snare.wav", "trim": -1}]}`,
+			`{"slots": [{}, {}, {}, {}, {"file": "snare.wav"}]}`,
+			`{"slots": []}`,
+			`{"slots": [{"path": "snare.wav"}]}`,
+		} {
+			res := post(body)
+			res.Body.Close()
+			if res.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s: expected bad request, got %s", body, res.Status)
+			}
+		}
+	})
+}
+
+func TestServeLocalOnly(t *testing.T) {
+	_, ts := newTestServer(t)
+	port := strings.TrimPrefix(ts.URL, "http://127.0.0.1")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		host   string
+		origin string
+		status int
+	}{
+		{"loopback address", "GET", "/api/library", "", "", http.StatusOK},
+		{"localhost", "GET", "/api/library", "localhost" + port, "", http.StatusOK},
+		{"IPv6 loopback", "GET", "/api/library", "[::1]" + port, "", http.StatusOK},
+		{"rebound name", "GET", "/api/library", "evil.example" + port, "", http.StatusForbidden},
+		{"rebound build", "POST", "/api/build", "evil.example" + port, "", http.StatusForbidden},
+		{"same origin", "POST", "/api/library", "", ts.URL, http.StatusOK},
+		{"foreign origin", "POST", "/api/build", "", "http://evil.example", http.StatusForbidden},
+		{"foreign origin rescan", "POST", "/api/library", "", "http://localhost:9999", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(`{"slots": [{"file": "snare.wav"}]}`))
+			if err != nil {
+				t.Fatalf("NewRequest failed: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.status {
+				t.Errorf("expected %d, got %s", tt.status, res.Status)
+			}
+		})
+	}
+}
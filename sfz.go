@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SFZMode selects whether and how an .sfz instrument is written for each batch
+type SFZMode string
+
+const (
+	SFZNone    SFZMode = "none"
+	SFZOffsets SFZMode = "offsets" // regions play ranges of the combined WAV
+	SFZFiles   SFZMode = "files"   // each slice is also written as its own WAV
+)
+
+// sfzModes lists the accepted modes in help order
+var sfzModes = []SFZMode{SFZNone, SFZOffsets, SFZFiles}
+
+// parseSFZMode validates an SFZ mode name
+func parseSFZMode(s string) (SFZMode, error) {
+	for _, m := range sfzModes {
+		if string(m) == strings.ToLower(s) {
+			return m, nil
+		}
+	}
+	names := make([]string, len(sfzModes))
+	for i, m := range sfzModes {
+		names[i] = string(m)
+	}
+	return "", fmt.Errorf("unknown sfz mode %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// SFZOptions controls the SFZ export. Consecutive slices are grouped onto one
+// key: Layers velocity layers of RoundRobin alternating samples each.
+type SFZOptions struct {
+	Mode       SFZMode
+	Layers     int
+	RoundRobin int
+}
+
+// enabled reports whether an .sfz should be written
+func (o SFZOptions) enabled() bool {
+	return o.Mode != "" && o.Mode != SFZNone
+}
+
+// slicesPerKey returns how many consecutive slices share a key
+func (o SFZOptions) slicesPerKey() int {
+	return max(o.Layers, 1) * max(o.RoundRobin, 1)
+}
+
+// validate checks the grouping fits the MIDI velocity range
+func (o SFZOptions) validate() error {
+	if o.Layers < 1 || o.Layers > 127 {
+		return errors.New("-sfz-layers must be between 1 and 127")
+	}
+	if o.RoundRobin < 1 {
+		return errors.New("-sfz-round-robin must be at least 1")
+	}
+	return nil
+}
+
+// sfzPath returns the instrument path for a combined WAV
+func sfzPath(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".sfz"
+}
+
+// velocityRange returns the velocity range of layer l out of n, splitting 1-127 evenly
+func velocityRange(l, n int) (int, int) {
+	return l*127/n + 1, (l + 1) * 127 / n
+}
+
+// encodeSFZ builds an instrument mapping the slices of a batch to consecutive
+// keys from FirstSliceNote, as the P-6 Chop mode does. sliceFiles names a WAV
+// for each slice; when nil, regions play ranges of the combined file.
+func encodeSFZ(layout BatchLayout, sliceFiles []string, opts SFZOptions) string {
+	layers, rr := max(opts.Layers, 1), max(opts.RoundRobin, 1)
+	perKey := opts.slicesPerKey()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n", strings.TrimSuffix(filepath.Base(layout.Path), ".wav"))
+	fmt.Fprintf(&b, "// %d slices, written by wavslice %s\n", len(layout.Slots), Version)
+	if perKey > 1 {
+		fmt.Fprintf(&b, "// %d velocity layer(s) of %d round-robin sample(s) per key\n", layers, rr)
+	}
+
+	for key := 0; key*perKey < len(layout.Slots); key++ {
+		note := FirstSliceNote + key
+		fmt.Fprintf(&b, "\n<group> key=%d", note)
+		if rr > 1 {
+			fmt.Fprintf(&b, " seq_length=%d", rr)
+		}
+		b.WriteString("\n")
+
+		for j := 0; j < perKey && key*perKey+j < len(layout.Slots); j++ {
+			i := key*perKey + j
+			slot := layout.Slots[i]
+			source := "(empty)"
+			if slot.Path != "" {
+				source = filepath.Base(slot.Path)
+			}
+			fmt.Fprintf(&b, "// %02d %s %s\n", i+1, noteName(note), source)
+
+			b.WriteString("<region>")
+			sample := filepath.Base(layout.Path)
+			if sliceFiles == nil {
+				start, end := layout.sliceBounds(i)
+				fmt.Fprintf(&b, " offset=%d end=%d", start, end-1)
+			} else {
+				sample = sliceFiles[i]
+			}
+			if layers > 1 {
+				lo, hi := velocityRange(j/rr, layers)
+				fmt.Fprintf(&b, " lovel=%d hivel=%d", lo, hi)
+			}
+			if rr > 1 {
+				fmt.Fprintf(&b, " seq_position=%d", j%rr+1)
+			}
+			// sample runs to the end of the line, so it goes last to allow spaces
+			fmt.Fprintf(&b, " sample=%s\n", sample)
+		}
+	}
+	return b.String()
+}
+
+// writeSFZFile writes the .sfz for a batch, plus one WAV per slice in files mode
+func writeSFZFile(layout BatchLayout, opts SFZOptions) ([]string, error) {
+	var created, sliceFiles []string
+
+	if opts.Mode == SFZFiles {
+		// Split the written file so slice files carry any normalization
+		paths, err := splitFile(layout.Path, filepath.Dir(layout.Path), len(layout.Slots), false, false)
+		created = append(created, paths...)
+		if err != nil {
+			return created, err
+		}
+		for _, p := range paths {
+			sliceFiles = append(sliceFiles, filepath.Base(p))
+		}
+	}
+
+	path := sfzPath(layout.Path)
+	if err := os.WriteFile(path, []byte(encodeSFZ(layout, sliceFiles, opts)), 0644); err != nil {
+		return created, err
+	}
+	return append(created, path), nil
+}
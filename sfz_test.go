@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// SFZ export tests
+// ============================================================================
+
+func TestParseSFZMode(t *testing.T) {
+	if m, err := parseSFZMode("Files"); err != nil || m != SFZFiles {
+		t.Errorf("expected files, got %q (%v)", m, err)
+	}
+	if _, err := parseSFZMode("kontakt"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestVelocityRange(t *testing.T) {
+	tests := []struct{ layer, layers, lo, hi int }{
+		{0, 1, 1, 127},
+		{0, 2, 1, 63},
+		{1, 2, 64, 127},
+		{2, 3, 85, 127},
+	}
+	for _, tc := range tests {
+		if lo, hi := velocityRange(tc.layer, tc.layers); lo != tc.lo || hi != tc.hi {
+			t.Errorf("velocityRange(%d, %d) = %d-%d, expected %d-%d", tc.layer, tc.layers, lo, hi, tc.lo, tc.hi)
+		}
+	}
+}
+
+func TestEncodeSFZ(t *testing.T) {
+	slots := []Slot{{FileInfo: FileInfo{Path: "/s/kick.wav"}}, {}, {FileInfo: FileInfo{Path: "/s/snare.wav"}}, {}}
+	layout := BatchLayout{Path: "/out/kit_4slices_batch001.wav", SampleRate: 44100, Channels: 1, SliceLength: 100, Slots: slots}
+
+	t.Run("offsets", func(t *testing.T) {
+		sfz := encodeSFZ(layout, nil, SFZOptions{Mode: SFZOffsets, Layers: 1, RoundRobin: 1})
+		for _, want := range []string{
+			"<group> key=60\n// 01 C4 kick.wav\n<region> offset=0 end=99 sample=kit_4slices_batch001.wav\n",
+			"<group> key=63\n// 04 D#4 (empty)\n<region> offset=300 end=399 sample=kit_4slices_batch001.wav\n",
+		} {
+			if !strings.Contains(sfz, want) {
+				t.Errorf("expected %q in:\n%s", want, sfz)
+			}
+		}
+	})
+
+	t.Run("slice files", func(t *testing.T) {
+		files := []string{"a.wav", "b.wav", "c.wav", "d.wav"}
+		sfz := encodeSFZ(layout, files, SFZOptions{Mode: SFZFiles, Layers: 1, RoundRobin: 1})
+		if !strings.Contains(sfz, "<region> sample=c.wav\n") || strings.Contains(sfz, "offset=") {
+			t.Errorf("expected per-slice samples without offsets:\n%s", sfz)
+		}
+	})
+
+	t.Run("file name with spaces", func(t *testing.T) {
+		spaced := layout
+		spaced.Path = "/out/my kit_4slices_batch001.wav"
+		sfz := encodeSFZ(spaced, nil, SFZOptions{Mode: SFZOffsets, Layers: 2, RoundRobin: 1})
+		want := "<region> offset=100 end=199 lovel=64 hivel=127 sample=my kit_4slices_batch001.wav\n"
+		if !strings.Contains(sfz, want) {
+			t.Errorf("expected %q in:\n%s", want, sfz)
+		}
+
+		// Every opcode before sample= must still parse as key=value
+		for _, line := range strings.Split(sfz, "\n") {
+			if !strings.HasPrefix(line, "<region>") {
+				continue
+			}
+			opcodes, sample, ok := strings.Cut(line, " sample=")
+			if !ok || sample != "my kit_4slices_batch001.wav" {
+				t.Errorf("expected sample= last with the whole name in %q", line)
+			}
+			for _, op := range strings.Fields(strings.TrimPrefix(opcodes, "<region>")) {
+				if !strings.Contains(op, "=") {
+					t.Errorf("opcode %q in %q is not key=value", op, line)
+				}
+			}
+		}
+	})
+
+	t.Run("velocity layers and round robin", func(t *testing.T) {
+		sfz := encodeSFZ(layout, nil, SFZOptions{Mode: SFZOffsets, Layers: 2, RoundRobin: 2})
+		if strings.Count(sfz, "<group>") != 1 || !strings.Contains(sfz, "<group> key=60 seq_length=2\n") {
+			t.Errorf("expected all four slices on one key:\n%s", sfz)
+		}
+		if !strings.Contains(sfz, "offset=100 end=199 lovel=1 hivel=63 seq_position=2 sample=kit_4slices_batch001.wav\n") ||
+			!strings.Contains(sfz, "offset=200 end=299 lovel=64 hivel=127 seq_position=1 sample=kit_4slices_batch001.wav\n") {
+			t.Errorf("unexpected layer assignment:\n%s", sfz)
+		}
+	})
+}
+
+func TestWriteSFZFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kit_2slices_batch001.wav")
+	writeWavFile(path, [][]float64{make([]float64, 20)}, 44100, 1)
+	layout := BatchLayout{Path: path, SampleRate: 44100, Channels: 1, SliceLength: 10, Slots: make([]Slot, 2)}
+
+	created, err := writeSFZFile(layout, SFZOptions{Mode: SFZFiles, Layers: 1, RoundRobin: 1})
+	if err != nil {
+		t.Fatalf("writeSFZFile failed: %v", err)
+	}
+	if len(created) != 3 {
+		t.Fatalf("expected 2 slice files and the .sfz, got %v", created)
+	}
+
+	sfz, err := os.ReadFile(filepath.Join(dir, "kit_2slices_batch001.sfz"))
+	if err != nil {
+		t.Fatalf("expected .sfz file: %v", err)
+	}
+	if !strings.Contains(string(sfz), "sample=kit_2slices_batch001_slice02.wav") {
+		t.Errorf("expected slice file reference:\n%s", sfz)
+	}
+	wav, err := readWavFile(filepath.Join(dir, "kit_2slices_batch001_slice02.wav"))
+	if err != nil || wav.NumSamples != 10 {
+		t.Errorf("expected 10-frame slice file, got %v", err)
+	}
+}
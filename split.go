@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sliceCountInName matches the slice count in names written by processFiles
+var sliceCountInName = regexp.MustCompile(`_(\d+)slices_`)
+
+// runSplit divides a combined file into slices at cue points or equal intervals
+func runSplit(args []string) error {
+	if err := textOnly("split", args); err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	sliceCount := fs.Int("slices", 0, "Number of equal slices (default: from the file name, else 32)")
+	useCues := fs.Bool("cues", true, "Split at embedded cue points when present, ignoring -slices")
+	trimTail := fs.Bool("trim", false, "Remove trailing silence from each slice")
+	outputDir := fs.String("output", ".", "Output directory for slice files")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one input file")
+	}
+	input := fs.Arg(0)
+
+	if *sliceCount == 0 {
+		*sliceCount = 32
+		if m := sliceCountInName.FindStringSubmatch(filepath.Base(input)); m != nil {
+			*sliceCount, _ = strconv.Atoi(m[1])
+		}
+	}
+	if *sliceCount < 1 {
+		return errors.New("-slices must be at least 1")
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %v", err)
+	}
+
+	paths, err := splitFile(input, *outputDir, *sliceCount, *useCues, *trimTail)
+	for _, p := range paths {
+		fmt.Printf("Created: %s\n", p)
+	}
+	return err
+}
+
+// splitFile writes each slice of input as a numbered WAV. Slices come from the
+// file's cue points when useCues is set and any exist, otherwise sliceCount equal parts.
+func splitFile(input, outputDir string, sliceCount int, useCues, trimTail bool) ([]string, error) {
+	wav, err := readWavFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", input, err)
+	}
+
+	var cues []int
+	if useCues {
+		cues, err = readCuePointsFile(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cue points from %s: %v", input, err)
+		}
+	}
+
+	var bounds []int
+	if len(cues) > 0 {
+		bounds = cueBoundaries(cues, wav.NumSamples)
+		fmt.Printf("Splitting at %d cue points\n", len(cues))
+	} else {
+		if wav.NumSamples/sliceCount == 0 {
+			return nil, fmt.Errorf("%s has %d frames, too short for %d slices", input, wav.NumSamples, sliceCount)
+		}
+		bounds = equalBoundaries(wav.NumSamples, sliceCount)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	rate := int(wav.Header.SampleRate)
+	channels := int(wav.Header.NumChannels)
+
+	var paths []string
+	for i := 0; i+1 < len(bounds); i++ {
+		slice := make([][]float64, channels)
+		for ch := range slice {
+			slice[ch] = wav.Samples[ch][bounds[i]:bounds[i+1]]
+		}
+		if trimTail {
+			slice = removeTrailingSilence(slice)
+		}
+
+		path := filepath.Join(outputDir, fmt.Sprintf("%s_slice%02d.wav", base, i+1))
+		if err := writeWavFile(path, slice, rate, channels); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// equalBoundaries returns sliceCount+1 frame offsets dividing numSamples evenly.
+// Leftover frames that don't fill a whole slice are dropped, matching how the P-6 chops.
+func equalBoundaries(numSamples, sliceCount int) []int {
+	sliceLen := numSamples / sliceCount
+	bounds := make([]int, sliceCount+1)
+	for i := range bounds {
+		bounds[i] = i * sliceLen
+	}
+	return bounds
+}
+
+// cueBoundaries turns cue positions into sorted, de-duplicated slice boundaries
+// covering the whole file
+func cueBoundaries(cues []int, numSamples int) []int {
+	bounds := []int{0, numSamples}
+	for _, c := range cues {
+		if c > 0 && c < numSamples {
+			bounds = append(bounds, c)
+		}
+	}
+	sort.Ints(bounds)
+
+	unique := bounds[:1]
+	for _, b := range bounds[1:] {
+		if b != unique[len(unique)-1] {
+			unique = append(unique, b)
+		}
+	}
+	return unique
+}
+
+// readCuePointsFile returns the sample frame offsets of every cue point in a WAV file
+func readCuePointsFile(path string) ([]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := listChunks(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.ID != "cue " {
+			continue
+		}
+		// Checked before allocating, so a corrupt size can't claim gigabytes
+		if c.Offset+8+c.Size > stat.Size() {
+			return nil, fmt.Errorf("cue chunk at offset %d extends past end of file", c.Offset)
+		}
+		if c.Size > maxDecodedChunkSize {
+			return nil, fmt.Errorf("cue chunk of %d bytes is too large", c.Size)
+		}
+		if _, err := f.Seek(c.Offset+8, io.SeekStart); err != nil {
+			return nil, err
+		}
+		data := make([]byte, c.Size)
+		if _, err := io.ReadFull(f, data); err != nil {
+			return nil, err
+		}
+		return parseCuePoints(data)
+	}
+	return nil, nil
+}
+
+// parseCuePoints decodes the body of a "cue " chunk. Each 24-byte cue point is
+// ID, position, data chunk ID, chunk start, block start and sample offset; the
+// sample offset is the frame position within the data chunk.
+func parseCuePoints(data []byte) ([]int, error) {
+	if len(data) < 4 {
+		return nil, errors.New("cue chunk too short")
+	}
+	count := int(binary.LittleEndian.Uint32(data[0:4]))
+	if count > (len(data)-4)/24 {
+		return nil, fmt.Errorf("cue chunk declares %d points but holds %d", count, (len(data)-4)/24)
+	}
+
+	cues := make([]int, count)
+	for i := range cues {
+		p := data[4+i*24:]
+		cues[i] = int(binary.LittleEndian.Uint32(p[20:24]))
+	}
+	return cues, nil
+}
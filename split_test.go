@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// Chunk helper functions
+// ============================================================================
+
+// appendChunk adds a chunk (with pad byte if needed) to the end of a WAV file
+// and updates the RIFF size
+func appendChunk(t *testing.T, path, id string, body []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	data = append(data, []byte(id)...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(body)))
+	data = append(data, body...)
+	if len(body)%2 == 1 {
+		data = append(data, 0)
+	}
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
+
+// cueChunkBody builds a "cue " chunk body with one point per sample offset
+func cueChunkBody(offsets ...int) []byte {
+	body := binary.LittleEndian.AppendUint32(nil, uint32(len(offsets)))
+	for i, off := range offsets {
+		body = binary.LittleEndian.AppendUint32(body, uint32(i+1)) // ID
+		body = binary.LittleEndian.AppendUint32(body, uint32(off)) // position
+		body = append(body, []byte("data")...)                     // chunk ID
+		body = binary.LittleEndian.AppendUint32(body, 0)           // chunk start
+		body = binary.LittleEndian.AppendUint32(body, 0)           // block start
+		body = binary.LittleEndian.AppendUint32(body, uint32(off)) // sample offset
+	}
+	return body
+}
+
+// ============================================================================
+// Boundary tests
+// ============================================================================
+
+func TestEqualBoundaries(t *testing.T) {
+	bounds := equalBoundaries(42, 4)
+	expected := []int{0, 10, 20, 30, 40}
+	if len(bounds) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, bounds)
+	}
+	for i := range expected {
+		if bounds[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, bounds)
+			break
+		}
+	}
+}
+
+func TestCueBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		cues     []int
+		expected []int
+	}{
+		{"sorted with start", []int{0, 10, 25}, []int{0, 10, 25, 40}},
+		{"unsorted duplicates", []int{25, 10, 10}, []int{0, 10, 25, 40}},
+		{"out of range dropped", []int{10, 40, 100}, []int{0, 10, 40}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bounds := cueBoundaries(tc.cues, 40)
+			if len(bounds) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, bounds)
+			}
+			for i := range tc.expected {
+				if bounds[i] != tc.expected[i] {
+					t.Errorf("expected %v, got %v", tc.expected, bounds)
+					break
+				}
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Cue chunk tests
+// ============================================================================
+
+func TestParseCuePoints(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cues, err := parseCuePoints(cueChunkBody(0, 100, 250))
+		if err != nil {
+			t.Fatalf("parseCuePoints failed: %v", err)
+		}
+		if len(cues) != 3 || cues[1] != 100 || cues[2] != 250 {
+			t.Errorf("unexpected cues %v", cues)
+		}
+	})
+
+	t.Run("count exceeds data", func(t *testing.T) {
+		body := cueChunkBody(0, 100)
+		binary.LittleEndian.PutUint32(body[0:4], 5)
+		if _, err := parseCuePoints(body); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		if _, err := parseCuePoints([]byte{1}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestReadCuePointsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cues.wav")
+	writeWavFile(path, [][]float64{make([]float64, 30)}, 44100, 1)
+
+	cues, err := readCuePointsFile(path)
+	if err != nil || cues != nil {
+		t.Errorf("expected no cues, got %v, %v", cues, err)
+	}
+
+	appendChunk(t, path, "LIST", []byte("odd"))
+	appendChunk(t, path, "cue ", cueChunkBody(0, 12))
+
+	cues, err = readCuePointsFile(path)
+	if err != nil {
+		t.Fatalf("readCuePointsFile failed: %v", err)
+	}
+	if len(cues) != 2 || cues[1] != 12 {
+		t.Errorf("unexpected cues %v", cues)
+	}
+
+	t.Run("oversized cue chunks", func(t *testing.T) {
+		past := filepath.Join(dir, "past.wav")
+		writeWavFile(past, [][]float64{make([]float64, 30)}, 44100, 1)
+		appendChunk(t, past, "cue ", cueChunkBody(0, 12))
+		data, _ := os.ReadFile(past)
+		binary.LittleEndian.PutUint32(data[len(data)-len(cueChunkBody(0, 12))-4:], 0xF0000000)
+		os.WriteFile(past, data, 0644)
+
+		huge := filepath.Join(dir, "huge.wav")
+		writeWavFile(huge, [][]float64{make([]float64, 30)}, 44100, 1)
+		appendChunk(t, huge, "cue ", make([]byte, maxDecodedChunkSize+24))
+
+		for path, want := range map[string]string{past: "past end of file", huge: "too large"} {
+			if _, err := readCuePointsFile(path); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: expected %q error, got %v", filepath.Base(path), want, err)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// splitFile tests
+// ============================================================================
+
+func TestSplitFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "kit.wav")
+
+	samples := make([]float64, 40)
+	for i := range samples {
+		samples[i] = float64(i/10+1) * 0.1
+	}
+	writeWavFile(input, [][]float64{samples}, 44100, 1)
+
+	outDir := filepath.Join(dir, "slices")
+	os.MkdirAll(outDir, 0755)
+
+	t.Run("equal slices", func(t *testing.T) {
+		paths, err := splitFile(input, outDir, 4, true, false)
+		if err != nil {
+			t.Fatalf("splitFile failed: %v", err)
+		}
+		if len(paths) != 4 {
+			t.Fatalf("expected 4 slice files, got %d", len(paths))
+		}
+		if filepath.Base(paths[0]) != "kit_slice01.wav" {
+			t.Errorf("unexpected file name %s", paths[0])
+		}
+
+		wav, err := readWavFile(paths[2])
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.NumSamples != 10 {
+			t.Errorf("expected 10 samples per slice, got %d", wav.NumSamples)
+		}
+		if wav.Samples[0][0] < 0.29 || wav.Samples[0][0] > 0.31 {
+			t.Errorf("expected third slice to hold 0.3, got %f", wav.Samples[0][0])
+		}
+	})
+
+	t.Run("too many slices", func(t *testing.T) {
+		if _, err := splitFile(input, outDir, 64, true, false); err == nil {
+			t.Error("expected error for slices longer than file")
+		}
+	})
+
+	t.Run("cue points", func(t *testing.T) {
+		cued := filepath.Join(dir, "cued.wav")
+		writeWavFile(cued, [][]float64{samples}, 44100, 1)
+		appendChunk(t, cued, "cue ", cueChunkBody(0, 5, 30))
+
+		paths, err := splitFile(cued, outDir, 4, true, false)
+		if err != nil {
+			t.Fatalf("splitFile failed: %v", err)
+		}
+		if len(paths) != 3 {
+			t.Fatalf("expected 3 slices from cue points, got %d", len(paths))
+		}
+		wav, _ := readWavFile(paths[1])
+		if wav.NumSamples != 25 {
+			t.Errorf("expected 25 samples between cues, got %d", wav.NumSamples)
+		}
+
+		paths, err = splitFile(cued, outDir, 4, false, false)
+		if err != nil {
+			t.Fatalf("splitFile failed: %v", err)
+		}
+		if len(paths) != 4 {
+			t.Errorf("expected cues to be ignored, got %d slices", len(paths))
+		}
+	})
+
+	t.Run("trim trailing silence", func(t *testing.T) {
+		padded := filepath.Join(dir, "padded.wav")
+		writeWavFile(padded, [][]float64{{0.5, 0.5, 0, 0, 0.5, 0, 0, 0}}, 44100, 1)
+
+		paths, err := splitFile(padded, outDir, 2, true, true)
+		if err != nil {
+			t.Fatalf("splitFile failed: %v", err)
+		}
+		first, _ := readWavFile(paths[0])
+		second, _ := readWavFile(paths[1])
+		if first.NumSamples != 2 || second.NumSamples != 1 {
+			t.Errorf("expected 2 and 1 samples after trim, got %d and %d", first.NumSamples, second.NumSamples)
+		}
+	})
+}
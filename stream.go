@@ -0,0 +1,433 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// silenceBlockFrames is how many frames are decoded at a time while skipping leading silence
+const silenceBlockFrames = 4096
+
+// wavStream decodes sample frames incrementally from an open WAV, RF64 or Wave64
+// file, so large recordings can be read without loading all of their data.
+type wavStream struct {
+	f        *os.File
+	Header   WavHeader
+	DataSize int64
+	FileSize int64
+	Frames   int64 // frames declared by the header
+
+	dataStart int64
+	pos       int64 // frames consumed so far
+	dec       frameDecoder
+	pending   [][]float64 // decoded frames left over from a partly consumed block
+}
+
+// frameDecoder turns blocks of encoded data into frames. Uncompressed and
+// companded formats have one frame per block; ADPCM blocks hold many.
+type frameDecoder struct {
+	blockSize   int                                             // encoded bytes per block
+	blockFrames func(size int) int                              // frames in a block of size bytes
+	decode      func(block []byte, out [][]float64) [][]float64 // appends a block's frames to out
+}
+
+// capped limits each block to limit frames, for ADPCM encoders whose fmt
+// extension declares fewer samples per block than the block size could hold
+func (d frameDecoder) capped(limit int) frameDecoder {
+	if limit <= 0 || limit >= d.blockFrames(d.blockSize) {
+		return d
+	}
+	blockFrames, decode := d.blockFrames, d.decode
+	d.blockFrames = func(size int) int { return min(blockFrames(size), limit) }
+	d.decode = func(block []byte, out [][]float64) [][]float64 {
+		starts := make([]int, len(out))
+		for ch := range out {
+			starts[ch] = len(out[ch])
+		}
+		out = decode(block, out)
+		for ch := range out {
+			out[ch] = out[ch][:min(len(out[ch]), starts[ch]+limit)]
+		}
+		return out
+	}
+	return d
+}
+
+// frameCount returns the frames in dataSize bytes, capped by the fact chunk
+// when a compressed format's last block is only partly used
+func (d frameDecoder) frameCount(header WavHeader, dataSize int64) int64 {
+	frames := dataSize/int64(d.blockSize)*int64(d.blockFrames(d.blockSize)) + int64(d.blockFrames(int(dataSize%int64(d.blockSize))))
+	if header.FactFrames > 0 && d.blockFrames(d.blockSize) > 1 {
+		frames = min(frames, int64(header.FactFrames))
+	}
+	return frames
+}
+
+// openWavStream opens a file, parses its header and validates the sample format.
+// The stream is positioned at the first frame.
+func openWavStream(path string) (*wavStream, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newWavStream(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func newWavStream(f *os.File) (*wavStream, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	fileSize := stat.Size()
+
+	header, dataSize, err := readWavHeader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.BlockAlign == 0 {
+		return nil, fmt.Errorf("invalid WAV header: block align is zero")
+	}
+	if header.NumChannels == 0 {
+		return nil, fmt.Errorf("invalid WAV header: channel count is zero")
+	}
+	if dataSize == 0 {
+		return nil, fmt.Errorf("invalid WAV header: data size is zero")
+	}
+	if dataSize > fileSize {
+		return nil, fmt.Errorf("invalid WAV header: data size exceeds file size")
+	}
+
+	dec, err := newFrameDecoder(header)
+	if err != nil {
+		return nil, err
+	}
+
+	// ADPCM files may end in a short block; other formats must hold whole frames
+	if dec.blockFrames(dec.blockSize) == 1 && dataSize%int64(header.BlockAlign) != 0 {
+		return nil, fmt.Errorf("invalid WAV header: data size not aligned to block size")
+	}
+
+	dataStart, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wavStream{
+		f:         f,
+		Header:    header,
+		DataSize:  dataSize,
+		FileSize:  fileSize,
+		Frames:    dec.frameCount(header, dataSize),
+		dataStart: dataStart,
+		dec:       dec,
+	}, nil
+}
+
+// Close closes the underlying file
+func (s *wavStream) Close() error {
+	return s.f.Close()
+}
+
+// Skip moves forward n frames, decoding only the block the new position falls in
+func (s *wavStream) Skip(n int64) error {
+	s.pos = min(s.pos+n, s.Frames)
+	s.pending = nil
+
+	framesPerBlock := int64(s.dec.blockFrames(s.dec.blockSize))
+	block := s.pos / framesPerBlock
+	if _, err := s.f.Seek(s.dataStart+block*int64(s.dec.blockSize), io.SeekStart); err != nil {
+		return err
+	}
+
+	if within := int(s.pos % framesPerBlock); within > 0 {
+		decoded, err := s.readBlocks(1)
+		if err != nil {
+			return err
+		}
+		for ch := range decoded {
+			decoded[ch] = decoded[ch][min(within, len(decoded[ch])):]
+		}
+		s.pending = decoded
+	}
+	return nil
+}
+
+// ReadFrames decodes up to n frames. Fewer are returned at the end of the data,
+// including when the file is shorter than its header claims.
+func (s *wavStream) ReadFrames(n int) ([][]float64, error) {
+	if left := s.Frames - s.pos; int64(n) > left {
+		n = int(left)
+	}
+
+	samples := make([][]float64, s.Header.NumChannels)
+	for ch := range samples {
+		samples[ch] = make([]float64, 0, n)
+	}
+	take := func(from [][]float64) [][]float64 {
+		k := min(n-len(samples[0]), len(from[0]))
+		for ch := range samples {
+			samples[ch] = append(samples[ch], from[ch][:k]...)
+			from[ch] = from[ch][k:]
+		}
+		return from
+	}
+
+	if s.pending != nil {
+		s.pending = take(s.pending)
+	}
+	if remaining := n - len(samples[0]); remaining > 0 {
+		framesPerBlock := s.dec.blockFrames(s.dec.blockSize)
+		decoded, err := s.readBlocks((remaining + framesPerBlock - 1) / framesPerBlock)
+		if err != nil {
+			return nil, err
+		}
+		s.pending = take(decoded)
+	}
+
+	s.pos += int64(len(samples[0]))
+	return samples, nil
+}
+
+// readBlocks reads and decodes up to count blocks, including a final short block
+func (s *wavStream) readBlocks(count int) ([][]float64, error) {
+	buf := make([]byte, count*s.dec.blockSize)
+	read, err := io.ReadFull(s.f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+
+	framesPerBlock := s.dec.blockFrames(s.dec.blockSize)
+	decoded := make([][]float64, s.Header.NumChannels)
+	for ch := range decoded {
+		decoded[ch] = make([]float64, 0, count*framesPerBlock)
+	}
+	for off := 0; off < read; off += s.dec.blockSize {
+		decoded = s.dec.decode(buf[off:min(off+s.dec.blockSize, read)], decoded)
+	}
+	return decoded, nil
+}
+
+// newFrameDecoder picks the block decoder for a header's audio format
+func newFrameDecoder(header WavHeader) (frameDecoder, error) {
+	channels := int(header.NumChannels)
+	blockAlign := int(header.BlockAlign)
+
+	switch header.AudioFormat {
+	case formatIMAADPCM, formatMSADPCM:
+		if header.BitsPerSample != 4 {
+			return frameDecoder{}, fmt.Errorf("unsupported ADPCM bit depth: %d", header.BitsPerSample)
+		}
+		if channels > 2 {
+			return frameDecoder{}, fmt.Errorf("unsupported ADPCM channel count: %d", channels)
+		}
+
+		if header.AudioFormat == formatIMAADPCM {
+			blockFrames := func(size int) int { return imaBlockFrames(size, channels) }
+			if blockFrames(blockAlign) == 0 {
+				return frameDecoder{}, fmt.Errorf("invalid IMA ADPCM block align: %d", blockAlign)
+			}
+			return frameDecoder{
+				blockSize:   blockAlign,
+				blockFrames: blockFrames,
+				decode: func(block []byte, out [][]float64) [][]float64 {
+					return decodeIMABlock(block, channels, out)
+				},
+			}.capped(int(header.SamplesPerBlock)), nil
+		}
+
+		coefs := header.Coefs
+		if len(coefs) == 0 {
+			coefs = msDefaultCoefs
+		}
+		blockFrames := func(size int) int { return msBlockFrames(size, channels) }
+		if blockFrames(blockAlign) == 0 {
+			return frameDecoder{}, fmt.Errorf("invalid MS ADPCM block align: %d", blockAlign)
+		}
+		return frameDecoder{
+			blockSize:   blockAlign,
+			blockFrames: blockFrames,
+			decode: func(block []byte, out [][]float64) [][]float64 {
+				return decodeMSBlock(block, channels, coefs, out)
+			},
+		}.capped(int(header.SamplesPerBlock)), nil
+	}
+
+	decodeSample, err := sampleDecoder(header)
+	if err != nil {
+		return frameDecoder{}, err
+	}
+
+	bytesPerSample := int(header.BitsPerSample) / 8
+	frameSize := channels * bytesPerSample
+	return frameDecoder{
+		blockSize: frameSize,
+		blockFrames: func(size int) int {
+			return size / frameSize
+		},
+		decode: func(block []byte, out [][]float64) [][]float64 {
+			if len(block) < frameSize {
+				return out
+			}
+			for ch := range out {
+				out[ch] = append(out[ch], decodeSample(block[ch*bytesPerSample:]))
+			}
+			return out
+		},
+	}, nil
+}
+
+// sampleDecoder returns a function converting one encoded sample to [-1, 1]
+func sampleDecoder(header WavHeader) (func(b []byte) float64, error) {
+	// Determine the actual audio format
+	// 1 = PCM, 3 = IEEE float, 0xFFFE = Extensible (treat as PCM or float based on bits)
+	isFloat := header.AudioFormat == 3
+	isPCM := header.AudioFormat == 1
+	isExtensible := header.AudioFormat == 0xFFFE
+
+	// G.711 companded formats expand 8-bit codes to 16-bit samples
+	switch header.AudioFormat {
+	case formatMuLaw, formatALaw:
+		if header.BitsPerSample != 8 {
+			return nil, fmt.Errorf("unsupported companded bit depth: %d", header.BitsPerSample)
+		}
+		expand := alawToLinear
+		if header.AudioFormat == formatMuLaw {
+			expand = mulawToLinear
+		}
+		return func(b []byte) float64 {
+			return float64(expand(b[0])) / 32768.0
+		}, nil
+	}
+
+	if !isPCM && !isFloat && !isExtensible {
+		return nil, fmt.Errorf("unsupported audio format: %d (supported: 1=PCM, 2=MS ADPCM, 3=IEEE Float, 6=A-law, 7=µ-law, 17=IMA ADPCM, 65534=Extensible)", header.AudioFormat)
+	}
+
+	// For extensible format, determine if it's float or PCM based on subformat GUID
+	if isExtensible {
+		switch header.ExtSubFormat {
+		case subFormatPCM:
+			isPCM = true
+		case subFormatFloat:
+			isFloat = true
+		default:
+			return nil, fmt.Errorf("unsupported extensible subformat")
+		}
+	}
+
+	if isFloat {
+		// IEEE Float format
+		switch header.BitsPerSample {
+		case 32:
+			return func(b []byte) float64 {
+				return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+			}, nil
+		case 64:
+			return func(b []byte) float64 {
+				return math.Float64frombits(binary.LittleEndian.Uint64(b))
+			}, nil
+		}
+		return nil, fmt.Errorf("unsupported float bit depth: %d", header.BitsPerSample)
+	}
+
+	// PCM format
+	switch header.BitsPerSample {
+	case 8:
+		// 8-bit is unsigned
+		return func(b []byte) float64 {
+			return (float64(b[0]) - 128) / 128.0
+		}, nil
+	case 16:
+		// 16-bit is signed
+		return func(b []byte) float64 {
+			return float64(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+		}, nil
+	case 24:
+		// 24-bit is signed
+		return func(b []byte) float64 {
+			val := int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16
+			if val&0x800000 != 0 {
+				val |= ^0xFFFFFF // Sign extend
+			}
+			return float64(val) / 8388608.0
+		}, nil
+	case 32:
+		// 32-bit is signed integer
+		return func(b []byte) float64 {
+			return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648.0
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported PCM bit depth: %d", header.BitsPerSample)
+}
+
+// skipSilence decodes block by block until a frame above the threshold used by
+// removeLeadingSilence, returning the samples from that frame to the end of its
+// block. Nothing is returned when the rest of the data is silent.
+func (s *wavStream) skipSilence() ([][]float64, error) {
+	for {
+		block, err := s.ReadFrames(silenceBlockFrames)
+		if err != nil || len(block[0]) == 0 {
+			return block, err
+		}
+
+		for i := range block[0] {
+			for ch := range block {
+				if math.Abs(block[ch][i]) > 0.001 {
+					for c := range block {
+						block[c] = block[c][i:]
+					}
+					return block, nil
+				}
+			}
+		}
+	}
+}
+
+// readSlotSource decodes only the part of a source file that can reach a slice:
+// it seeks past the trim offset, skips leading silence, then reads enough frames
+// to fill samplesPerSlice after resampling to targetRate. The source header is
+// returned for its rate and channel layout.
+func readSlotSource(path string, trimMs float64, targetRate, samplesPerSlice int) ([][]float64, WavHeader, error) {
+	s, err := openWavStream(path)
+	if err != nil {
+		return nil, WavHeader{}, err
+	}
+	defer s.Close()
+
+	sourceRate := int(s.Header.SampleRate)
+	if err := s.Skip(int64(msToSamples(trimMs, sourceRate))); err != nil {
+		return nil, s.Header, err
+	}
+
+	head, err := s.skipSilence()
+	if err != nil {
+		return nil, s.Header, err
+	}
+
+	// One extra frame for the resampler's interpolation
+	needed := int(math.Ceil(float64(samplesPerSlice)*float64(sourceRate)/float64(targetRate))) + 1
+	if more := needed - len(head[0]); more > 0 {
+		if int64(more)*int64(s.Header.BlockAlign) > MaxInputDataSize {
+			return nil, s.Header, fmt.Errorf("input data too large: %d frames", more)
+		}
+		tail, err := s.ReadFrames(more)
+		if err != nil {
+			return nil, s.Header, err
+		}
+		for ch := range head {
+			head[ch] = append(head[ch], tail[ch]...)
+		}
+	}
+
+	return head, s.Header, nil
+}
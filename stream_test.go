@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// wavStream tests
+// ============================================================================
+
+func TestWavStream(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ramp.wav")
+
+	samples := [][]float64{make([]float64, 100), make([]float64, 100)}
+	for i := range samples[0] {
+		samples[0][i] = float64(i) / 200
+		samples[1][i] = -float64(i) / 200
+	}
+	writeWavFile(path, samples, 44100, 2)
+
+	s, err := openWavStream(path)
+	if err != nil {
+		t.Fatalf("openWavStream failed: %v", err)
+	}
+	defer s.Close()
+
+	if s.Frames != 100 {
+		t.Fatalf("expected 100 frames, got %d", s.Frames)
+	}
+
+	if err := s.Skip(10); err != nil {
+		t.Fatalf("Skip failed: %v", err)
+	}
+	block, err := s.ReadFrames(5)
+	if err != nil {
+		t.Fatalf("ReadFrames failed: %v", err)
+	}
+	if len(block[0]) != 5 || block[0][0] < 0.049 || block[0][0] > 0.051 || block[1][0] > -0.049 {
+		t.Errorf("expected frame 10 after skip, got %v", block)
+	}
+
+	block, _ = s.ReadFrames(1000)
+	if len(block[0]) != 85 {
+		t.Errorf("expected 85 remaining frames, got %d", len(block[0]))
+	}
+
+	t.Run("truncated data", func(t *testing.T) {
+		truncated := filepath.Join(dir, "truncated.wav")
+		data, _ := os.ReadFile(path)
+		os.WriteFile(truncated, data[:len(data)-40], 0644)
+
+		s, err := openWavStream(truncated)
+		if err != nil {
+			t.Fatalf("openWavStream failed: %v", err)
+		}
+		defer s.Close()
+
+		block, err := s.ReadFrames(1000)
+		if err != nil {
+			t.Fatalf("ReadFrames failed: %v", err)
+		}
+		if len(block[0]) != 90 {
+			t.Errorf("expected 90 frames from truncated file, got %d", len(block[0]))
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		bad := filepath.Join(dir, "alaw.wav")
+		data, _ := os.ReadFile(path)
+		data[20] = 6
+		os.WriteFile(bad, data, 0644)
+
+		if _, err := openWavStream(bad); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+// ============================================================================
+// readSlotSource tests
+// ============================================================================
+
+func TestReadSlotSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "long.wav")
+
+	// Silence, then a long tone
+	samples := make([]float64, 20000)
+	for i := 5000; i < len(samples); i++ {
+		samples[i] = 0.5
+	}
+	writeWavFile(path, [][]float64{samples}, 44100, 1)
+
+	t.Run("skips silence and reads only the slice", func(t *testing.T) {
+		source, header, err := readSlotSource(path, 0, 44100, 1000)
+		if err != nil {
+			t.Fatalf("readSlotSource failed: %v", err)
+		}
+		if header.SampleRate != 44100 {
+			t.Errorf("expected source rate 44100, got %d", header.SampleRate)
+		}
+		// Leading silence ends inside the second block, which is returned whole
+		if len(source[0]) != 2*silenceBlockFrames-5000 {
+			t.Errorf("expected %d frames, got %d", 2*silenceBlockFrames-5000, len(source[0]))
+		}
+		if source[0][0] < 0.49 {
+			t.Errorf("expected first frame to be the tone, got %f", source[0][0])
+		}
+	})
+
+	t.Run("reads enough source frames to resample", func(t *testing.T) {
+		source, _, err := readSlotSource(path, 0, 11025, 5000)
+		if err != nil {
+			t.Fatalf("readSlotSource failed: %v", err)
+		}
+		if len(source[0]) != 15000 {
+			t.Errorf("expected the rest of the file (15000 frames), got %d", len(source[0]))
+		}
+		if out := resample(source, 44100, 11025); len(out[0]) < 3750 {
+			t.Errorf("expected at least 3750 resampled frames, got %d", len(out[0]))
+		}
+	})
+
+	t.Run("trim seeks into the tone", func(t *testing.T) {
+		// 200 ms is 8820 frames, past the silence
+		source, _, err := readSlotSource(path, 200, 44100, 100)
+		if err != nil {
+			t.Fatalf("readSlotSource failed: %v", err)
+		}
+		if len(source[0]) != silenceBlockFrames || source[0][0] < 0.49 {
+			t.Errorf("expected one block of tone, got %d frames", len(source[0]))
+		}
+
+		source, _, err = readSlotSource(path, 1000, 44100, 100)
+		if err != nil {
+			t.Fatalf("readSlotSource failed: %v", err)
+		}
+		if len(source[0]) != 0 {
+			t.Errorf("expected trim past the end to leave nothing, got %d frames", len(source[0]))
+		}
+	})
+
+	t.Run("all silent", func(t *testing.T) {
+		silent := filepath.Join(dir, "silent.wav")
+		writeWavFile(silent, [][]float64{make([]float64, 10000)}, 44100, 1)
+
+		source, _, err := readSlotSource(silent, 0, 44100, 100)
+		if err != nil {
+			t.Fatalf("readSlotSource failed: %v", err)
+		}
+		if len(source[0]) != 0 {
+			t.Errorf("expected no frames, got %d", len(source[0]))
+		}
+	})
+}
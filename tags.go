@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SourceTags holds the descriptive metadata found while walking a source file's
+// chunks. Sample libraries often name files generically and describe them here.
+type SourceTags struct {
+	Title       string // INFO INAM or iXML USER/FXNAME
+	Comment     string // INFO ICMT
+	Description string // bext description or iXML USER/DESCRIPTION, NOTE
+	Genre       string // INFO IGNR
+	Category    string // iXML USER/CATEGORY and SUBCATEGORY
+
+	// MIDI root note from the smpl unity note or, failing that, the ACID chunk
+	RootNote    int
+	HasRootNote bool
+}
+
+// isTagChunk reports whether readWavHeader should hand a chunk to collect
+func isTagChunk(id string) bool {
+	switch id {
+	case "LIST", "bext", "iXML", "acid", "smpl":
+		return true
+	}
+	return false
+}
+
+// collect merges the contents of one tag chunk. Malformed chunks are ignored;
+// tags only widen the search and must never make a file unreadable.
+func (t *SourceTags) collect(id string, data []byte) {
+	le := binary.LittleEndian
+	switch id {
+	case "LIST":
+		listType, entries, _ := parseListChunk(data)
+		if listType != "INFO" {
+			return
+		}
+		for _, e := range entries {
+			switch e.ID {
+			case "INAM":
+				t.Title = cString(e.Data)
+			case "ICMT":
+				t.Comment = cString(e.Data)
+			case "IGNR":
+				t.Genre = cString(e.Data)
+			}
+		}
+
+	case "bext":
+		if len(data) >= 256 && t.Description == "" {
+			t.Description = cString(data[0:256])
+		}
+
+	case "iXML":
+		t.collectIXML(data)
+
+	case "smpl":
+		if len(data) >= 16 {
+			if note := int(le.Uint32(data[12:16])); note <= 127 {
+				t.RootNote, t.HasRootNote = note, true
+			}
+		}
+
+	case "acid":
+		// Flag 0x02 marks the root note as set
+		if len(data) >= 6 && le.Uint32(data[0:4])&0x02 != 0 && !t.HasRootNote {
+			if note := int(le.Uint16(data[4:6])); note <= 127 {
+				t.RootNote, t.HasRootNote = note, true
+			}
+		}
+	}
+}
+
+// ixmlDoc is the subset of iXML read for tags. USER holds the free-form fields
+// that library tools such as Soundminer write.
+type ixmlDoc struct {
+	Note string `xml:"NOTE"`
+	User struct {
+		FXName      string `xml:"FXNAME"`
+		Description string `xml:"DESCRIPTION"`
+		Category    string `xml:"CATEGORY"`
+		SubCategory string `xml:"SUBCATEGORY"`
+	} `xml:"USER"`
+}
+
+func (t *SourceTags) collectIXML(data []byte) {
+	var doc ixmlDoc
+	if err := xml.Unmarshal([]byte(cString(data)), &doc); err != nil {
+		return
+	}
+	if t.Title == "" {
+		t.Title = strings.TrimSpace(doc.User.FXName)
+	}
+	for _, d := range []string{doc.User.Description, doc.Note} {
+		if d = strings.TrimSpace(d); d != "" {
+			t.Description = d
+			break
+		}
+	}
+	category := strings.TrimSpace(doc.User.Category + " " + doc.User.SubCategory)
+	if category != "" {
+		t.Category = category
+	}
+}
+
+// match returns the name and value of the first tag the pattern matches, or
+// empty strings when none does
+func (t SourceTags) match(pattern *regexp.Regexp) (string, string) {
+	fields := []struct{ name, value string }{
+		{"title", t.Title},
+		{"comment", t.Comment},
+		{"description", t.Description},
+		{"genre", t.Genre},
+		{"category", t.Category},
+	}
+	for _, f := range fields {
+		if f.value != "" && pattern.MatchString(f.value) {
+			return f.name, f.value
+		}
+	}
+	return "", ""
+}
+
+// printTagMatches lists the files included because of a tag rather than their name
+func printTagMatches(files []FileInfo) {
+	var matched []FileInfo
+	for _, f := range files {
+		if f.MatchedTag != "" {
+			matched = append(matched, f)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	progress.Printf("\nMatched by embedded tag (%d files):\n", len(matched))
+	for _, f := range matched {
+		line := fmt.Sprintf("  %-50s %s", filepath.Base(f.Path), truncateText(f.MatchedTag, 60))
+		if f.Tags.HasRootNote {
+			line += fmt.Sprintf(", root %s", noteName(f.Tags.RootNote))
+		}
+		progress.Println(line)
+	}
+}
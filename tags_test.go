@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// ============================================================================
+// Tag collection tests
+// ============================================================================
+
+func TestSourceTagsCollect(t *testing.T) {
+	t.Run("INFO", func(t *testing.T) {
+		var tags SourceTags
+		tags.collect("LIST", infoListBody("INAM", "Deep One", "ICMT", "808 style", "IGNR", "Techno"))
+		if tags.Title != "Deep One" || tags.Comment != "808 style" || tags.Genre != "Techno" {
+			t.Errorf("unexpected tags %+v", tags)
+		}
+	})
+
+	t.Run("non-INFO LIST ignored", func(t *testing.T) {
+		var tags SourceTags
+		body := infoListBody("INAM", "ignored")
+		copy(body, "adtl")
+		tags.collect("LIST", body)
+		if tags.Title != "" {
+			t.Errorf("expected no title, got %q", tags.Title)
+		}
+	})
+
+	t.Run("bext description", func(t *testing.T) {
+		var tags SourceTags
+		tags.collect("bext", (&BextInfo{Description: "Snare, tight"}).encode())
+		if tags.Description != "Snare, tight" {
+			t.Errorf("unexpected description %q", tags.Description)
+		}
+	})
+
+	t.Run("iXML", func(t *testing.T) {
+		var tags SourceTags
+		tags.collect("iXML", []byte(`<?xml version="1.0"?><BWFXML><NOTE>take note</NOTE>`+
+			`<USER><FXNAME>Perc Hit</FXNAME><DESCRIPTION>Metal clank</DESCRIPTION>`+
+			`<CATEGORY>IMPACTS</CATEGORY><SUBCATEGORY>METAL</SUBCATEGORY></USER></BWFXML>`+"\x00"))
+		if tags.Title != "Perc Hit" || tags.Description != "Metal clank" || tags.Category != "IMPACTS METAL" {
+			t.Errorf("unexpected tags %+v", tags)
+		}
+	})
+
+	t.Run("malformed iXML ignored", func(t *testing.T) {
+		var tags SourceTags
+		tags.collect("iXML", []byte("<BWFXML><USER>"))
+		if tags != (SourceTags{}) {
+			t.Errorf("expected no tags, got %+v", tags)
+		}
+	})
+
+	t.Run("root note from smpl wins over acid", func(t *testing.T) {
+		smpl := make([]byte, 36)
+		binary.LittleEndian.PutUint32(smpl[12:16], 48)
+		acid := make([]byte, 24)
+		binary.LittleEndian.PutUint32(acid[0:4], 0x03)
+		binary.LittleEndian.PutUint16(acid[4:6], 62)
+
+		var tags SourceTags
+		tags.collect("smpl", smpl)
+		tags.collect("acid", acid)
+		if !tags.HasRootNote || tags.RootNote != 48 {
+			t.Errorf("expected root note 48, got %d (set %v)", tags.RootNote, tags.HasRootNote)
+		}
+
+		var acidOnly SourceTags
+		acidOnly.collect("acid", acid)
+		if !acidOnly.HasRootNote || acidOnly.RootNote != 62 {
+			t.Errorf("expected root note 62, got %d", acidOnly.RootNote)
+		}
+	})
+
+	t.Run("acid without root note flag", func(t *testing.T) {
+		acid := make([]byte, 24)
+		binary.LittleEndian.PutUint16(acid[4:6], 62)
+		var tags SourceTags
+		tags.collect("acid", acid)
+		if tags.HasRootNote {
+			t.Error("expected no root note")
+		}
+	})
+}
+
+func TestSourceTagsMatch(t *testing.T) {
+	tags := SourceTags{Title: "Sample 042", Genre: "House", Category: "DRUMS KICK"}
+	re := regexp.MustCompile("(?i)kick")
+	if tag, value := tags.match(re); tag != "category" || value != "DRUMS KICK" {
+		t.Errorf("expected category match, got %q %q", tag, value)
+	}
+	if tag, _ := tags.match(regexp.MustCompile("(?i)snare")); tag != "" {
+		t.Errorf("expected no match, got %q", tag)
+	}
+}
+
+// ============================================================================
+// Header walk and search tests
+// ============================================================================
+
+func TestReadWavHeaderCollectsTags(t *testing.T) {
+	// Tags after the data chunk are still found
+	file := riffFile(0,
+		riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true),
+		riffChunk("data", make([]byte, 4), true),
+		riffChunk("LIST", infoListBody("IGNR", "Dub"), true))
+
+	header, dataSize, err := readWavHeader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("readWavHeader failed: %v", err)
+	}
+	if dataSize != 4 {
+		t.Errorf("expected data size 4, got %d", dataSize)
+	}
+	if header.Tags.Genre != "Dub" {
+		t.Errorf("expected genre Dub, got %q", header.Tags.Genre)
+	}
+}
+
+func TestFindWavFilesByTag(t *testing.T) {
+	dir := t.TempDir()
+	samples := [][]float64{make([]float64, 10)}
+
+	tagged := filepath.Join(dir, "Sample 042.wav")
+	writeWavFileWithMetadata(tagged, samples, 44100, 1, &WavMetadata{Title: "Big Kick"})
+	writeWavFile(filepath.Join(dir, "Sample 043.wav"), samples, 44100, 1)
+	writeWavFile(filepath.Join(dir, "kick_01.wav"), samples, 44100, 1)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("kick"), 0644)
+
+	pattern := regexp.MustCompile(`(?i)^.*kick.*\.wav$`)
+
+	t.Run("names only", func(t *testing.T) {
+		files, err := findWavFiles(dir, pattern, nil)
+		if err != nil {
+			t.Fatalf("findWavFiles failed: %v", err)
+		}
+		if len(files) != 1 {
+			t.Errorf("expected 1 file, got %d", len(files))
+		}
+	})
+
+	t.Run("names and tags", func(t *testing.T) {
+		files, err := findWavFiles(dir, pattern, regexp.MustCompile("(?i)kick"))
+		if err != nil {
+			t.Fatalf("findWavFiles failed: %v", err)
+		}
+		if len(files) != 2 {
+			t.Fatalf("expected 2 files, got %d", len(files))
+		}
+		if files[0].Path != tagged || files[0].MatchedTag != `title "Big Kick"` {
+			t.Errorf("unexpected tag match %q for %s", files[0].MatchedTag, files[0].Path)
+		}
+		if files[1].MatchedTag != "" {
+			t.Errorf("name match should have no matched tag, got %q", files[1].MatchedTag)
+		}
+	})
+}
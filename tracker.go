@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Polyend Tracker .pti instrument layout: a 392-byte little-endian header
+// followed by mono 16-bit PCM. Playback points and slice positions are stored
+// as fractions of the sample length scaled to 0-65535.
+const (
+	ptiHeaderSize    = 392
+	ptiMaxSlices     = 48
+	ptiNameOffset    = 0x15
+	ptiNameSize      = 31
+	ptiLengthOffset  = 0x3C
+	ptiModeOffset    = 0x4C
+	ptiPointsOffset  = 0x4E // start, loop start, loop end, end
+	ptiVolumeOffset  = 0x110
+	ptiPanOffset     = 0x114
+	ptiSlicesOffset  = 0x118
+	ptiCountOffset   = 0x178
+	ptiModeSlice     = 4
+	ptiDefaultLevel  = 50 // volume and pan are 0-100; 50 is the Tracker default and centre
+	ptiPositionScale = 65535
+)
+
+// ptiMagic opens every .pti file
+var ptiMagic = []byte{'T', 'I', 0x01, 0x00, 0x01, 0x05, 0x00, 0x01}
+
+// trackerPath returns the instrument path for a combined WAV
+func trackerPath(wavPath string) string {
+	return strings.TrimSuffix(wavPath, ".wav") + ".pti"
+}
+
+// ptiPosition scales a frame offset to the 16-bit fraction .pti files use
+func ptiPosition(frame, length int) uint16 {
+	if length <= 0 {
+		return 0
+	}
+	return uint16(math.Round(float64(frame) / float64(length) * ptiPositionScale))
+}
+
+// encodeTrackerInstrument builds a sliced .pti instrument with the audio
+// embedded. The Tracker plays slice n on the nth note up from the instrument's
+// root, matching the slot order.
+func encodeTrackerInstrument(layout BatchLayout, audio []float64) ([]byte, error) {
+	if layout.Channels != 1 {
+		return nil, errors.New("Polyend Tracker instruments are mono; run without -stereo")
+	}
+	if len(layout.Slots) > ptiMaxSlices {
+		return nil, fmt.Errorf("Polyend Tracker instruments hold at most %d slices, batch has %d", ptiMaxSlices, len(layout.Slots))
+	}
+
+	le := binary.LittleEndian
+	data := make([]byte, ptiHeaderSize, ptiHeaderSize+2*len(audio))
+	copy(data, ptiMagic)
+	copy(data[ptiNameOffset:ptiNameOffset+ptiNameSize], strings.TrimSuffix(filepath.Base(layout.Path), ".wav"))
+
+	length := len(audio)
+	le.PutUint32(data[ptiLengthOffset:], uint32(length))
+	data[ptiModeOffset] = ptiModeSlice
+	for i, point := range []uint16{0, 1, ptiPositionScale - 1, ptiPositionScale} {
+		le.PutUint16(data[ptiPointsOffset+2*i:], point)
+	}
+	data[ptiVolumeOffset] = ptiDefaultLevel
+	data[ptiPanOffset] = ptiDefaultLevel
+
+	for i := range layout.Slots {
+		start, _ := layout.sliceBounds(i)
+		le.PutUint16(data[ptiSlicesOffset+2*i:], ptiPosition(start, length))
+	}
+	data[ptiCountOffset] = byte(len(layout.Slots))
+
+	for _, v := range audio {
+		v = max(-1, min(1, v))
+		data = le.AppendUint16(data, uint16(int16(v*32767)))
+	}
+	return data, nil
+}
+
+// writeTrackerInstrument writes the .pti for a batch from its combined WAV
+func writeTrackerInstrument(layout BatchLayout) (string, error) {
+	if layout.Channels != 1 {
+		return "", errors.New("Polyend Tracker instruments are mono; run without -stereo")
+	}
+	wav, err := readWavFile(layout.Path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := encodeTrackerInstrument(layout, wav.Samples[0])
+	if err != nil {
+		return "", err
+	}
+	path := trackerPath(layout.Path)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
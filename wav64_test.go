@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// ============================================================================
+// RF64/BW64 and Wave64 helper functions
+// ============================================================================
+
+// rf64File builds an RF64-style file whose RIFF and data sizes live in a ds64 chunk
+func rf64File(marker string, fmtBody, samples []byte, chunks ...[]byte) []byte {
+	ds64 := binary.LittleEndian.AppendUint64(nil, 0) // RIFF size, set below
+	ds64 = binary.LittleEndian.AppendUint64(ds64, uint64(len(samples)))
+	ds64 = binary.LittleEndian.AppendUint64(ds64, 0)
+	ds64 = binary.LittleEndian.AppendUint32(ds64, 0) // table length
+
+	file := []byte(marker)
+	file = binary.LittleEndian.AppendUint32(file, rf64SizeUnset)
+	file = append(file, []byte("WAVE")...)
+	file = append(file, riffChunk("ds64", ds64, true)...)
+	file = append(file, riffChunk("fmt ", fmtBody, true)...)
+	for _, c := range chunks {
+		file = append(file, c...)
+	}
+	file = append(file, []byte("data")...)
+	file = binary.LittleEndian.AppendUint32(file, rf64SizeUnset)
+	file = append(file, samples...)
+	binary.LittleEndian.PutUint64(file[20:28], uint64(len(file)-8))
+	return file
+}
+
+// w64Chunk builds a Wave64 chunk with 8-byte alignment padding
+func w64Chunk(id string, body []byte) []byte {
+	chunk := append([]byte(id), w64ChunkSuffix[:]...)
+	chunk = binary.LittleEndian.AppendUint64(chunk, uint64(24+len(body)))
+	chunk = append(chunk, body...)
+	for len(chunk)%8 != 0 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// w64File wraps chunks in a Wave64 riff/wave header
+func w64File(chunks ...[]byte) []byte {
+	var body []byte
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	file := append([]byte(nil), w64RiffGUID[:]...)
+	file = binary.LittleEndian.AppendUint64(file, uint64(40+len(body)))
+	file = append(file, w64WaveGUID[:]...)
+	return append(file, body...)
+}
+
+// ============================================================================
+// readWavHeader RF64/BW64 tests
+// ============================================================================
+
+func TestReadWavHeaderRF64(t *testing.T) {
+	samples := []byte{0x00, 0x40, 0x00, 0xC0, 0x00, 0x20} // 0.5, -0.5, 0.25
+
+	for _, marker := range []string{"RF64", "BW64"} {
+		t.Run(marker, func(t *testing.T) {
+			file := rf64File(marker, pcmFmtBody(1, 48000, 16), samples, riffChunk("LIST", []byte("INFOabc"), true))
+
+			header, dataSize, err := readWavHeader(bytes.NewReader(file))
+			if err != nil {
+				t.Fatalf("readWavHeader failed: %v", err)
+			}
+			if dataSize != 6 {
+				t.Errorf("expected dataSize 6 from ds64, got %d", dataSize)
+			}
+			if header.SampleRate != 48000 {
+				t.Errorf("expected 48000 Hz, got %d", header.SampleRate)
+			}
+		})
+	}
+
+	t.Run("chunk after data is found through ds64 size", func(t *testing.T) {
+		file := rf64File("RF64", pcmFmtBody(1, 44100, 16), samples)
+		file = append(file, riffChunk("cue ", cueChunkBody(1), true)...)
+
+		path := filepath.Join(t.TempDir(), "cued.wav")
+		os.WriteFile(path, file, 0644)
+
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.NumSamples != 3 || wav.Samples[0][1] != -0.5 {
+			t.Errorf("unexpected samples %v", wav.Samples)
+		}
+
+		chunks, err := listChunks(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("listChunks failed: %v", err)
+		}
+		if len(chunks) != 4 || chunks[2].Size != 6 || chunks[3].ID != "cue " {
+			t.Errorf("expected ds64-sized data followed by cue, got %+v", chunks)
+		}
+	})
+
+	t.Run("ds64 ignored in plain RIFF", func(t *testing.T) {
+		file := riffFile(0,
+			riffChunk("ds64", make([]byte, 28), true),
+			riffChunk("fmt ", pcmFmtBody(1, 44100, 16), true),
+			riffChunk("data", samples, true))
+
+		_, dataSize, err := readWavHeader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("readWavHeader failed: %v", err)
+		}
+		if dataSize != 6 {
+			t.Errorf("expected dataSize 6, got %d", dataSize)
+		}
+	})
+}
+
+// ============================================================================
+// readWavHeader Wave64 tests
+// ============================================================================
+
+func TestReadWavHeaderW64(t *testing.T) {
+	samples := []byte{0x00, 0x40, 0x00, 0xC0, 0x00, 0x20, 0x00, 0xE0}
+
+	t.Run("stereo file with extra chunk", func(t *testing.T) {
+		file := w64File(
+			w64Chunk("fmt ", pcmFmtBody(2, 96000, 16)),
+			w64Chunk("junk", []byte{1, 2, 3}),
+			w64Chunk("data", samples))
+
+		header, dataSize, err := readWavHeader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("readWavHeader failed: %v", err)
+		}
+		if dataSize != 8 || header.NumChannels != 2 || header.SampleRate != 96000 {
+			t.Errorf("unexpected header %+v, dataSize %d", header, dataSize)
+		}
+
+		path := filepath.Join(t.TempDir(), "test.w64")
+		os.WriteFile(path, file, 0644)
+		wav, err := readWavFile(path)
+		if err != nil {
+			t.Fatalf("readWavFile failed: %v", err)
+		}
+		if wav.NumSamples != 2 || wav.Samples[1][0] != -0.5 || wav.Samples[1][1] != -0.25 {
+			t.Errorf("unexpected samples %v", wav.Samples)
+		}
+	})
+
+	t.Run("missing data chunk", func(t *testing.T) {
+		file := w64File(w64Chunk("fmt ", pcmFmtBody(1, 44100, 16)))
+		if _, _, err := readWavHeader(bytes.NewReader(file)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("bad wave GUID", func(t *testing.T) {
+		file := w64File(w64Chunk("fmt ", pcmFmtBody(1, 44100, 16)), w64Chunk("data", samples))
+		file[24+5] ^= 0xFF
+		if _, _, err := readWavHeader(bytes.NewReader(file)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("chunk size smaller than header", func(t *testing.T) {
+		file := w64File(w64Chunk("fmt ", pcmFmtBody(1, 44100, 16)))
+		binary.LittleEndian.PutUint64(file[56:64], 8)
+		if _, _, err := readWavHeader(bytes.NewReader(file)); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+// ============================================================================
+// Source discovery tests
+// ============================================================================
+
+func TestFindWavFilesLargeFormats(t *testing.T) {
+	samples := []byte{0x00, 0x40, 0x00, 0xC0}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "kick_room.w64"), w64File(w64Chunk("fmt ", pcmFmtBody(1, 44100, 16)), w64Chunk("data", samples)), 0644)
+	os.WriteFile(filepath.Join(dir, "kick_field.RF64"), rf64File("RF64", pcmFmtBody(1, 44100, 16), samples), 0644)
+	writeWavFile(filepath.Join(dir, "kick_close.wav"), [][]float64{{0.1, 0.2}}, 44100, 1)
+	os.WriteFile(filepath.Join(dir, "kick_notes.txt"), []byte("kick"), 0644)
+
+	files, err := findWavFiles(dir, regexp.MustCompile(wavPattern("kick")), nil)
+	if err != nil {
+		t.Fatalf("findWavFiles failed: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f.Path))
+	}
+	if want := []string{"kick_close.wav", "kick_field.RF64", "kick_room.w64"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
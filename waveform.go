@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// WaveformFormat selects whether and in which formats waveform images are
+// written for each batch
+type WaveformFormat string
+
+const (
+	WaveformNone WaveformFormat = "none"
+	WaveformPNG  WaveformFormat = "png"
+	WaveformSVG  WaveformFormat = "svg"
+	WaveformBoth WaveformFormat = "both"
+)
+
+// waveformFormats lists the accepted formats in help order
+var waveformFormats = []WaveformFormat{WaveformNone, WaveformPNG, WaveformSVG, WaveformBoth}
+
+// parseWaveformFormat validates a waveform format name
+func parseWaveformFormat(s string) (WaveformFormat, error) {
+	for _, f := range waveformFormats {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(waveformFormats))
+	for i, f := range waveformFormats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown waveform format %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// enabled reports whether any waveform image should be written
+func (f WaveformFormat) enabled() bool {
+	return f != "" && f != WaveformNone
+}
+
+// extensions returns the image file extensions to write
+func (f WaveformFormat) extensions() []string {
+	switch f {
+	case WaveformPNG:
+		return []string{".png"}
+	case WaveformSVG:
+		return []string{".svg"}
+	case WaveformBoth:
+		return []string{".png", ".svg"}
+	}
+	return nil
+}
+
+// Image geometry in pixels. Text is drawn at textScale, so a line of labels
+// is lineHeight tall.
+const (
+	textScale  = 2
+	lineHeight = (glyphHeight + 2) * textScale
+	labelPad   = 3
+
+	overviewWidth      = 1600
+	overviewWaveHeight = 240
+	overviewLabelLines = 3
+
+	thumbColumns    = 8
+	thumbWidth      = 192
+	thumbWaveHeight = 80
+	thumbLabelLines = 2
+	thumbGap        = 8
+)
+
+// Image colours
+var (
+	waveformBackground = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	waveformShade      = color.RGBA{0xEE, 0xF1, 0xF5, 0xFF}
+	waveformWave       = color.RGBA{0x2F, 0x5F, 0x98, 0xFF}
+	waveformBoundary   = color.RGBA{0xD0, 0x3A, 0x2F, 0xFF}
+	waveformText       = color.RGBA{0x22, 0x22, 0x22, 0xFF}
+)
+
+// waveColumn is the sample range drawn in one pixel column
+type waveColumn struct {
+	lo, hi float64
+}
+
+// waveformPeaks reduces frames start to end-1 of every channel to width
+// columns of their lowest and highest values
+func waveformPeaks(audio [][]float64, start, end, width int) []waveColumn {
+	cols := make([]waveColumn, width)
+	span := end - start
+	if span <= 0 {
+		return cols
+	}
+	for x := range cols {
+		from := start + x*span/width
+		to := max(start+(x+1)*span/width, from+1)
+		c := waveColumn{}
+		for _, ch := range audio {
+			for i := from; i < min(to, len(ch)); i++ {
+				c.lo = min(c.lo, ch[i])
+				c.hi = max(c.hi, ch[i])
+			}
+		}
+		cols[x] = c
+	}
+	return cols
+}
+
+// sliceLabel is the text shown for a slice: its number, the P-6 key that
+// plays it and its source
+type sliceLabel struct {
+	Number, Note, Name string
+}
+
+// sliceLabels returns the label of every slice in a batch
+func sliceLabels(layout BatchLayout) []sliceLabel {
+	labels := make([]sliceLabel, len(layout.Slots))
+	for i, slot := range layout.Slots {
+		labels[i] = sliceLabel{fmt.Sprint(i + 1), noteName(FirstSliceNote + i), slotName(slot, i)}
+	}
+	return labels
+}
+
+// canvas is a drawing surface shared by the PNG and SVG renderers
+type canvas interface {
+	rect(x, y, w, h int, c color.RGBA)
+	// wave draws one column per pixel from x, centred in a band h tall at y
+	wave(x, y, h int, cols []waveColumn, c color.RGBA)
+	// text draws s with its top left at x, y, cut to fit width
+	text(x, y, width int, s string, c color.RGBA)
+}
+
+// overviewSize returns the dimensions of the whole-batch image
+func overviewSize() (int, int) {
+	return overviewWidth, overviewLabelLines*lineHeight + 2*labelPad + overviewWaveHeight
+}
+
+// drawOverview draws the whole batch with a labelled column per slice
+func drawOverview(c canvas, layout BatchLayout, audio [][]float64) {
+	width, height := overviewSize()
+	c.rect(0, 0, width, height, waveformBackground)
+
+	labels := sliceLabels(layout)
+	n := max(len(labels), 1)
+	for i, l := range labels {
+		x0, x1 := i*width/n, (i+1)*width/n
+		if i%2 == 1 {
+			c.rect(x0, 0, x1-x0, height, waveformShade)
+		}
+		for line, s := range []string{l.Number, l.Note, l.Name} {
+			c.text(x0+labelPad, labelPad+line*lineHeight, x1-x0-2*labelPad, s, waveformText)
+		}
+	}
+
+	top := overviewLabelLines*lineHeight + 2*labelPad
+	c.wave(0, top, overviewWaveHeight, waveformPeaks(audio, 0, layout.NumSamples(), width), waveformWave)
+
+	for i := 1; i < len(labels); i++ {
+		c.rect(i*width/n, 0, 1, height, waveformBoundary)
+	}
+}
+
+// thumbnailSize returns the dimensions of the per-slice grid
+func thumbnailSize(numSlices int) (int, int) {
+	cols := max(min(numSlices, thumbColumns), 1)
+	rows := max((numSlices+thumbColumns-1)/thumbColumns, 1)
+	cellHeight := thumbWaveHeight + thumbLabelLines*lineHeight + labelPad
+	return cols*(thumbWidth+thumbGap) + thumbGap, rows*(cellHeight+thumbGap) + thumbGap
+}
+
+// drawThumbnails draws every slice in its own cell, eight to a row, with its
+// number, note and source beneath
+func drawThumbnails(c canvas, layout BatchLayout, audio [][]float64) {
+	width, height := thumbnailSize(len(layout.Slots))
+	c.rect(0, 0, width, height, waveformBackground)
+
+	cellHeight := thumbWaveHeight + thumbLabelLines*lineHeight + labelPad
+	for i, l := range sliceLabels(layout) {
+		x := thumbGap + i%thumbColumns*(thumbWidth+thumbGap)
+		y := thumbGap + i/thumbColumns*(cellHeight+thumbGap)
+		start, end := layout.sliceBounds(i)
+
+		c.rect(x, y, thumbWidth, thumbWaveHeight, waveformShade)
+		c.wave(x, y, thumbWaveHeight, waveformPeaks(audio, start, end, thumbWidth), waveformWave)
+		c.text(x, y+thumbWaveHeight+labelPad, thumbWidth, l.Number+" "+l.Note, waveformText)
+		c.text(x, y+thumbWaveHeight+labelPad+lineHeight, thumbWidth, l.Name, waveformText)
+	}
+}
+
+// pngCanvas draws into an RGBA image
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height int) pngCanvas {
+	return pngCanvas{image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+func (p pngCanvas) rect(x, y, w, h int, c color.RGBA) {
+	fillRect(p.img, x, y, w, h, c)
+}
+
+func (p pngCanvas) wave(x, y, h int, cols []waveColumn, c color.RGBA) {
+	for i, col := range cols {
+		top, bottom := waveY(y, h, col.hi), waveY(y, h, col.lo)
+		fillRect(p.img, x+i, top, 1, bottom-top+1, c)
+	}
+}
+
+func (p pngCanvas) text(x, y, width int, s string, c color.RGBA) {
+	drawText(p.img, x, y, textScale, fitText(s, width, textScale), c)
+}
+
+// waveY maps a sample value to a row in a band h tall at y, +1 at the top
+func waveY(y, h int, v float64) int {
+	v = max(-1, min(1, v))
+	return y + int((1-v)*float64(h-1)/2)
+}
+
+// svgCanvas writes SVG elements. Text uses a monospace font sized to take no
+// more room than the PNG font, so both cut labels at the same length.
+type svgCanvas struct {
+	w *xmlWriter
+}
+
+func newSVGCanvas(width, height int) svgCanvas {
+	w := newXMLWriter("  ")
+	w.open("svg", "xmlns", "http://www.w3.org/2000/svg", "width", width, "height", height,
+		"viewBox", fmt.Sprintf("0 0 %d %d", width, height))
+	return svgCanvas{w}
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (s svgCanvas) rect(x, y, w, h int, c color.RGBA) {
+	s.w.empty("rect", "x", x, "y", y, "width", w, "height", h, "fill", svgColor(c))
+}
+
+func (s svgCanvas) wave(x, y, h int, cols []waveColumn, c color.RGBA) {
+	var d strings.Builder
+	for i, col := range cols {
+		top, bottom := waveY(y, h, col.hi), waveY(y, h, col.lo)
+		fmt.Fprintf(&d, "M%d.5 %dV%d", x+i, top, bottom+1)
+	}
+	s.w.empty("path", "d", d.String(), "stroke", svgColor(c), "stroke-width", 1, "fill", "none")
+}
+
+func (s svgCanvas) text(x, y, width int, text string, c color.RGBA) {
+	s.w.text("text", fitText(text, width, textScale), "x", x, "y", y+glyphHeight*textScale,
+		"font-family", "monospace", "font-size", lineHeight-2, "fill", svgColor(c))
+}
+
+// document closes the SVG and returns it
+func (s svgCanvas) document() string {
+	s.w.close("svg")
+	return s.w.String()
+}
+
+// renderWaveformImages draws the overview and thumbnail grid of a batch,
+// encoded for a file extension
+func renderWaveformImages(layout BatchLayout, audio [][]float64, ext string) (overview, thumbnails []byte, err error) {
+	ow, oh := overviewSize()
+	tw, th := thumbnailSize(len(layout.Slots))
+
+	if ext == ".svg" {
+		o, t := newSVGCanvas(ow, oh), newSVGCanvas(tw, th)
+		drawOverview(o, layout, audio)
+		drawThumbnails(t, layout, audio)
+		return []byte(o.document()), []byte(t.document()), nil
+	}
+
+	o, t := newPNGCanvas(ow, oh), newPNGCanvas(tw, th)
+	drawOverview(o, layout, audio)
+	drawThumbnails(t, layout, audio)
+	var ob, tb bytes.Buffer
+	if err := png.Encode(&ob, o.img); err != nil {
+		return nil, nil, err
+	}
+	if err := png.Encode(&tb, t.img); err != nil {
+		return nil, nil, err
+	}
+	return ob.Bytes(), tb.Bytes(), nil
+}
+
+// waveformPaths returns the overview and thumbnail grid paths for a combined WAV
+func waveformPaths(wavPath, ext string) (string, string) {
+	base := strings.TrimSuffix(wavPath, ".wav")
+	return base + "_waveform" + ext, base + "_slices" + ext
+}
+
+// writeWaveforms writes the overview and thumbnail grid of a batch in each
+// selected format
+func writeWaveforms(layout BatchLayout, format WaveformFormat) ([]string, error) {
+	wav, err := readWavFile(layout.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for _, ext := range format.extensions() {
+		overview, thumbnails, err := renderWaveformImages(layout, wav.Samples, ext)
+		if err != nil {
+			return created, err
+		}
+		overviewPath, thumbnailPath := waveformPaths(layout.Path, ext)
+		for _, f := range []struct {
+			path string
+			data []byte
+		}{{overviewPath, overview}, {thumbnailPath, thumbnails}} {
+			if err := os.WriteFile(f.path, f.data, 0644); err != nil {
+				return created, err
+			}
+			created = append(created, f.path)
+		}
+	}
+	return created, nil
+}
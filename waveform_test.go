@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ============================================================================
+// Waveform tests
+// ============================================================================
+
+func TestWaveformPeaks(t *testing.T) {
+	audio := [][]float64{{0.1, -0.5, 0.3, 0, 0, 0, 0.9, 0}, {0, 0, -0.7, 0, 0, 0, 0, 0}}
+
+	cols := waveformPeaks(audio, 0, 8, 4)
+	want := []waveColumn{{-0.5, 0.1}, {-0.7, 0.3}, {0, 0}, {0, 0.9}}
+	if !reflect.DeepEqual(cols, want) {
+		t.Errorf("expected %v, got %v", want, cols)
+	}
+
+	// More columns than frames repeat frames rather than leaving gaps
+	cols = waveformPeaks(audio, 6, 8, 4)
+	if cols[0].hi != 0.9 || cols[1].hi != 0.9 {
+		t.Errorf("expected stretched peaks, got %v", cols)
+	}
+}
+
+func TestSliceLabels(t *testing.T) {
+	layout := BatchLayout{Slots: []Slot{{FileInfo: FileInfo{Path: "/s/kick 01.wav"}}, {}}}
+	want := []sliceLabel{{"1", "C4", "kick 01"}, {"2", "C#4", "Slice 02"}}
+	if got := sliceLabels(layout); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRenderWaveformImages(t *testing.T) {
+	layout := BatchLayout{Path: "/out/kit.wav", SampleRate: 44100, Channels: 1, SliceLength: 100, Slots: make([]Slot, 10)}
+	audio := [][]float64{make([]float64, 1000)}
+	for i := range audio[0] {
+		audio[0][i] = float64(i%100) / 100
+	}
+
+	t.Run("png", func(t *testing.T) {
+		overview, thumbnails, err := renderWaveformImages(layout, audio, ".png")
+		if err != nil {
+			t.Fatalf("renderWaveformImages failed: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(overview))
+		if err != nil {
+			t.Fatalf("overview is not a PNG: %v", err)
+		}
+		if w, h := overviewSize(); img.Bounds().Dx() != w || img.Bounds().Dy() != h {
+			t.Errorf("unexpected overview size %v", img.Bounds())
+		}
+		// Slice 2 starts a fifth of the way across with a boundary line
+		if c := img.At(overviewWidth/10, 1); c != waveformBoundary {
+			t.Errorf("expected boundary colour at the second slice, got %v", c)
+		}
+
+		img, err = png.Decode(bytes.NewReader(thumbnails))
+		if err != nil {
+			t.Fatalf("thumbnail grid is not a PNG: %v", err)
+		}
+		if w, h := thumbnailSize(10); img.Bounds().Dx() != w || img.Bounds().Dy() != h {
+			t.Errorf("unexpected grid size %v", img.Bounds())
+		}
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		overview, thumbnails, err := renderWaveformImages(layout, audio, ".svg")
+		if err != nil {
+			t.Fatalf("renderWaveformImages failed: %v", err)
+		}
+
+		var doc struct {
+			Width int      `xml:"width,attr"`
+			Texts []string `xml:"text"`
+			Paths []string `xml:"path"`
+		}
+		if err := xml.Unmarshal(overview, &doc); err != nil {
+			t.Fatalf("overview is not valid XML: %v", err)
+		}
+		if doc.Width != overviewWidth || len(doc.Texts) != 30 || len(doc.Paths) != 1 {
+			t.Errorf("expected width %d with 30 labels and 1 wave, got %d, %d and %d", overviewWidth, doc.Width, len(doc.Texts), len(doc.Paths))
+		}
+		if doc.Texts[1] != "C4" || doc.Texts[2] != "Slice 01" {
+			t.Errorf("unexpected first slice labels %q", doc.Texts[:3])
+		}
+
+		doc.Texts, doc.Paths = nil, nil
+		if err := xml.Unmarshal(thumbnails, &doc); err != nil {
+			t.Fatalf("thumbnail grid is not valid XML: %v", err)
+		}
+		if len(doc.Texts) != 20 || len(doc.Paths) != 10 {
+			t.Errorf("expected 20 labels and 10 waves, got %d and %d", len(doc.Texts), len(doc.Paths))
+		}
+	})
+}
+
+func TestWriteWaveforms(t *testing.T) {
+	dir := t.TempDir()
+	layout := BatchLayout{Path: filepath.Join(dir, "kit.wav"), SampleRate: 44100, Channels: 1, SliceLength: 10, Slots: make([]Slot, 2)}
+	writeWavFile(layout.Path, [][]float64{make([]float64, 20)}, 44100, 1)
+
+	paths, err := writeWaveforms(layout, WaveformBoth)
+	if err != nil {
+		t.Fatalf("writeWaveforms failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "kit_waveform.png"), filepath.Join(dir, "kit_slices.png"),
+		filepath.Join(dir, "kit_waveform.svg"), filepath.Join(dir, "kit_slices.svg"),
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// xmlWriter builds indented XML documents for sampler presets. Attributes are
+// given as name/value pairs and escaped; element names are trusted.
+type xmlWriter struct {
+	b      strings.Builder
+	depth  int
+	indent string
+}
+
+// newXMLWriter starts a document with the XML declaration
+func newXMLWriter(indent string) *xmlWriter {
+	w := &xmlWriter{indent: indent}
+	w.b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	return w
+}
+
+// startTag writes an indented tag up to and including end, without a newline
+func (w *xmlWriter) startTag(name string, attrs []any, end string) {
+	w.b.WriteString(strings.Repeat(w.indent, w.depth))
+	w.b.WriteString("<" + name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(&w.b, ` %v="%s"`, attrs[i], xmlEscape(fmt.Sprint(attrs[i+1])))
+	}
+	w.b.WriteString(end)
+}
+
+func (w *xmlWriter) tag(name string, attrs []any, end string) {
+	w.startTag(name, attrs, end)
+	w.b.WriteString("\n")
+}
+
+// open starts an element that has children
+func (w *xmlWriter) open(name string, attrs ...any) {
+	w.tag(name, attrs, ">")
+	w.depth++
+}
+
+// close ends the innermost open element
+func (w *xmlWriter) close(name string) {
+	w.depth--
+	w.b.WriteString(strings.Repeat(w.indent, w.depth) + "</" + name + ">\n")
+}
+
+// empty writes a self-closing element
+func (w *xmlWriter) empty(name string, attrs ...any) {
+	w.tag(name, attrs, " />")
+}
+
+// value writes <name Value="v" />, the form Ableton uses for every property
+func (w *xmlWriter) value(name string, v any) {
+	w.empty(name, "Value", v)
+}
+
+// text writes an element holding only character data
+func (w *xmlWriter) text(name string, v any, attrs ...any) {
+	w.startTag(name, attrs, ">")
+	fmt.Fprintf(&w.b, "%s</%s>\n", xmlEscape(fmt.Sprint(v)), name)
+}
+
+// comment writes an XML comment; "--" is not allowed inside one
+func (w *xmlWriter) comment(text string) {
+	text = strings.ReplaceAll(text, "--", "- -")
+	w.b.WriteString(strings.Repeat(w.indent, w.depth) + "<!-- " + text + " -->\n")
+}
+
+func (w *xmlWriter) String() string {
+	return w.b.String()
+}
+
+// xmlEscape escapes text for use in an attribute value
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}